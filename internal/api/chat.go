@@ -0,0 +1,246 @@
+// internal/api/chat.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/internal/rag"
+	"github.com/deepwiki-go/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultChatHistoryTokens = 4000 // 随问题发送的历史对话的默认 token 上限
+	defaultChatMemoryTurns   = 50   // 检索时默认参考的最近对话轮数
+	chatRewriteMessages      = 6    // 改写检索查询时参考的最近消息数
+	chatRewriteTimeout       = 30 * time.Second
+	chatSummaryTimeout       = 60 * time.Second
+	maxRewrittenQueryLength  = 500
+)
+
+// chatConversation 是整理后的聊天对话
+type chatConversation struct {
+	Question string               // 当前的用户问题
+	History  []models.ChatMessage // 随问题发送的较近对话
+	Summary  string               // 超出预算的较早对话的摘要
+}
+
+// chatTurn 是一次聊天回答的输入
+type chatTurn struct {
+	RepoURL  string               // 检索的仓库，为空时不检索
+	Ref      string               // 生成引用链接使用的分支、标签或提交
+	Question string               // 当前的用户问题
+	History  []models.ChatMessage // 问题之前的对话
+	File     *chatFile            // 用户正在查看的文件，为空时只使用检索结果
+	// OnAnswer 在回答完整生成后调用，例如保存到会话；返回错误时向客户端发送 error 事件
+	OnAnswer func(answer models.ChatMessage) error
+}
+
+// streamChatAnswer 整理对话、检索相关代码，并以 SSE 流式返回回答
+// 依次发送 message、citations 和 done 事件，失败时发送 error 事件
+func (s *Server) streamChatAnswer(c *gin.Context, ctx context.Context, provider rag.RAGProvider, turn chatTurn) {
+	conv := s.prepareConversation(ctx, provider, turn.Question, turn.History)
+
+	// 用户正在查看的文件排在最前面，之后是检索结果
+	var docs []models.Document
+	var fileLabel string
+	if turn.File != nil {
+		docs = turn.File.Context.Documents()
+		fileLabel = turn.File.Context.Label()
+	}
+
+	// 检索相关代码并编号，便于回答中引用来源；追问结合对话改写为独立的查询，
+	// 并参考本次对话（而不是提供者全局的 Memory）调整检索结果
+	if turn.RepoURL != "" {
+		query := s.retrievalQuery(ctx, provider, conv)
+		if turn.File != nil {
+			query += "\n" + turn.File.Context.Path
+		}
+		memory := rag.NewMemoryFromMessages(turn.History, s.chatMemoryTurns())
+		retrieved, err := rag.RetrieveWithMemory(provider, query, memory)
+		if err != nil {
+			log.Printf("检索相关文档失败: %v", err)
+		} else if turn.File != nil {
+			retrieved = turn.File.focusDocuments(retrieved)
+		}
+		docs = append(docs, retrieved...)
+	}
+	var codeContext string
+	var sources []models.Citation
+	if len(docs) > 0 {
+		codeContext, sources = rag.NumberDocuments(docs, 1, 0, s.sourceLabel(ctx))
+	}
+	prompt, err := s.renderPrompt(ctx, "chat", prompts.Data{Context: codeContext, Question: turn.Question, Summary: conv.Summary, File: fileLabel})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成提示词失败: %v", err)})
+		return
+	}
+
+	// 设置内容类型为SSE
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	// 创建一个SSE流
+	clientGone := c.Stream(func(w io.Writer) bool {
+		// 以完整的对话获取AI回复
+		responseCh, errCh, err := rag.StreamChat(c.Request.Context(), provider, conv.messages(prompt))
+		if err != nil {
+			c.SSEvent("error", gin.H{"error": fmt.Sprintf("生成失败: %v", err)})
+			return false
+		}
+
+		// 流式传输响应
+		var answer strings.Builder
+		for chunk := range responseCh {
+			answer.WriteString(chunk)
+			c.SSEvent("message", chunk)
+		}
+		if err := <-errCh; err != nil {
+			c.SSEvent("error", gin.H{"error": fmt.Sprintf("生成失败: %v", err)})
+			return false
+		}
+
+		// 发送回答中引用的来源
+		citations := rag.ParseCitations(answer.String(), sources)
+		if len(citations) > 0 {
+			citations = rag.ResolveCitations(citations, turn.RepoURL, turn.Ref)
+			c.SSEvent("citations", citations)
+		}
+		if turn.OnAnswer != nil {
+			reply := models.ChatMessage{Role: rag.RoleAssistant, Content: answer.String(), Citations: citations}
+			if err := turn.OnAnswer(reply); err != nil {
+				c.SSEvent("error", gin.H{"error": err.Error()})
+				return false
+			}
+		}
+
+		// 发送完成事件
+		c.SSEvent("done", nil)
+		return false
+	})
+
+	if !clientGone {
+		// 客户端断开连接
+		c.AbortWithStatus(http.StatusOK)
+	}
+}
+
+// parseConversation 检查消息角色，返回最后一条用户消息和它之前的对话
+func parseConversation(messages []models.ChatMessage) (string, []models.ChatMessage, error) {
+	for i, msg := range messages {
+		if msg.Role != rag.RoleUser && msg.Role != rag.RoleAssistant {
+			return "", nil, fmt.Errorf("第 %d 条消息的角色无效: %q", i+1, msg.Role)
+		}
+	}
+	if len(messages) == 0 {
+		return "", nil, errors.New("未找到用户消息")
+	}
+	last := messages[len(messages)-1]
+	if last.Role != rag.RoleUser || strings.TrimSpace(last.Content) == "" {
+		return "", nil, errors.New("未找到用户消息")
+	}
+	return last.Content, messages[:len(messages)-1], nil
+}
+
+// chatHistoryTokens 返回历史对话的 token 上限，负数表示不发送历史
+func (s *Server) chatHistoryTokens() int {
+	if s.config == nil || s.config.Chat.HistoryTokens == 0 {
+		return defaultChatHistoryTokens
+	}
+	return s.config.Chat.HistoryTokens
+}
+
+// chatMemoryTurns 返回检索时参考的最近对话轮数
+func (s *Server) chatMemoryTurns() int {
+	if s.config == nil || s.config.Chat.MemoryTurns <= 0 {
+		return defaultChatMemoryTurns
+	}
+	return s.config.Chat.MemoryTurns
+}
+
+// prepareConversation 按 token 预算保留最近的对话，超出预算的较早对话按配置生成摘要或直接舍弃
+// 摘要失败时退回到舍弃
+func (s *Server) prepareConversation(ctx context.Context, provider rag.RAGProvider, question string, history []models.ChatMessage) *chatConversation {
+	conv := &chatConversation{Question: question}
+	budget := s.chatHistoryTokens()
+	if budget < 0 {
+		return conv
+	}
+
+	kept, dropped := rag.TrimHistory(history, budget, utils.EstimateTokens)
+	conv.History = kept
+	if len(dropped) == 0 || (s.config != nil && s.config.Chat.Overflow == "truncate") {
+		return conv
+	}
+
+	summaryCtx, cancel := context.WithTimeout(ctx, chatSummaryTimeout)
+	defer cancel()
+	prompt, err := s.renderPrompt(summaryCtx, "chat_summary", prompts.Data{History: rag.FormatConversation(dropped)})
+	if err == nil {
+		conv.Summary, err = rag.GenerateText(summaryCtx, provider, prompt)
+	}
+	if err != nil {
+		log.Printf("生成对话摘要失败，舍弃较早的 %d 条消息: %v", len(dropped), err)
+		conv.Summary = ""
+	}
+	conv.Summary = strings.TrimSpace(conv.Summary)
+	return conv
+}
+
+// retrievalQuery 结合对话把追问改写为独立的检索查询，例如“那它是怎么测试的？”
+// 没有历史对话、未启用改写或改写失败时使用原问题
+func (s *Server) retrievalQuery(ctx context.Context, provider rag.RAGProvider, conv *chatConversation) string {
+	if len(conv.History) == 0 || s.config == nil || !s.config.Chat.RewriteQueries {
+		return conv.Question
+	}
+	recent := conv.History
+	if len(recent) > chatRewriteMessages {
+		recent = recent[len(recent)-chatRewriteMessages:]
+	}
+
+	rewriteCtx, cancel := context.WithTimeout(ctx, chatRewriteTimeout)
+	defer cancel()
+	prompt, err := s.renderPrompt(rewriteCtx, "query_rewrite", prompts.Data{History: rag.FormatConversation(recent), Question: conv.Question})
+	if err != nil {
+		log.Printf("渲染检索查询改写提示词失败: %v", err)
+		return conv.Question
+	}
+	text, err := rag.GenerateText(rewriteCtx, provider, prompt)
+	if err != nil {
+		log.Printf("改写检索查询失败，使用原问题: %v", err)
+		return conv.Question
+	}
+	query := cleanRewrittenQuery(text)
+	if query == "" || len(query) > maxRewrittenQueryLength {
+		return conv.Question
+	}
+	return query
+}
+
+// cleanRewrittenQuery 取改写结果的第一行非空文本，并去掉包裹的引号
+func cleanRewrittenQuery(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			return strings.Trim(line, "\"'“”`")
+		}
+	}
+	return ""
+}
+
+// messages 返回发送给模型的对话：保留的历史对话加上包含检索上下文的当前提示词
+func (conv *chatConversation) messages(prompt string) []models.ChatMessage {
+	messages := make([]models.ChatMessage, 0, len(conv.History)+1)
+	messages = append(messages, conv.History...)
+	return append(messages, models.ChatMessage{Role: rag.RoleUser, Content: prompt})
+}
@@ -0,0 +1,93 @@
+// internal/api/chat_files.go
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/rag"
+)
+
+// defaultChatFileChars 是固定的文件完整发送的默认最大字符数
+const defaultChatFileChars = 24000
+
+// chatFile 是用户针对提问的文件，以及与它相关的包
+type chatFile struct {
+	Context *data.FileContext
+	Related *data.RelatedFiles // 查找失败时为 nil，此时不调整检索结果的顺序
+}
+
+// chatFileChars 返回固定的文件完整发送的最大字符数
+func (s *Server) chatFileChars() int {
+	if s.config == nil || s.config.Chat.FileChars <= 0 {
+		return defaultChatFileChars
+	}
+	return s.config.Chat.FileChars
+}
+
+// errRemoteRepoRequired 表示指定文件时仓库地址不是远程仓库
+var errRemoteRepoRequired = errors.New("指定文件时 repo_url 必须是 http 或 https 仓库地址")
+
+// chatRepoPath 返回远程仓库在服务器上的克隆路径，使用已有的克隆或重新克隆
+// 只接受 http 和 https 地址，不接受服务器上的本地目录，因此只能读取仓库管理器克隆的仓库
+func (s *Server) chatRepoPath(repoURL, accessToken string) (string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errRemoteRepoRequired
+	}
+	return data.NewRepositoryManager(s.config).CloneRepository(repoURL, accessToken)
+}
+
+// checkoutChatRepo 在独立的工作树中检出远程仓库的 ref（为空时为克隆当前的 HEAD），返回工作树路径和提交
+func (s *Server) checkoutChatRepo(repoURL, accessToken, ref string) (string, string, error) {
+	repoPath, err := s.chatRepoPath(repoURL, accessToken)
+	if err != nil {
+		return "", "", err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	worktree, commit, err := data.CheckoutWorktree(repoPath, ref)
+	if err != nil {
+		return "", "", fmt.Errorf("检出 %s 失败: %v", ref, err)
+	}
+	return worktree, commit, nil
+}
+
+// pinChatFile 读取用户正在查看的文件（或其中的行范围），并查找它所在的包、导入的包和导入它的包
+func (s *Server) pinChatFile(repoPath, filePath string, startLine, endLine int, question string) (*chatFile, error) {
+	file, err := data.ReadFileContext(repoPath, filePath, startLine, endLine, question, s.chatFileChars())
+	if err != nil {
+		return nil, err
+	}
+	related, err := data.FindRelatedFiles(repoPath, file.Path)
+	if err != nil {
+		log.Printf("查找 %s 的相关文件失败: %v", file.Path, err)
+		related = nil
+	}
+	return &chatFile{Context: file, Related: related}, nil
+}
+
+// focusDocuments 去掉已经包含在固定文件中的检索结果，并将同一个包、导入和被导入的包中的结果排在前面
+func (f *chatFile) focusDocuments(docs []models.Document) []models.Document {
+	focused := make([]models.Document, 0, len(docs))
+	for _, doc := range docs {
+		source := rag.SourceFromDocument(doc)
+		if f.Context.Covers(source.FilePath, source.StartLine, source.EndLine) {
+			continue
+		}
+		focused = append(focused, doc)
+	}
+	if f.Related == nil {
+		return focused
+	}
+	sort.SliceStable(focused, func(i, j int) bool {
+		return f.Related.Weight(rag.SourceFromDocument(focused[i]).FilePath) > f.Related.Weight(rag.SourceFromDocument(focused[j]).FilePath)
+	})
+	return focused
+}
@@ -0,0 +1,238 @@
+// internal/api/chat_sessions.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/rag"
+	"github.com/gin-gonic/gin"
+)
+
+// anonymousChatOwner 是未认证请求创建的会话所属的用户
+const anonymousChatOwner = "anonymous"
+
+// maxChatTitleLength 是由第一个问题生成的会话标题的最大字符数
+const maxChatTitleLength = 60
+
+// createChatSessionRequest 表示创建聊天会话的请求
+type createChatSessionRequest struct {
+	RepoURL  string `json:"repo_url" binding:"required"`
+	Ref      string `json:"ref,omitempty"`      // 分支、标签或提交，为空时使用仓库当前检出的版本
+	Title    string `json:"title,omitempty"`    // 为空时使用第一个问题
+	Language string `json:"language,omitempty"` // 回答语言，默认使用配置的语言
+}
+
+// chatSessionMessageRequest 表示向会话发送的一条消息
+type chatSessionMessageRequest struct {
+	Content     string `json:"content" binding:"required"`
+	Language    string `json:"language,omitempty"`   // 本次回答的语言，默认使用会话的语言
+	FilePath    string `json:"file_path,omitempty"`  // 用户正在查看的文件，其内容固定在检索结果之前
+	StartLine   int    `json:"start_line,omitempty"` // 文件的起始行，从 1 开始，0 表示整个文件
+	EndLine     int    `json:"end_line,omitempty"`   // 文件的结束行，0 表示到文件末尾
+	GitHubToken string `json:"github_token,omitempty"`
+	GitLabToken string `json:"gitlab_token,omitempty"`
+}
+
+// chatOwner 返回当前请求的用户ID，未经认证的请求共用匿名用户
+func chatOwner(c *gin.Context) string {
+	if owner := c.GetString("user_id"); owner != "" {
+		return owner
+	}
+	return anonymousChatOwner
+}
+
+// handleCreateChatSession 为当前用户创建绑定到仓库和引用的聊天会话
+func (s *Server) handleCreateChatSession(c *gin.Context) {
+	var req createChatSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
+		return
+	}
+	lang, err := s.requestLanguage(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session := &models.ChatSession{
+		Owner:    chatOwner(c),
+		RepoURL:  req.RepoURL,
+		Ref:      req.Ref,
+		Title:    strings.TrimSpace(req.Title),
+		Language: lang,
+	}
+	if err := s.chats.Create(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建聊天会话失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+// handleListChatSessions 列出当前用户的聊天会话，可以用 repo_url 参数筛选仓库
+func (s *Server) handleListChatSessions(c *gin.Context) {
+	sessions, err := s.chats.List(chatOwner(c), c.Query("repo_url"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取聊天会话失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// handleGetChatSession 返回当前用户的某个会话及其全部消息
+func (s *Server) handleGetChatSession(c *gin.Context) {
+	session, err := s.chats.Get(chatOwner(c), c.Param("id"))
+	if err != nil {
+		respondChatSessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// handleDeleteChatSession 删除当前用户的某个会话
+func (s *Server) handleDeleteChatSession(c *gin.Context) {
+	if err := s.chats.Delete(chatOwner(c), c.Param("id")); err != nil {
+		respondChatSessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": c.Param("id")})
+}
+
+// handleChatSessionMessage 向会话发送一个问题，以会话中的全部消息作为对话历史流式返回回答
+// 回答完整生成后，问题和回答一起追加到会话中；生成失败时会话保持不变
+func (s *Server) handleChatSessionMessage(c *gin.Context) {
+	var req chatSessionMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "消息内容为空"})
+		return
+	}
+	owner := chatOwner(c)
+	session, err := s.chats.Get(owner, c.Param("id"))
+	if err != nil {
+		respondChatSessionError(c, err)
+		return
+	}
+	if req.Language == "" {
+		req.Language = session.Language
+	}
+	lang, err := s.requestLanguage(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := withLanguage(c.Request.Context(), lang)
+
+	provider, err := s.manager.GetActiveProvider()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 RAG 提供者失败: %v", err)})
+		return
+	}
+	accessToken := req.GitHubToken
+	if accessToken == "" {
+		accessToken = req.GitLabToken
+	}
+	repoPath, ref, err := s.prepareSessionRetriever(ctx, provider, session, accessToken)
+	if errors.Is(err, errRemoteRepoRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("准备仓库失败: %v", err)})
+		return
+	}
+	var file *chatFile
+	if req.FilePath != "" {
+		if file, err = s.pinChatFile(repoPath, req.FilePath, req.StartLine, req.EndLine, req.Content); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	asked := time.Now().UTC()
+	question := models.ChatMessage{Role: rag.RoleUser, Content: req.Content, CreatedAt: &asked}
+	s.streamChatAnswer(c, ctx, provider, chatTurn{
+		RepoURL:  session.RepoURL,
+		Ref:      ref,
+		Question: req.Content,
+		History:  session.Messages,
+		File:     file,
+		OnAnswer: func(answer models.ChatMessage) error {
+			answered := time.Now().UTC()
+			answer.CreatedAt = &answered
+			_, err := s.chats.Update(owner, session.ID, func(session *models.ChatSession) error {
+				if session.Title == "" {
+					session.Title = chatTitle(req.Content)
+				}
+				session.Messages = append(session.Messages, question, answer)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("保存聊天会话失败: %v", err)
+			}
+			return nil
+		},
+	})
+}
+
+// prepareSessionRetriever 在独立的工作树中检出会话固定的版本，并为其准备检索器
+// 第一条消息时将 ref（为空时为仓库当前检出的版本）解析为提交并保存到会话中，之后的消息都使用该提交；
+// 检索器当前已为该工作树建立索引时不再重复索引。返回工作树路径和生成引用链接使用的提交
+func (s *Server) prepareSessionRetriever(ctx context.Context, provider rag.RAGProvider, session *models.ChatSession, accessToken string) (string, string, error) {
+	ref := session.Commit
+	if ref == "" {
+		ref = session.Ref
+	}
+	worktree, commit, err := s.checkoutChatRepo(session.RepoURL, accessToken, ref)
+	if err != nil {
+		return "", "", err
+	}
+	if session.Commit == "" {
+		if _, err := s.chats.Update(session.Owner, session.ID, func(stored *models.ChatSession) error {
+			stored.Commit = commit
+			return nil
+		}); err != nil {
+			return "", "", fmt.Errorf("保存聊天会话失败: %v", err)
+		}
+		session.Commit = commit
+	}
+
+	// 检索器由所有请求共享，只有它当前的索引正是该工作树时才跳过索引
+	if !s.retrieverIndexed(provider, worktree) {
+		if err := s.prepareRetriever(ctx, provider, worktree, accessToken); err != nil {
+			return "", "", err
+		}
+	}
+	return worktree, commit, nil
+}
+
+// respondChatSessionError 返回读取或删除会话失败时的错误响应
+func respondChatSessionError(c *gin.Context, err error) {
+	if errors.Is(err, data.ErrChatSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// chatTitle 由第一个问题生成会话标题：取第一行，过长时截断
+func chatTitle(question string) string {
+	title := strings.TrimSpace(question)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	if utf8.RuneCountInString(title) > maxChatTitleLength {
+		title = string([]rune(title)[:maxChatTitleLength]) + "…"
+	}
+	return title
+}
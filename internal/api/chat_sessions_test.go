@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/rag"
+	"github.com/gin-gonic/gin"
+)
+
+// countingProvider 记录检索器准备过的路径，其他方法不做任何事
+type countingProvider struct {
+	rag.RAGProvider
+	prepared []string
+}
+
+func (p *countingProvider) PrepareRetriever(repoURLOrPath string, accessToken string) error {
+	p.prepared = append(p.prepared, repoURLOrPath)
+	return nil
+}
+
+// runGit 在目录中执行 git 命令，失败时终止测试
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return string(output)
+}
+
+func TestChatSessionsRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{
+		router: gin.New(),
+		config: &config.Config{Auth: config.AuthConfig{EnableJWT: true}},
+		chats:  data.NewChatStore(&config.Config{Chat: config.ChatConfig{StorePath: t.TempDir()}}),
+	}
+	s.setupRoutes()
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/chat/sessions"},
+		{http.MethodGet, "/chat/sessions"},
+		{http.MethodGet, "/chat/sessions/abc"},
+		{http.MethodDelete, "/chat/sessions/abc"},
+		{http.MethodPost, "/chat/sessions/abc/messages"},
+	} {
+		if w := serve(s, tc.method, tc.path, `{}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected 401 without a token, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
+
+func TestPrepareSessionRetrieverPinsCommit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// 远程仓库和服务器上已有的克隆
+	origin := t.TempDir()
+	runGit(t, origin, "init", "--quiet", "-b", "main")
+	os.WriteFile(filepath.Join(origin, "main.go"), []byte("package main\n"), 0644)
+	runGit(t, origin, "add", "-A")
+	runGit(t, origin, "commit", "--quiet", "-m", "first")
+	clone := filepath.Join(home, ".deepwiki", "repos", "github.com_owner_repo")
+	runGit(t, home, "clone", "--quiet", origin, clone)
+
+	s := &Server{
+		config: &config.Config{},
+		chats:  data.NewChatStore(&config.Config{Chat: config.ChatConfig{StorePath: t.TempDir()}}),
+	}
+	session := &models.ChatSession{Owner: anonymousChatOwner, RepoURL: "https://github.com/owner/repo"}
+	if err := s.chats.Create(session); err != nil {
+		t.Fatal(err)
+	}
+	provider := &countingProvider{}
+
+	worktree, commit, err := s.prepareSessionRetriever(context.Background(), provider, session, "")
+	if err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	if worktree == clone || len(provider.prepared) != 1 || provider.prepared[0] != worktree {
+		t.Fatalf("expected the session to be indexed once in its own worktree, got %q %v", worktree, provider.prepared)
+	}
+	stored, _ := s.chats.Get(anonymousChatOwner, session.ID)
+	if stored.Commit != commit {
+		t.Errorf("pinned commit not saved: %q != %q", stored.Commit, commit)
+	}
+
+	// 克隆前进后，会话仍然使用固定的提交，且不会重新索引
+	os.WriteFile(filepath.Join(clone, "other.go"), []byte("package main\n"), 0644)
+	runGit(t, clone, "add", "-A")
+	runGit(t, clone, "commit", "--quiet", "-m", "second")
+	_, again, err := s.prepareSessionRetriever(context.Background(), provider, stored, "")
+	if err != nil {
+		t.Fatalf("prepare again: %v", err)
+	}
+	if again != commit || len(provider.prepared) != 1 {
+		t.Errorf("expected pinned commit %s without re-indexing, got %s after %d preparations", commit, again, len(provider.prepared))
+	}
+
+	// 其他请求替换了共享的索引后，会话重新索引自己的工作树
+	if err := s.prepareRetriever(context.Background(), provider, clone, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.prepareSessionRetriever(context.Background(), provider, stored, ""); err != nil {
+		t.Fatalf("prepare after another index: %v", err)
+	}
+	if len(provider.prepared) != 3 || provider.prepared[2] != worktree {
+		t.Errorf("expected the session worktree to be indexed again, got %v", provider.prepared)
+	}
+	// 另一个提供者的索引同样使会话的索引失效
+	if _, _, err := s.prepareSessionRetriever(context.Background(), &countingProvider{}, stored, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.prepareSessionRetriever(context.Background(), provider, stored, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.prepared) != 4 {
+		t.Errorf("expected re-indexing after another provider indexed, got %v", provider.prepared)
+	}
+}
+
+func TestChatRepoPathRejectsLocalPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := &Server{config: &config.Config{}}
+	for _, repoURL := range []string{t.TempDir(), "/etc", "file:///etc", "../repo", "ssh://host/repo", "https://"} {
+		if _, err := s.chatRepoPath(repoURL, ""); !errors.Is(err, errRemoteRepoRequired) {
+			t.Errorf("%s: expected errRemoteRepoRequired, got %v", repoURL, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// internal/api/diagrams.go
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/internal/rag"
+	"github.com/deepwiki-go/pkg/mermaid"
+)
+
+// defaultDiagramRepairs 是每个无效图表请模型修复的默认次数
+const defaultDiagramRepairs = 2
+
+// diagramRepairs 从配置中读取修复次数，负数表示不修复
+func (s *Server) diagramRepairs() int {
+	if s.config == nil || s.config.Wiki.DiagramRepairs == 0 {
+		return defaultDiagramRepairs
+	}
+	if s.config.Wiki.DiagramRepairs < 0 {
+		return 0
+	}
+	return s.config.Wiki.DiagramRepairs
+}
+
+// repairPageDiagrams 校验页面中的 Mermaid 图表，将无效的图表连同解析错误交给模型修复
+// 多次修复仍无效的图表改为普通代码块，避免前端渲染失败
+func (s *Server) repairPageDiagrams(ctx context.Context, provider rag.RAGProvider, content string, timeout time.Duration) (string, *models.DiagramReport) {
+	blocks := mermaid.ExtractBlocks(content)
+	if len(blocks) == 0 {
+		return content, nil
+	}
+
+	report := &models.DiagramReport{Total: len(blocks)}
+	maxRepairs := s.diagramRepairs()
+
+	var repaired strings.Builder
+	last := 0
+	for _, block := range blocks {
+		code := block.Code
+		err := mermaid.Validate(code)
+		for attempt := 1; err != nil && attempt <= maxRepairs && provider != nil && ctx.Err() == nil; attempt++ {
+			fixed, repairErr := s.repairDiagram(ctx, provider, code, err, timeout)
+			if repairErr != nil {
+				log.Printf("修复 Mermaid 图表失败: %v", repairErr)
+				break
+			}
+			code = fixed
+			err = mermaid.Validate(code)
+			if err == nil {
+				report.Repaired++
+			}
+		}
+
+		if err == nil {
+			repaired.WriteString(content[last:block.Start])
+			repaired.WriteString(code)
+		} else {
+			report.Invalid++
+			report.Errors = append(report.Errors, err.Error())
+			repaired.WriteString(content[last:block.Open])
+			repaired.WriteString("```text\n")
+			repaired.WriteString(block.Code)
+		}
+		last = block.End
+	}
+	repaired.WriteString(content[last:])
+	return repaired.String(), report
+}
+
+// repairDiagram 请模型根据解析错误修复单个图表，返回修复后的图表代码
+func (s *Server) repairDiagram(ctx context.Context, provider rag.RAGProvider, code string, validateErr error, timeout time.Duration) (string, error) {
+	prompt, err := s.renderPrompt(ctx, "diagram_repair", prompts.Data{Error: validateErr.Error(), Code: strings.TrimSpace(code)})
+	if err != nil {
+		return "", err
+	}
+
+	repairCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := rag.GenerateText(repairCtx, provider, prompt)
+	if err != nil {
+		return "", err
+	}
+	if blocks := mermaid.ExtractBlocks(response); len(blocks) > 0 {
+		return blocks[0].Code, nil
+	}
+	// 模型没有使用代码块时，按原样视为图表代码
+	return strings.TrimSpace(response) + "\n", nil
+}
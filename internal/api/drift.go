@@ -0,0 +1,237 @@
+// internal/api/drift.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/rag"
+	"github.com/gin-gonic/gin"
+)
+
+// handleWikiStaleness 返回已保存 Wiki 相对于新提交的过期报告
+// 查询参数 to 指定比较的引用或提交，默认为 Wiki 所在引用的最新提交；
+// 私有仓库通过 github_token 或 gitlab_token 查询参数提供访问令牌
+func (s *Server) handleWikiStaleness(c *gin.Context) {
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+
+	accessToken := c.Query("github_token")
+	if accessToken == "" {
+		accessToken = c.Query("gitlab_token")
+	}
+
+	repoManager := data.NewRepositoryManager(s.config)
+	repoPath, err := repoManager.CloneRepository(wiki.RepoURL, accessToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("克隆仓库失败: %v", err)})
+		return
+	}
+	if err := data.FetchRefs(repoPath); err != nil {
+		log.Printf("获取远程引用失败: %v", err)
+	}
+
+	report, err := detectWikiDrift(wiki, repoPath, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("检测过期页面失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// refreshWikiRequest 表示刷新过期页面的请求
+type refreshWikiRequest struct {
+	To          string `json:"to,omitempty"`        // 目标引用或提交，默认为 Wiki 所在引用的最新提交
+	Overwrite   bool   `json:"overwrite,omitempty"` // 是否重新生成人工编辑过的过期页面
+	GitHubToken string `json:"github_token,omitempty"`
+	GitLabToken string `json:"gitlab_token,omitempty"`
+}
+
+// handleRefreshWiki 以后台任务只重新生成过期的页面，结果保存为新提交上的 Wiki
+func (s *Server) handleRefreshWiki(c *gin.Context) {
+	var req refreshWikiRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
+		return
+	}
+
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+
+	// 提前检查 RAG 提供者，避免创建注定失败的任务
+	if _, err := s.manager.GetActiveProvider(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 RAG 提供者失败: %v", err)})
+		return
+	}
+
+	job := s.jobs.Start("wiki_refresh", func(ctx context.Context, job *Job) (interface{}, error) {
+		return s.runWikiRefresh(ctx, job, wiki, req)
+	})
+	respondJobAccepted(c, job)
+}
+
+// runWikiRefresh 检测过期页面，在新提交上重新生成它们，其余页面原样保留
+func (s *Server) runWikiRefresh(ctx context.Context, reporter progressReporter, wiki *models.Wiki, req refreshWikiRequest) (interface{}, error) {
+	ctx = withLanguage(ctx, wiki.Language)
+	provider, err := s.manager.GetActiveProvider()
+	if err != nil {
+		return nil, fmt.Errorf("获取 RAG 提供者失败: %v", err)
+	}
+
+	accessToken := req.GitHubToken
+	if accessToken == "" {
+		accessToken = req.GitLabToken
+	}
+
+	repoManager := data.NewRepositoryManager(s.config)
+
+	reporter.SetPhase(JobPhaseCloning, wiki.RepoURL)
+	repoPath, err := repoManager.CloneRepository(wiki.RepoURL, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("克隆仓库失败: %v", err)
+	}
+	if err := data.FetchRefs(repoPath); err != nil {
+		log.Printf("获取远程引用失败: %v", err)
+	}
+
+	reporter.SetPhase(JobPhaseAnalyzing, "")
+	report, err := detectWikiDrift(wiki, repoPath, req.To)
+	if err != nil {
+		return nil, fmt.Errorf("检测过期页面失败: %v", err)
+	}
+
+	// 区分需要刷新的页面和因人工编辑而跳过的页面
+	var staleIDs, skipped []string
+	for _, stale := range report.StalePages {
+		if stale.HumanAuthored && !req.Overwrite {
+			skipped = append(skipped, stale.PageID)
+			continue
+		}
+		staleIDs = append(staleIDs, stale.PageID)
+	}
+	if len(staleIDs) == 0 {
+		return gin.H{
+			"drift":   report,
+			"skipped": skipped,
+			"message": "没有需要刷新的页面",
+		}, nil
+	}
+
+	if repoPath, _, err = data.CheckoutWorktree(repoPath, report.ToCommit); err != nil {
+		return nil, fmt.Errorf("检出 %s 失败: %v", report.ToCommit, err)
+	}
+	analysis, err := repoManager.AnalyzeRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("分析仓库失败: %v", err)
+	}
+
+	reporter.SetPhase(JobPhaseIndexing, "")
+	if err := s.prepareRetriever(ctx, provider, repoPath, accessToken); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("准备检索器失败: %v", err)
+	}
+
+	var tasks []pageTask
+	for _, id := range staleIDs {
+		task, err := s.pageTaskFor(ctx, wiki, id, analysis, repoPath, report.ToCommit, provider)
+		if err != nil {
+			log.Printf("跳过页面 %s: %v", id, err)
+			skipped = append(skipped, id)
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	reporter.SetPhase(JobPhaseGenerating, "")
+	pages, statuses := s.runPageTasks(ctx, reporter, repoPath, tasks)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// 在新提交上保存一份 Wiki，未过期的页面沿用原有内容
+	// 先以原提交记录版本，使首次修改的页面的第 1 版指向原来的生成结果
+	refreshed := &models.Wiki{
+		RepoURL:     wiki.RepoURL,
+		RepoKey:     wiki.RepoKey,
+		Ref:         wiki.Ref,
+		Commit:      wiki.Commit,
+		Provider:    provider.Name(),
+		Model:       rag.ModelName(provider),
+		Mode:        wiki.Mode,
+		Language:    wiki.Language,
+		GeneratedAt: wiki.GeneratedAt,
+		Plan:        wiki.Plan,
+		Pages:       append([]models.WikiPage(nil), wiki.Pages...),
+		PageStatus:  append([]models.PageStatus(nil), wiki.PageStatus...),
+	}
+	for id, history := range wiki.Revisions {
+		if refreshed.Revisions == nil {
+			refreshed.Revisions = make(map[string][]models.PageRevision)
+		}
+		refreshed.Revisions[id] = append([]models.PageRevision(nil), history...)
+	}
+	for _, page := range pages {
+		if current := refreshed.FindPage(page.ID); current != nil {
+			inheritPageMetadata(&page, *current)
+		}
+		refreshed.RecordRevision(page, models.PageRevision{
+			Source: models.RevisionSourceRegenerated,
+			Commit: report.ToCommit,
+		})
+	}
+	for _, status := range statuses {
+		setPageStatus(refreshed, status)
+	}
+	refreshed.Commit = report.ToCommit
+	refreshed.GeneratedAt = time.Now().UTC()
+	refreshed.PromptVersions = s.prompts.Versions(s.language(ctx))
+
+	if err := s.wikis.Save(refreshed); err != nil {
+		return nil, fmt.Errorf("保存 Wiki 失败: %v", err)
+	}
+
+	return gin.H{
+		"wiki":        refreshed.Summary(),
+		"drift":       report,
+		"page_status": statuses,
+		"skipped":     skipped,
+	}, nil
+}
+
+// detectWikiDrift 计算 Wiki 相对于目标引用的过期报告，to 为空时使用 Wiki 所在引用的最新提交
+func detectWikiDrift(wiki *models.Wiki, repoPath, to string) (*models.DriftReport, error) {
+	var toCommit string
+	var err error
+	if to != "" {
+		toCommit, err = data.ResolveCommit(repoPath, to)
+	} else {
+		toCommit, err = data.LatestCommit(repoPath, wiki.Ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data.DetectDrift(wiki, repoPath, toCommit)
+}
+
+// inheritPageMetadata 为重新生成的页面补全原页面的章节和关联页面
+func inheritPageMetadata(page *models.WikiPage, current models.WikiPage) {
+	if page.Section == "" {
+		page.Section = current.Section
+	}
+	if len(page.RelatedPages) == 0 {
+		page.RelatedPages = current.RelatedPages
+	}
+}
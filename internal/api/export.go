@@ -0,0 +1,241 @@
+// internal/api/export.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/export"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// exportWiki 将导出请求转换为导出器使用的 Wiki
+func exportWiki(req *models.WikiExportRequest) *export.Wiki {
+	return &export.Wiki{
+		Title:       req.Title,
+		Description: req.Description,
+		RepoName:    getRepoNameFromURL(req.RepoURL),
+		RepoURL:     req.RepoURL,
+		Commit:      req.Commit,
+		Language:    req.Language,
+		Pages:       req.Pages,
+		Sections:    req.Sections,
+	}
+}
+
+// storedExportWiki 读取请求指定的已保存 Wiki 并转换为导出器使用的 Wiki，失败时直接写入错误响应
+// 推送到外部系统时只导出服务端保存的内容，不使用请求中的页面；其他格式在请求未提供页面时使用
+func (s *Server) storedExportWiki(c *gin.Context, req *models.WikiExportRequest) (*export.Wiki, bool) {
+	if req.RepoURL == "" || req.WikiID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "导出已保存的 Wiki 需要提供 repo_url 和 wiki_id"})
+		return nil, false
+	}
+	wiki, err := s.wikis.Get(data.RepoKey(req.RepoURL), req.WikiID)
+	if errors.Is(err, data.ErrWikiNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wiki 不存在"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取 Wiki 失败: %v", err)})
+		return nil, false
+	}
+
+	result := &export.Wiki{
+		RepoName: getRepoNameFromURL(wiki.RepoURL),
+		RepoURL:  wiki.RepoURL,
+		Ref:      wiki.Ref,
+		Commit:   wiki.Commit,
+		Language: wiki.Language,
+		Pages:    wiki.Pages,
+	}
+	if wiki.Plan != nil {
+		result.Title = wiki.Plan.Title
+		result.Description = wiki.Plan.Description
+		result.Sections = wiki.Plan.Sections
+	}
+	return result, true
+}
+
+// mermaidScript 读取配置的本地 mermaid.min.js，未配置或读取失败时返回 nil，图表将以源码显示
+func (s *Server) mermaidScript() []byte {
+	path := s.config.Export.MermaidScript
+	if path == "" {
+		return nil
+	}
+	script, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("读取 Mermaid 脚本失败，图表将以源码显示: %v", err)
+		return nil
+	}
+	return script
+}
+
+// markdownOptions 返回 Markdown 导出选项，请求中的页眉页脚覆盖配置
+func (s *Server) markdownOptions(req *models.WikiExportRequest) export.MarkdownOptions {
+	cfg := s.config.Export.Markdown
+	opts := export.MarkdownOptions{
+		Header:      cfg.Header,
+		Footer:      cfg.Footer,
+		TOCTitle:    cfg.TOCTitle,
+		FrontMatter: req.FrontMatter,
+	}
+	if req.Header != nil {
+		opts.Header = *req.Header
+	}
+	if req.Footer != nil {
+		opts.Footer = *req.Footer
+	}
+	return opts
+}
+
+// confluenceOptions 返回配置的 Confluence 导出选项
+func (s *Server) confluenceOptions() export.ConfluenceOptions {
+	return export.ConfluenceOptions{Mermaid: s.config.Export.Confluence.Mermaid}
+}
+
+// pushConfluence 以后台任务将已保存的 Wiki 推送到配置的 Confluence 空间
+func (s *Server) pushConfluence(c *gin.Context, req *models.WikiExportRequest) {
+	cfg := s.config.Export.Confluence
+	if cfg.BaseURL == "" || cfg.SpaceKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未配置 export.confluence.base_url 或 space_key"})
+		return
+	}
+	wiki, ok := s.storedExportWiki(c, req)
+	if !ok {
+		return
+	}
+	client := &export.ConfluenceClient{
+		BaseURL:  cfg.BaseURL,
+		SpaceKey: cfg.SpaceKey,
+		Username: cfg.Username,
+		Token:    cfg.APIToken,
+		ParentID: cfg.ParentID,
+	}
+	pages := export.ConfluencePages(wiki, s.confluenceOptions())
+	commit := wiki.Commit
+
+	job := s.jobs.Start("confluence_push", func(ctx context.Context, job *Job) (interface{}, error) {
+		results, err := client.Push(ctx, pages, commit)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{"space": cfg.SpaceKey, "pages": results}, nil
+	})
+	respondJobAccepted(c, job)
+}
+
+// exportToGit 以后台任务将已保存的 Wiki 以 Markdown 提交到仓库本身
+// 默认写入默认分支的 docs/wiki 目录，orphan 为 true 时写入只包含 Wiki 的孤立分支；
+// 推送只使用请求中的访问令牌或为该仓库配置的令牌，两者都没有时拒绝推送
+func (s *Server) exportToGit(c *gin.Context, req *models.WikiExportRequest) {
+	wiki, ok := s.storedExportWiki(c, req)
+	if !ok {
+		return
+	}
+	// 提交到仓库的页面总是带头信息，便于工具读取页面元数据
+	opts := s.markdownOptions(req)
+	opts.FrontMatter = true
+	files, err := export.MarkdownFolder(wiki, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成 Markdown 文件失败: %v", err)})
+		return
+	}
+
+	accessToken := req.GitHubToken
+	if accessToken == "" {
+		accessToken = req.GitLabToken
+	}
+	cfg := s.config.Export.Git
+	target := data.GitCommitOptions{
+		Branch:      req.Branch,
+		Orphan:      req.Orphan,
+		Dir:         req.Dir,
+		Files:       files,
+		Message:     s.gitExportMessage(wiki),
+		AuthorName:  cfg.AuthorName,
+		AuthorEmail: cfg.AuthorEmail,
+	}
+	if req.Push {
+		pushToken := accessToken
+		if pushToken == "" {
+			pushToken = cfg.PushTokens[wiki.RepoURL]
+		}
+		pushURL := utils.AuthenticatedURL(wiki.RepoURL, pushToken)
+		if pushToken == "" || pushURL == wiki.RepoURL {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "推送需要提供 github_token 或 gitlab_token，或在 export.git.push_tokens 中为该仓库配置令牌"})
+			return
+		}
+		target.PushURL = pushURL
+	}
+	if target.Orphan {
+		if target.Branch == "" {
+			target.Branch = cfg.OrphanBranch
+		}
+		if target.Branch == "" {
+			target.Branch = "deepwiki"
+		}
+	} else if target.Dir == "" {
+		target.Dir = cfg.Dir
+		if target.Dir == "" {
+			target.Dir = "docs/wiki"
+		}
+	}
+
+	repoURL := wiki.RepoURL
+	job := s.jobs.Start("wiki_git_export", func(ctx context.Context, job *Job) (interface{}, error) {
+		job.SetPhase(JobPhaseCloning, repoURL)
+		repoPath, err := data.NewRepositoryManager(s.config).CloneRepository(repoURL, accessToken)
+		if err != nil {
+			return nil, fmt.Errorf("克隆仓库失败: %v", err)
+		}
+		if err := data.FetchRefs(repoPath); err != nil {
+			log.Printf("获取远程引用失败: %v", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if target.Branch == "" {
+			target.Branch = data.DefaultBranch(repoPath)
+		}
+
+		job.SetPhase(JobPhaseCommitting, target.Branch)
+		result, err := data.CommitFiles(repoPath, target)
+		if err != nil {
+			return nil, fmt.Errorf("提交 Wiki 失败: %v", err)
+		}
+		return result, nil
+	})
+	respondJobAccepted(c, job)
+}
+
+// gitExportMessage 以 Wiki 的语言生成提交说明，标题和正文中都包含源码提交
+func (s *Server) gitExportMessage(w *export.Wiki) string {
+	ctx := withLanguage(context.Background(), w.Language)
+	short := w.Commit
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	subject := s.promptText(ctx, "git_export_subject", prompts.Data{Title: w.DisplayTitle(), To: short}, "docs(wiki): update "+w.DisplayTitle())
+	lines := []string{subject, ""}
+	if w.Commit != "" {
+		lines = append(lines, s.promptText(ctx, "git_export_commit", prompts.Data{}, "Source commit")+": "+w.Commit)
+	}
+	if w.RepoURL != "" {
+		lines = append(lines, s.promptText(ctx, "git_export_repo", prompts.Data{}, "Repository")+": "+w.RepoURL)
+	}
+	if w.Language != "" {
+		lines = append(lines, s.promptText(ctx, "git_export_language", prompts.Data{}, "Language")+": "+w.Language)
+	}
+	lines = append(lines, fmt.Sprintf("%s: %d", s.promptText(ctx, "git_export_pages", prompts.Data{}, "Pages"), len(w.Pages)),
+		"", s.promptText(ctx, "git_export_footer", prompts.Data{}, "Generated by DeepWiki-Go"))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/export"
+	"github.com/deepwiki-go/internal/models"
+)
+
+func TestPushConfluenceStoredWiki(t *testing.T) {
+	var mu sync.Mutex
+	var titles []string
+	confluence := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"results":[]}`))
+			return
+		}
+		var page map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&page)
+		titles = append(titles, page["title"].(string))
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": page["title"]})
+	}))
+	defer confluence.Close()
+
+	s, wiki := newWikiTestServer(t)
+	s.jobs = NewJobManager()
+	s.config = &config.Config{Export: config.ExportConfig{Confluence: config.ConfluenceConfig{BaseURL: confluence.URL, SpaceKey: "DOCS"}}}
+	s.router.POST("/wiki/export", s.handleExportWiki)
+
+	// 推送必须指定已保存的 Wiki，请求中的页面不会被推送
+	forged := `{"repo_url": "https://github.com/owner/repo", "format": "confluence", "push": true, "pages": [{"id": "x", "title": "Forged", "content": "x"}]}`
+	if w := serve(s, http.MethodPost, "/wiki/export", forged); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without wiki_id, got %d", w.Code)
+	}
+	missing := `{"repo_url": "https://github.com/owner/repo", "format": "confluence", "push": true, "wiki_id": "nope"}`
+	if w := serve(s, http.MethodPost, "/wiki/export", missing); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown wiki, got %d", w.Code)
+	}
+
+	stored := `{"repo_url": "https://github.com/owner/repo", "format": "confluence", "push": true, "wiki_id": "` + wiki.ID + `",
+		"pages": [{"id": "x", "title": "Forged", "content": "x"}]}`
+	w := serve(s, http.MethodPost, "/wiki/export", stored)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("push not accepted: %d %s", w.Code, w.Body)
+	}
+	var accepted struct {
+		JobID string `json:"job_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &accepted)
+	job, _ := s.jobs.Get(accepted.JobID)
+	if snapshot := waitJob(t, job); snapshot.Status != JobStatusSucceeded {
+		t.Fatalf("push failed: %+v", snapshot)
+	}
+	if got := strings.Join(titles, ","); strings.Contains(got, "Forged") || !strings.Contains(got, "Overview") {
+		t.Errorf("unexpected pushed pages: %s", got)
+	}
+}
+
+func TestExportToGitStoredWiki(t *testing.T) {
+	s, wiki := newWikiTestServer(t)
+	s.jobs = NewJobManager()
+	s.config = &config.Config{}
+	s.router.POST("/wiki/export", s.handleExportWiki)
+
+	// git 导出同样只接受已保存的 Wiki
+	forged := `{"repo_url": "https://github.com/owner/repo", "format": "git", "pages": [{"id": "x", "title": "Forged", "content": "x"}]}`
+	if w := serve(s, http.MethodPost, "/wiki/export", forged); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without wiki_id, got %d", w.Code)
+	}
+
+	// 没有请求令牌也没有配置令牌时拒绝推送，不会退回到 origin 中的凭据
+	push := `{"repo_url": "https://github.com/owner/repo", "format": "git", "push": true, "wiki_id": "` + wiki.ID + `"}`
+	w := serve(s, http.MethodPost, "/wiki/export", push)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "push_tokens") {
+		t.Errorf("expected 400 for a push without credentials, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestExportStoredWikiForEveryFormat(t *testing.T) {
+	s, wiki := newWikiTestServer(t)
+	s.config = &config.Config{}
+	s.router.POST("/wiki/export", s.handleExportWiki)
+
+	if w := serve(s, http.MethodPost, "/wiki/export", `{"repo_url": "https://github.com/owner/repo", "format": "json"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without pages or wiki_id, got %d", w.Code)
+	}
+	if w := serve(s, http.MethodPost, "/wiki/export", `{"repo_url": "https://github.com/owner/repo", "format": "json", "wiki_id": "nope"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown wiki, got %d", w.Code)
+	}
+
+	// 未提供页面时导出已保存的 Wiki，而不是空文档
+	for _, format := range []string{"markdown", "json", "html", "mkdocs", "epub", "confluence"} {
+		body := `{"repo_url": "https://github.com/owner/repo", "format": "` + format + `", "wiki_id": "` + wiki.ID + `"}`
+		w := serve(s, http.MethodPost, "/wiki/export", body)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d %s", format, w.Code, w.Body)
+			continue
+		}
+		if format == "markdown" && !strings.Contains(w.Body.String(), "generated") {
+			t.Errorf("%s: stored pages not exported:\n%s", format, w.Body)
+		}
+		if format == "json" {
+			// json 导出包含元数据，可以直接重新导入
+			imported, err := export.ParseJSON(w.Body.Bytes())
+			if err != nil || imported.RepoURL != wiki.RepoURL || imported.Ref != "main" || imported.Commit != wiki.Commit || len(imported.Pages) != 2 {
+				t.Errorf("json export does not round-trip: %v %s", err, w.Body)
+			}
+		}
+	}
+}
+
+func TestGitExportMessage(t *testing.T) {
+	s := &Server{prompts: loadPrompts("")}
+	wiki := &export.Wiki{RepoName: "repo", RepoURL: "https://github.com/owner/repo", Commit: "0123456789abcdef", Language: "en",
+		Pages: []models.WikiPage{{ID: "overview"}}}
+
+	message := s.gitExportMessage(wiki)
+	if !strings.HasPrefix(message, "docs(wiki): update ") || !strings.HasSuffix(strings.SplitN(message, "\n", 2)[0], " to 0123456") ||
+		!strings.Contains(message, "Source commit: 0123456789abcdef") || !strings.Contains(message, "Pages: 1") {
+		t.Errorf("unexpected en message:\n%s", message)
+	}
+	wiki.Language = "zh"
+	if message := s.gitExportMessage(wiki); !strings.Contains(message, "源码提交: 0123456789abcdef") {
+		t.Errorf("unexpected zh message:\n%s", message)
+	}
+}
@@ -2,19 +2,23 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
-	"io"
 	"log"
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/deepwiki-go/internal/config"
 	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/export"
 	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
 	"github.com/deepwiki-go/internal/rag"
+	"github.com/deepwiki-go/pkg/mermaid"
 	"github.com/gin-gonic/gin"
 	"time"
 )
@@ -25,6 +29,50 @@ type Server struct {
 	config    *config.Config
 	manager   *rag.RAGManager
 	dbManager *data.DatabaseManager // 添加数据库管理器
+	jobs      *JobManager           // 后台任务管理器
+	wikis     *data.WikiStore       // 已生成 Wiki 的持久化存储
+	chats     *data.ChatStore       // 聊天会话的持久化存储
+	prompts   *prompts.Library      // 提示词模板
+
+	index retrieverIndex // 检索器当前索引的仓库
+}
+
+// retrieverIndex 记录检索器当前索引的仓库路径。向量数据库由所有请求共享，
+// 每次准备检索器都会替换其中的内容，因此只有最近一次完成且没有并发索引的结果是确定的
+type retrieverIndex struct {
+	mu         sync.Mutex
+	generation int // 开始过的索引次数
+	running    int // 正在进行的索引数
+	provider   rag.RAGProvider
+	path       string
+}
+
+// prepareRetriever 为仓库路径准备检索器，并记录检索器当前索引的内容
+func (s *Server) prepareRetriever(ctx context.Context, provider rag.RAGProvider, repoPath, accessToken string) error {
+	s.index.mu.Lock()
+	s.index.generation++
+	s.index.running++
+	generation := s.index.generation
+	s.index.provider, s.index.path = nil, ""
+	s.index.mu.Unlock()
+
+	err := rag.PrepareRetriever(ctx, provider, repoPath, accessToken)
+
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	s.index.running--
+	// 期间有其他索引开始或仍在进行时，数据库中的内容无法确定，不作记录
+	if err == nil && s.index.running == 0 && s.index.generation == generation {
+		s.index.provider, s.index.path = provider, repoPath
+	}
+	return err
+}
+
+// retrieverIndexed 判断检索器当前是否由该提供者为 repoPath 建立了索引
+func (s *Server) retrieverIndexed(provider rag.RAGProvider, repoPath string) bool {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	return s.index.path != "" && s.index.path == repoPath && s.index.provider == provider
 }
 
 // NewServer 创建一个新的服务器实例
@@ -73,6 +121,10 @@ func NewServer(cfg *config.Config) *Server {
 		config:    cfg,
 		manager:   manager,
 		dbManager: dbManager,
+		jobs:      NewJobManager(),
+		wikis:     data.NewWikiStore(cfg),
+		chats:     data.NewChatStore(cfg),
+		prompts:   loadPrompts(cfg.Wiki.PromptsDir),
 	}
 
 	// 注册路由
@@ -89,12 +141,37 @@ func (s *Server) setupRoutes() {
 	// 聊天完成端点
 	s.router.POST("/chat/completions/stream", s.handleChatCompletions)
 
+	// 聊天会话端点，会话属于 JWT 中的用户，启用认证时必须提供令牌
+	sessions := s.router.Group("/chat/sessions", AuthMiddleware(s.config))
+	sessions.POST("", s.handleCreateChatSession)
+	sessions.GET("", s.handleListChatSessions)
+	sessions.GET("/:id", s.handleGetChatSession)
+	sessions.DELETE("/:id", s.handleDeleteChatSession)
+	sessions.POST("/:id/messages", s.handleChatSessionMessage)
+
 	// Wiki生成端点
 	s.router.POST("/wiki/generate", s.handleGenerateWiki)
 
 	// Wiki导出端点
 	s.router.POST("/wiki/export", s.handleExportWiki)
 
+	// Wiki导入端点
+	s.router.POST("/wiki/import", s.handleImportWiki)
+
+	// 发布说明生成端点
+	s.router.POST("/wiki/changelog", s.handleGenerateChangelog)
+
+	// 已保存的 Wiki 端点
+	s.router.GET("/wikis", s.handleListWikis)
+	s.router.GET("/wikis/:repo", s.handleGetWiki)
+	s.router.GET("/wikis/:repo/pages/:pageId", s.handleGetWikiPage)
+	s.router.PUT("/wikis/:repo/pages/:pageId", s.handleEditPage)
+	s.router.POST("/wikis/:repo/pages/:pageId/regenerate", s.handleRegeneratePage)
+	s.router.GET("/wikis/:repo/pages/:pageId/revisions", s.handleListPageRevisions)
+	s.router.GET("/wikis/:repo/staleness", s.handleWikiStaleness)
+	s.router.POST("/wikis/:repo/refresh", s.handleRefreshWiki)
+	s.router.POST("/wikis/:repo/translate", s.handleTranslateWiki)
+
 	// 仓库分析端点
 	s.router.POST("/repo/analyze", s.handleAnalyzeRepo)
 
@@ -116,6 +193,12 @@ func (s *Server) setupRoutes() {
 	// 仓库同步端点
 	s.router.POST("/repo/sync", s.handleSyncRepo)
 
+	// 后台任务端点
+	s.router.GET("/jobs", s.handleListJobs)
+	s.router.GET("/jobs/:id", s.handleGetJob)
+	s.router.DELETE("/jobs/:id", s.handleCancelJob)
+	s.router.GET("/jobs/:id/events", s.handleJobEvents)
+
 	// 向量索引端点
 	s.router.POST("/vector/index", s.handleIndexVectors)
 
@@ -146,6 +229,12 @@ func (s *Server) handleChatCompletions(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
 		return
 	}
+	lang, err := s.requestLanguage(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := withLanguage(c.Request.Context(), lang)
 
 	// 获取当前活动的 RAG 提供者
 	provider, err := s.manager.GetActiveProvider()
@@ -160,70 +249,215 @@ func (s *Server) handleChatCompletions(c *gin.Context) {
 		accessToken = req.GitLabToken
 	}
 
-	// 准备仓库
+	// 最后一条用户消息是当前问题，之前的消息作为对话历史
+	question, history, err := parseConversation(req.Messages)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.FilePath != "" && req.RepoURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "指定文件时需要提供仓库 repo_url"})
+		return
+	}
+
+	// 在独立的工作树中检出仓库当前的提交并建立索引，引用链接指向该提交
+	ref := "HEAD"
+	var file *chatFile
 	if req.RepoURL != "" {
-		if err := provider.PrepareRetriever(req.RepoURL, accessToken); err != nil {
+		repoPath, commit, err := s.checkoutChatRepo(req.RepoURL, accessToken, "")
+		if errors.Is(err, errRemoteRepoRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("准备仓库失败: %v", err)})
 			return
 		}
-	}
+		ref = commit
 
-	// 获取最后一条用户消息
-	var userPrompt string
-	if len(req.Messages) > 0 {
-		lastMsg := req.Messages[len(req.Messages)-1]
-		if lastMsg.Role == "user" {
-			userPrompt = lastMsg.Content
+		// 读取用户正在查看的文件
+		if req.FilePath != "" {
+			if file, err = s.pinChatFile(repoPath, req.FilePath, req.StartLine, req.EndLine, question); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if err := s.prepareRetriever(ctx, provider, repoPath, accessToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("准备仓库失败: %v", err)})
+			return
 		}
 	}
 
-	if userPrompt == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "未找到用户消息"})
+	s.streamChatAnswer(c, ctx, provider, chatTurn{RepoURL: req.RepoURL, Ref: ref, Question: question, History: history, File: file})
+}
+
+// generateWikiRequest 表示Wiki生成请求
+type generateWikiRequest struct {
+	RepoURL     string `json:"repo_url" binding:"required"`
+	GitHubToken string `json:"github_token,omitempty"`
+	GitLabToken string `json:"gitlab_token,omitempty"`
+	Ref         string `json:"ref,omitempty"`       // 分支、标签或提交，为空时使用当前检出的版本
+	Mode        string `json:"mode,omitempty"`      // "concise" 或 "comprehensive"
+	Overwrite   bool   `json:"overwrite,omitempty"` // 是否覆盖人工编辑过的页面
+	Language    string `json:"language,omitempty"`  // 输出语言，例如 en、zh、ja，默认使用配置的语言
+}
+
+// handleGenerateWiki 处理Wiki生成请求，生成过程以后台任务执行并立即返回任务ID
+func (s *Server) handleGenerateWiki(c *gin.Context) {
+	var req generateWikiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
 		return
 	}
+	lang, err := s.requestLanguage(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Language = lang
+
+	// 提前检查 RAG 提供者，避免创建注定失败的任务
+	if _, err := s.manager.GetActiveProvider(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 RAG 提供者失败: %v", err)})
+		return
+	}
+
+	job := s.jobs.Start("wiki_generate", func(ctx context.Context, job *Job) (interface{}, error) {
+		return s.runWikiGeneration(ctx, job, req)
+	})
+	respondJobAccepted(c, job)
+}
+
+// runWikiGeneration 执行Wiki生成任务：克隆、分析、索引、规划并生成页面
+func (s *Server) runWikiGeneration(ctx context.Context, reporter progressReporter, req generateWikiRequest) (interface{}, error) {
+	ctx = withLanguage(ctx, req.Language)
+
+	// 获取当前活动的 RAG 提供者
+	provider, err := s.manager.GetActiveProvider()
+	if err != nil {
+		return nil, fmt.Errorf("获取 RAG 提供者失败: %v", err)
+	}
+
+	// 选择访问令牌
+	accessToken := req.GitHubToken
+	if accessToken == "" {
+		accessToken = req.GitLabToken
+	}
 
-	// 设置内容类型为SSE
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
+	// 初始化库管理器
+	repoManager := data.NewRepositoryManager(s.config)
+
+	// 克隆仓库
+	reporter.SetPhase(JobPhaseCloning, req.RepoURL)
+	repoPath, err := repoManager.CloneRepository(req.RepoURL, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("克隆仓库失败: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	// 创建一个SSE流
-	clientGone := c.Stream(func(w io.Writer) bool {
-		// 获取AI回复
-		responseCh, err := provider.GenerateStreamingResponse(userPrompt)
+	// 在独立的工作树中检出请求的版本，共享克隆保持在原来的分支上
+	ref := req.Ref
+	var commit string
+	if ref != "" {
+		repoPath, commit, err = data.CheckoutWorktree(repoPath, ref)
 		if err != nil {
-			c.SSEvent("error", gin.H{"error": fmt.Sprintf("生成失败: %v", err)})
-			return false
+			return nil, fmt.Errorf("检出 %s 失败: %v", ref, err)
 		}
-
-		// 流式传输响应
-		for chunk := range responseCh {
-			c.SSEvent("message", chunk)
+	} else {
+		if ref = data.CurrentBranch(repoPath); ref == "" {
+			ref = "HEAD"
+		}
+		commit, err = data.HeadCommit(repoPath)
+		if err != nil {
+			return nil, fmt.Errorf("获取当前提交失败: %v", err)
 		}
+	}
 
-		// 发送完成事件
-		c.SSEvent("done", nil)
-		return false
-	})
+	// 分析仓库结构
+	reporter.SetPhase(JobPhaseAnalyzing, "")
+	analysis, err := repoManager.AnalyzeRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("分析仓库失败: %v", err)
+	}
+
+	// 准备RAG检索器
+	reporter.SetPhase(JobPhaseIndexing, "")
+	if err := s.prepareRetriever(ctx, provider, repoPath, accessToken); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("准备检索器失败: %v", err)
+	}
 
-	if !clientGone {
-		// 客户端断开连接
-		c.AbortWithStatus(http.StatusOK)
+	// 分析 git 历史，索引时已经为当前提交分析过，这里直接复用
+	history, err := data.CachedHistory(repoPath)
+	if err != nil {
+		log.Printf("分析仓库历史失败: %v", err)
+		history = nil
 	}
+
+	// 生成Wiki页面
+	mode := normalizePlanMode(req.Mode)
+	pages, statuses, plan, err := s.generateWikiPages(ctx, reporter, analysis, history, repoPath, req.RepoURL, commit, mode)
+	if err != nil {
+		return nil, fmt.Errorf("生成Wiki失败: %v", err)
+	}
+
+	// 保存生成结果，失败时仍返回页面
+	wiki := &models.Wiki{
+		RepoURL:        req.RepoURL,
+		Ref:            ref,
+		Commit:         commit,
+		Provider:       provider.Name(),
+		Model:          rag.ModelName(provider),
+		Mode:           mode,
+		Language:       req.Language,
+		GeneratedAt:    time.Now().UTC(),
+		Plan:           plan,
+		Pages:          pages,
+		PageStatus:     statuses,
+		PromptVersions: s.prompts.Versions(req.Language),
+	}
+	if previous, err := s.wikis.Latest(data.RepoKey(req.RepoURL), ref, "", req.Language); err == nil {
+		preserveHumanEdits(wiki, previous, req.Overwrite)
+	}
+	result := gin.H{
+		"pages":       wiki.Pages,
+		"page_status": statuses,
+		"plan":        plan,
+	}
+	if err := s.wikis.Save(wiki); err != nil {
+		log.Printf("保存 Wiki 失败: %v", err)
+	} else {
+		result["wiki"] = wiki.Summary()
+	}
+	return result, nil
 }
 
-// handleGenerateWiki 处理Wiki生成请求
-func (s *Server) handleGenerateWiki(c *gin.Context) {
+// handleGenerateChangelog 处理两个引用之间的发布说明生成请求
+func (s *Server) handleGenerateChangelog(c *gin.Context) {
 	var req struct {
-		RepoURL     string `json:"repo_url"`
+		RepoURL     string `json:"repo_url" binding:"required"`
+		FromRef     string `json:"from_ref" binding:"required"`
+		ToRef       string `json:"to_ref" binding:"required"`
 		GitHubToken string `json:"github_token,omitempty"`
 		GitLabToken string `json:"gitlab_token,omitempty"`
+		Language    string `json:"language,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
 		return
 	}
+	lang, err := s.requestLanguage(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 获取当前活动的 RAG 提供者
 	provider, err := s.manager.GetActiveProvider()
@@ -248,28 +482,27 @@ func (s *Server) handleGenerateWiki(c *gin.Context) {
 		return
 	}
 
-	// 分析仓库结构
-	analysis, err := repoManager.AnalyzeRepository(repoPath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("分析仓库失败: %v", err)})
-		return
+	// 已有的克隆可能缺少新的标签或分支
+	if err := data.FetchRefs(repoPath); err != nil {
+		log.Printf("获取远程引用失败: %v", err)
 	}
 
-	// 准备RAG检索器
-	if err := provider.PrepareRetriever(repoPath, accessToken); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("准备检索器失败: %v", err)})
+	// 收集提交记录和差异统计
+	changelog, err := data.CollectChangelog(repoPath, req.FromRef, req.ToRef)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("收集变更记录失败: %v", err)})
 		return
 	}
 
-	// 生成Wiki页面
-	pages, err := s.generateWikiPages(analysis, req.RepoURL)
+	page, err := s.generateChangelogPage(withLanguage(c.Request.Context(), lang), changelog, req.RepoURL, provider)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成Wiki失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成发布说明失败: %v", err)})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"pages": pages,
+		"page":      page,
+		"changelog": changelog,
 	})
 }
 
@@ -281,32 +514,109 @@ func (s *Server) handleExportWiki(c *gin.Context) {
 		return
 	}
 
-	if len(req.Pages) == 0 {
+	// git 格式和推送到 Confluence 时只导出服务端保存的 Wiki
+	format := strings.ToLower(req.Format)
+	if format == "git" {
+		s.exportToGit(c, &req)
+		return
+	}
+	if format == "confluence" && req.Push {
+		s.pushConfluence(c, &req)
+		return
+	}
+
+	// 其他格式导出请求中的页面，未提供页面时导出 wiki_id 指定的已保存 Wiki
+	var wiki *export.Wiki
+	switch {
+	case len(req.Pages) > 0:
+		wiki = exportWiki(&req)
+	case req.WikiID != "":
+		var ok bool
+		if wiki, ok = s.storedExportWiki(c, &req); !ok {
+			return
+		}
+	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "未提供页面内容"})
 		return
 	}
 
 	// 根据不同格式导出
-	var content string
+	var content []byte
 	var contentType string
 	var filename string
 
-	repoName := getRepoNameFromURL(req.RepoURL)
+	repoName := wiki.RepoName
 
-	switch strings.ToLower(req.Format) {
+	switch format {
 	case "markdown", "md":
-		content = exportToMarkdown(req.Pages)
+		if req.PerPage {
+			pages, err := export.MarkdownPages(wiki, s.markdownOptions(&req))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成 Markdown 文件失败: %v", err)})
+				return
+			}
+			content = pages
+			contentType = "application/zip"
+			filename = fmt.Sprintf("%s-wiki-markdown.zip", repoName)
+			break
+		}
+		doc, err := export.Markdown(wiki, s.markdownOptions(&req))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成 Markdown 文档失败: %v", err)})
+			return
+		}
+		content = []byte(doc)
 		contentType = "text/markdown"
 		filename = fmt.Sprintf("%s-wiki.md", repoName)
 	case "json":
-		jsonData, err := json.MarshalIndent(req.Pages, "", "  ")
+		jsonData, err := export.JSON(wiki)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("序列化JSON失败: %v", err)})
 			return
 		}
-		content = string(jsonData)
+		content = jsonData
 		contentType = "application/json"
 		filename = fmt.Sprintf("%s-wiki.json", repoName)
+	case "html":
+		site, err := export.HTMLSite(wiki, export.SiteOptions{MermaidScript: s.mermaidScript()})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成静态站点失败: %v", err)})
+			return
+		}
+		content = site
+		contentType = "application/zip"
+		filename = fmt.Sprintf("%s-wiki-site.zip", repoName)
+	case "mkdocs", "docusaurus":
+		build, name := export.MkDocs, "mkdocs"
+		if format == "docusaurus" {
+			build, name = export.Docusaurus, "docusaurus"
+		}
+		project, err := build(wiki)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成文档项目失败: %v", err)})
+			return
+		}
+		content = project
+		contentType = "application/zip"
+		filename = fmt.Sprintf("%s-wiki-%s.zip", repoName, name)
+	case "epub":
+		book, err := export.EPUB(wiki)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成电子书失败: %v", err)})
+			return
+		}
+		content = book
+		contentType = "application/epub+zip"
+		filename = fmt.Sprintf("%s-wiki.epub", repoName)
+	case "confluence":
+		bundle, err := export.ConfluenceBundle(wiki, s.confluenceOptions())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成 Confluence 导入包失败: %v", err)})
+			return
+		}
+		content = bundle
+		contentType = "application/zip"
+		filename = fmt.Sprintf("%s-wiki-confluence.zip", repoName)
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的导出格式"})
 		return
@@ -314,7 +624,7 @@ func (s *Server) handleExportWiki(c *gin.Context) {
 
 	// 设置响应头
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	c.Data(http.StatusOK, contentType, []byte(content))
+	c.Data(http.StatusOK, contentType, content)
 }
 
 // handleAnalyzeRepo 处理仓库分析请求
@@ -360,63 +670,157 @@ func (s *Server) handleAnalyzeRepo(c *gin.Context) {
 		return
 	}
 
+	result := gin.H{
+		"repo":    analysis,
+		"diagram": diagram,
+	}
+
+	// 分析 git 历史（变更频率、所有权、最近修改）
+	history, err := data.CachedHistory(repoPath)
+	if err != nil {
+		log.Printf("分析仓库历史失败: %v", err)
+	} else {
+		result["history"] = history
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"analysis": gin.H{
-			"repo":    analysis,
-			"diagram": diagram,
-		},
+		"analysis": result,
 	})
 }
 
 // 辅助函数
 
 // generateWikiPages 生成Wiki页面
-func (s *Server) generateWikiPages(analysis map[string]interface{}, repoURL string) ([]models.WikiPage, error) {
+// 先由模型规划 Wiki 大纲，规划失败时退回到固定的概述/架构/模块页面；页面以有限并发生成，
+// 返回的状态列表记录每个页面的生成结果；history 为 nil 时不生成所有权与活跃度页面。
+// commit 是索引时检出的提交，页面中的源码链接都指向该提交，避免分支移动后行号失效
+func (s *Server) generateWikiPages(ctx context.Context, reporter progressReporter, analysis map[string]interface{}, history *models.RepoHistory, repoPath, repoURL, commit, mode string) ([]models.WikiPage, []models.PageStatus, *models.WikiPlan, error) {
 	// 获取当前活动的 RAG 提供者
 	provider, err := s.manager.GetActiveProvider()
 	if err != nil {
-		return nil, fmt.Errorf("获取 RAG 提供者失败: %v", err)
+		return nil, nil, nil, fmt.Errorf("获取 RAG 提供者失败: %v", err)
+	}
+	if reporter == nil {
+		reporter = nopReporter{}
 	}
 
-	// 创建页面集合
-	var pages []models.WikiPage
-
-	// 创建概述页面
-	overviewPage, err := s.generateOverviewPage(analysis, repoURL, provider)
+	var tasks []pageTask
+	reporter.SetPhase(JobPhasePlanning, mode)
+	plan, err := s.planWikiStructure(ctx, analysis, repoPath, repoURL, mode, provider)
 	if err != nil {
-		return nil, err
+		if ctx.Err() != nil {
+			return nil, nil, nil, ctx.Err()
+		}
+		log.Printf("规划 Wiki 结构失败，使用默认结构: %v", err)
+		plan = nil
+		tasks = s.defaultPageTasks(ctx, analysis, repoURL, commit, provider)
+	} else {
+		for _, plannedPage := range plan.Pages {
+			plannedPage := plannedPage
+			tasks = append(tasks, pageTask{
+				id:    plannedPage.ID,
+				title: plannedPage.Title,
+				run: func(ctx context.Context) (models.WikiPage, error) {
+					return s.generatePlannedPage(ctx, plannedPage, plan, repoPath, repoURL, commit, provider)
+				},
+			})
+		}
+	}
+
+	reporter.SetPhase(JobPhaseGenerating, "")
+	pages, statuses := s.runPageTasks(ctx, reporter, repoPath, tasks)
+	if ctx.Err() != nil {
+		return nil, statuses, plan, ctx.Err()
 	}
-	pages = append(pages, overviewPage)
 
-	// 创建架构页面
-	architecturePage, err := s.generateArchitecturePage(analysis, repoURL, provider)
+	// 根据 Go 文档注释生成确定性的 API 参考页面
+	apiPages, err := data.BuildAPIReference(repoPath, repoURL, commit, s.pageText(ctx))
 	if err != nil {
-		return nil, err
+		log.Printf("生成 API 参考失败: %v", err)
+	} else if len(apiPages) > 0 {
+		// 在架构页面中关联 API 参考索引
+		for i := range pages {
+			if pages[i].ID == "architecture" {
+				pages[i].RelatedPages = append(pages[i].RelatedPages, data.APIReferenceIndexID)
+			}
+		}
+		pages = appendGeneratedSection(pages, plan, "api-reference", s.promptText(ctx, "api_section", prompts.Data{}, "API"), apiPages)
+	}
+
+	// 根据 git 历史生成所有权与活跃度页面
+	if history != nil && history.CommitCount > 0 {
+		pages = appendGeneratedSection(pages, plan, "activity", s.promptText(ctx, "activity_section", prompts.Data{}, "Activity"), []models.WikiPage{data.BuildOwnershipPage(history, s.pageText(ctx))})
+	}
+
+	return pages, statuses, plan, nil
+}
+
+// appendGeneratedSection 追加确定性生成的页面，并在存在大纲时将其登记为一个章节
+func appendGeneratedSection(pages []models.WikiPage, plan *models.WikiPlan, sectionID, sectionTitle string, extra []models.WikiPage) []models.WikiPage {
+	section := models.WikiSection{ID: sectionID, Title: sectionTitle}
+	for _, page := range extra {
+		if plan != nil {
+			page.Section = sectionID
+		}
+		section.Pages = append(section.Pages, page.ID)
+		pages = append(pages, page)
+	}
+	if plan != nil {
+		plan.Sections = append(plan.Sections, section)
+	}
+	return pages
+}
+
+// defaultPageTasks 返回固定结构的概述、架构和模块页面任务，页面中的源码链接指向 commit
+func (s *Server) defaultPageTasks(ctx context.Context, analysis map[string]interface{}, repoURL, commit string, provider rag.RAGProvider) []pageTask {
+	repoName := getRepoNameFromURL(repoURL)
+	tasks := []pageTask{
+		{
+			id:    "overview",
+			title: s.promptText(ctx, "overview_title", prompts.Data{Repo: repoName}, repoName),
+			run: func(ctx context.Context) (models.WikiPage, error) {
+				return s.generateOverviewPage(ctx, analysis, repoURL, commit, provider)
+			},
+		},
+		{
+			id:    "architecture",
+			title: s.promptText(ctx, "architecture_title", prompts.Data{Repo: repoName}, repoName),
+			run: func(ctx context.Context) (models.WikiPage, error) {
+				return s.generateArchitecturePage(ctx, analysis, repoURL, commit, provider)
+			},
+		},
 	}
-	pages = append(pages, architecturePage)
 
 	// 为每个主要目录创建模块页面
-	for dirName, dirContent := range analysis {
-		if content, ok := dirContent.(map[string]interface{}); ok {
-			if isNonEssentialDir(dirName) {
-				continue
-			}
+	structure, _ := analysis["structure"].(map[string]interface{})
+	dirNames := make([]string, 0, len(structure))
+	for dirName := range structure {
+		dirNames = append(dirNames, dirName)
+	}
+	sort.Strings(dirNames)
 
-			modulePage, err := s.generateModulePage(dirName, content, repoURL, provider)
-			if err != nil {
-				continue // 跳过出错的模块
-			}
-			pages = append(pages, modulePage)
+	for _, dirName := range dirNames {
+		content, ok := structure[dirName].(map[string]interface{})
+		if !ok || strings.HasPrefix(dirName, ".") || isNonEssentialDir(dirName) {
+			continue
 		}
+		dirName := dirName
+		tasks = append(tasks, pageTask{
+			id:    fmt.Sprintf("module-%s", strings.ToLower(dirName)),
+			title: s.promptText(ctx, "module_title", prompts.Data{Module: dirName}, dirName),
+			run: func(ctx context.Context) (models.WikiPage, error) {
+				return s.generateModulePage(ctx, dirName, content, repoURL, commit, provider)
+			},
+		})
 	}
 
-	return pages, nil
+	return tasks
 }
 
 // generateOverviewPage 生成项目概述页面
-func (s *Server) generateOverviewPage(analysis map[string]interface{}, repoURL string, provider rag.RAGProvider) (models.WikiPage, error) {
+func (s *Server) generateOverviewPage(ctx context.Context, analysis map[string]interface{}, repoURL, commit string, provider rag.RAGProvider) (models.WikiPage, error) {
 	// 准备查询获取项目概述
-	query := fmt.Sprintf("生成以下代码仓库的概述: %s\n\n请包括以下内容:\n- 项目名称和简短描述\n- 主要功能\n- 技术栈概览\n- 开发者指南", repoURL)
+	query := s.promptText(ctx, "overview_query", prompts.Data{RepoURL: repoURL}, "Generate an overview of the code repository "+repoURL)
 
 	// 检索相关文档
 	docs, err := provider.RetrieveDocuments(query)
@@ -424,49 +828,46 @@ func (s *Server) generateOverviewPage(analysis map[string]interface{}, repoURL s
 		return models.WikiPage{}, err
 	}
 
-	// 构建上下文
-	var context string
-	for _, doc := range docs {
-		context += doc.Text + "\n\n"
-	}
+	// 构建带编号的上下文
+	context, sources := rag.NumberDocuments(docs, 1, 0, s.sourceLabel(ctx))
 
 	// 生成概述内容
-	prompt := fmt.Sprintf("基于以下代码库的信息，生成一个项目概述页面：\n\n%s\n\n请使用Markdown格式，包括以下部分：\n1. 项目简介\n2. 主要功能\n3. 技术栈\n4. 入门指南", context)
-
-	responseCh, err := provider.GenerateStreamingResponse(prompt)
+	prompt, err := s.renderPrompt(ctx, "overview", prompts.Data{Context: context})
 	if err != nil {
 		return models.WikiPage{}, err
 	}
 
-	// 收集响应
-	var content strings.Builder
-	for chunk := range responseCh {
-		content.WriteString(chunk)
+	content, err := rag.GenerateText(ctx, provider, prompt)
+	if err != nil {
+		return models.WikiPage{}, err
 	}
 
+	content, citations, filePaths := s.resolvePageCitations(ctx, content, sources, repoURL, commit)
+
 	// 获取仓库名称
 	repoName := getRepoNameFromURL(repoURL)
 
 	// 创建页面
 	return models.WikiPage{
 		ID:           "overview",
-		Title:        fmt.Sprintf("%s - 项目概述", repoName),
-		Content:      content.String(),
-		FilePaths:    []string{"README.md", "CONTRIBUTING.md", "docs/"},
+		Title:        s.promptText(ctx, "overview_title", prompts.Data{Repo: repoName}, repoName),
+		Content:      content,
+		FilePaths:    filePaths,
 		Importance:   "high",
 		RelatedPages: []string{},
+		Citations:    citations,
 	}, nil
 }
 
 // generateArchitecturePage 生成架构页面
-func (s *Server) generateArchitecturePage(analysis map[string]interface{}, repoURL string, provider rag.RAGProvider) (models.WikiPage, error) {
+func (s *Server) generateArchitecturePage(ctx context.Context, analysis map[string]interface{}, repoURL, commit string, provider rag.RAGProvider) (models.WikiPage, error) {
 	// 生成结构图
 	diagram, err := generateRepoStructureDiagram(analysis)
 	if err != nil {
 		return models.WikiPage{}, err
 	}
 
-	query := "描述此代码库的整体架构、主要组件和它们之间的关系"
+	query := s.promptText(ctx, "architecture_query", prompts.Data{}, "Describe the overall architecture of this codebase")
 
 	// 检索相关文档
 	docs, err := provider.RetrieveDocuments(query)
@@ -474,44 +875,41 @@ func (s *Server) generateArchitecturePage(analysis map[string]interface{}, repoU
 		return models.WikiPage{}, err
 	}
 
-	// 构建上下文
-	var context string
-	for _, doc := range docs {
-		context += doc.Text + "\n\n"
-	}
+	// 构建带编号的上下文
+	context, sources := rag.NumberDocuments(docs, 1, 0, s.sourceLabel(ctx))
 
 	// 生成架构内容
-	prompt := fmt.Sprintf("基于以下代码信息，生成一个架构文档：\n\n%s\n\n请使用Markdown格式，解释主要组件及其交互方式。以下是项目结构图，请在解释中引用它：\n\n```mermaid\n%s\n```", context, diagram)
-
-	responseCh, err := provider.GenerateStreamingResponse(prompt)
+	prompt, err := s.renderPrompt(ctx, "architecture", prompts.Data{Context: context, Diagram: diagram})
 	if err != nil {
 		return models.WikiPage{}, err
 	}
 
-	// 收集响应
-	var content strings.Builder
-	for chunk := range responseCh {
-		content.WriteString(chunk)
+	content, err := rag.GenerateText(ctx, provider, prompt)
+	if err != nil {
+		return models.WikiPage{}, err
 	}
 
+	content, citations, filePaths := s.resolvePageCitations(ctx, content, sources, repoURL, commit)
+
 	// 获取仓库名称
 	repoName := getRepoNameFromURL(repoURL)
 
 	// 创建页面
 	return models.WikiPage{
 		ID:           "architecture",
-		Title:        fmt.Sprintf("%s - 系统架构", repoName),
-		Content:      content.String(),
-		FilePaths:    []string{},
+		Title:        s.promptText(ctx, "architecture_title", prompts.Data{Repo: repoName}, repoName),
+		Content:      content,
+		FilePaths:    filePaths,
 		Importance:   "high",
 		RelatedPages: []string{"overview"},
+		Citations:    citations,
 	}, nil
 }
 
 // generateModulePage 生成模块页面
-func (s *Server) generateModulePage(moduleName string, moduleContent interface{}, repoURL string, provider rag.RAGProvider) (models.WikiPage, error) {
+func (s *Server) generateModulePage(ctx context.Context, moduleName string, moduleContent interface{}, repoURL, commit string, provider rag.RAGProvider) (models.WikiPage, error) {
 	// 准备查询
-	query := fmt.Sprintf("描述%s目录中的代码功能和主要组件", moduleName)
+	query := s.promptText(ctx, "module_query", prompts.Data{Module: moduleName}, "Describe the code in the "+moduleName+" directory")
 
 	// 检索相关文档
 	docs, err := provider.RetrieveDocuments(query)
@@ -519,44 +917,115 @@ func (s *Server) generateModulePage(moduleName string, moduleContent interface{}
 		return models.WikiPage{}, err
 	}
 
-	// 构建上下文
-	var context string
-	for _, doc := range docs {
-		context += doc.Text + "\n\n"
-	}
+	// 构建带编号的上下文
+	context, sources := rag.NumberDocuments(docs, 1, 0, s.sourceLabel(ctx))
 
 	// 生成模块内容
-	prompt := fmt.Sprintf("请基于以下代码信息，详细描述'%s'模块的功能、组件和用法：\n\n%s\n\n请使用Markdown格式，包括：\n1. 模块概述\n2. 主要组件和类\n3. 关键功能\n4. 与其他模块的交互\n5. 示例用法（如果适用）", moduleName, context)
-
-	responseCh, err := provider.GenerateStreamingResponse(prompt)
+	prompt, err := s.renderPrompt(ctx, "module", prompts.Data{Module: moduleName, Context: context})
 	if err != nil {
 		return models.WikiPage{}, err
 	}
 
-	// 收集响应
-	var content strings.Builder
-	for chunk := range responseCh {
-		content.WriteString(chunk)
+	content, err := rag.GenerateText(ctx, provider, prompt)
+	if err != nil {
+		return models.WikiPage{}, err
 	}
 
+	content, citations, filePaths := s.resolvePageCitations(ctx, content, sources, repoURL, commit)
+
 	// 创建页面
 	return models.WikiPage{
 		ID:           fmt.Sprintf("module-%s", strings.ToLower(moduleName)),
-		Title:        fmt.Sprintf("%s 模块", moduleName),
-		Content:      content.String(),
-		FilePaths:    []string{moduleName + "/"},
+		Title:        s.promptText(ctx, "module_title", prompts.Data{Module: moduleName}, moduleName),
+		Content:      content,
+		FilePaths:    filePaths,
 		Importance:   "medium",
 		RelatedPages: []string{"architecture", "overview"},
+		Citations:    citations,
 	}, nil
 }
 
+// resolvePageCitations 解析页面正文中的来源引用并链接到 commit 中的源码，commit 为空时链接到 HEAD
+// 返回链接后的正文、被引用的来源和页面使用的文件；模型没有标注引用时，所有提供的来源都视为已使用
+func (s *Server) resolvePageCitations(ctx context.Context, content string, sources []models.Citation, repoURL, commit string) (string, []models.Citation, []string) {
+	ref := commit
+	if ref == "" {
+		ref = "HEAD"
+	}
+	citations := rag.ResolveCitations(rag.ParseCitations(content, sources), repoURL, ref)
+	content = rag.LinkCitationsWithHeading(content, citations, s.promptText(ctx, "sources_heading", prompts.Data{}, "Sources"))
+	if len(citations) > 0 {
+		return content, citations, rag.CitedFiles(citations)
+	}
+	return content, citations, rag.CitedFiles(sources)
+}
+
+// generateChangelogPage 基于提交汇总生成发布说明页面
+func (s *Server) generateChangelogPage(ctx context.Context, changelog *models.Changelog, repoURL string, provider rag.RAGProvider) (models.WikiPage, error) {
+	repoName := getRepoNameFromURL(repoURL)
+	pageID := fmt.Sprintf("changelog-%s-%s", slugifyRef(changelog.FromRef), slugifyRef(changelog.ToRef))
+	titleData := prompts.Data{Repo: repoName, From: changelog.FromRef, To: changelog.ToRef}
+	title := s.promptText(ctx, "release_notes_title", titleData, fmt.Sprintf("%s: %s..%s", repoName, changelog.FromRef, changelog.ToRef))
+
+	// 收集变更涉及的文件
+	seen := make(map[string]bool)
+	filePaths := []string{}
+	for _, commit := range changelog.Commits {
+		for _, f := range commit.Files {
+			if !seen[f] {
+				seen[f] = true
+				filePaths = append(filePaths, f)
+			}
+		}
+	}
+	sort.Strings(filePaths)
+
+	if len(changelog.Commits) == 0 {
+		return models.WikiPage{
+			ID:           pageID,
+			Title:        title,
+			Content:      fmt.Sprintf("# %s\n\n%s\n", title, s.promptText(ctx, "release_notes_empty", titleData, "")),
+			FilePaths:    filePaths,
+			Importance:   "medium",
+			RelatedPages: []string{"overview"},
+		}, nil
+	}
+
+	promptData := titleData
+	promptData.Commits = data.FormatChangelogSummary(changelog, s.pageText(ctx))
+	prompt, err := s.renderPrompt(ctx, "release_notes", promptData)
+	if err != nil {
+		return models.WikiPage{}, err
+	}
+
+	content, err := rag.GenerateText(ctx, provider, prompt)
+	if err != nil {
+		return models.WikiPage{}, err
+	}
+
+	return models.WikiPage{
+		ID:           pageID,
+		Title:        title,
+		Content:      content,
+		FilePaths:    filePaths,
+		Importance:   "medium",
+		RelatedPages: []string{"overview"},
+	}, nil
+}
+
+// slugifyRef 将 git 引用转换为可用于页面ID的形式
+func slugifyRef(ref string) string {
+	ref = strings.ToLower(ref)
+	return strings.NewReplacer("/", "-", " ", "-", ".", "-").Replace(ref)
+}
+
 // 生成仓库结构图
 func generateRepoStructureDiagram(analysis map[string]interface{}) (string, error) {
 	if structure, ok := analysis["structure"].(map[string]interface{}); ok {
 		// 创建Mermaid图表
 		var diagram strings.Builder
 		diagram.WriteString("graph TD\n")
-		diagram.WriteString("    Root[项目根目录] --> ")
+		diagram.WriteString(fmt.Sprintf("    Root[%s]\n", mermaid.EscapeLabel("项目根目录")))
 
 		// 添加顶级目录
 		var dirs []string
@@ -572,17 +1041,12 @@ func generateRepoStructureDiagram(analysis map[string]interface{}) (string, erro
 		// 添加目录节点
 		for i, dir := range dirs {
 			dirID := fmt.Sprintf("Dir%d", i)
-			diagram.WriteString(fmt.Sprintf("%s[%s]\n", dirID, dir))
+			diagram.WriteString(fmt.Sprintf("    Root --> %s[%s]\n", dirID, mermaid.EscapeLabel(dir)))
 
 			// 递归添加目录结构
 			if dirContent, ok := structure[dir].(map[string]interface{}); ok {
 				addDirStructure(&diagram, dirID, dirContent, 0)
 			}
-
-			// 如果不是最后一个目录，添加Root到下一个目录的连接
-			if i < len(dirs)-1 {
-				diagram.WriteString("    Root --> ")
-			}
 		}
 
 		// 添加样式
@@ -596,6 +1060,9 @@ func generateRepoStructureDiagram(analysis map[string]interface{}) (string, erro
 			diagram.WriteString(fmt.Sprintf("    class Dir%d dir;\n", i))
 		}
 
+		if err := mermaid.Validate(diagram.String()); err != nil {
+			return "", fmt.Errorf("生成的仓库结构图无效: %v", err)
+		}
 		return diagram.String(), nil
 	}
 
@@ -627,14 +1094,14 @@ func addDirStructure(diagram *strings.Builder, parentID string, content map[stri
 		// 检查是子目录还是文件
 		if subDir, ok := content[name].(map[string]interface{}); ok {
 			// 这是一个目录
-			diagram.WriteString(fmt.Sprintf("    %s --> %s[%s/]\n", parentID, itemID, name))
+			diagram.WriteString(fmt.Sprintf("    %s --> %s[%s]\n", parentID, itemID, mermaid.EscapeLabel(name+"/")))
 			diagram.WriteString(fmt.Sprintf("    class %s dir;\n", itemID))
 
 			// 递归处理子目录
 			addDirStructure(diagram, itemID, subDir, depth+1)
 		} else {
 			// 这是一个文件
-			diagram.WriteString(fmt.Sprintf("    %s --> %s[%s]\n", parentID, itemID, name))
+			diagram.WriteString(fmt.Sprintf("    %s --> %s[%s]\n", parentID, itemID, mermaid.EscapeLabel(name)))
 			diagram.WriteString(fmt.Sprintf("    class %s file;\n", itemID))
 		}
 	}
@@ -642,7 +1109,7 @@ func addDirStructure(diagram *strings.Builder, parentID string, content map[stri
 	// 如果有更多项，添加省略号
 	if len(content) > maxItems {
 		moreID := fmt.Sprintf("%s_more", parentID)
-		diagram.WriteString(fmt.Sprintf("    %s --> %s[...更多项]\n", parentID, moreID))
+		diagram.WriteString(fmt.Sprintf("    %s --> %s[%s]\n", parentID, moreID, mermaid.EscapeLabel("...更多项")))
 	}
 }
 
@@ -664,34 +1131,6 @@ func getRepoNameFromURL(url string) string {
 	return "wiki"
 }
 
-// exportToMarkdown 将Wiki页面导出为Markdown
-func exportToMarkdown(pages []models.WikiPage) string {
-	var result strings.Builder
-
-	// 添加标题
-	result.WriteString("# DeepWiki 导出\n\n")
-	result.WriteString("## 目录\n\n")
-
-	// 生成目录
-	for _, page := range pages {
-		result.WriteString(fmt.Sprintf("- [%s](#%s)\n", page.Title, strings.ToLower(strings.ReplaceAll(page.ID, " ", "-"))))
-	}
-
-	result.WriteString("\n---\n\n")
-
-	// 添加每个页面的内容
-	for _, page := range pages {
-		result.WriteString(fmt.Sprintf("## %s\n\n", page.Title))
-		result.WriteString(page.Content)
-		result.WriteString("\n\n---\n\n")
-	}
-
-	// 添加页脚
-	result.WriteString("*由 DeepWiki-Go 生成*\n")
-
-	return result.String()
-}
-
 // isNonEssentialDir 检查是否为非关键目录
 func isNonEssentialDir(dirName string) bool {
 	nonEssentialDirs := map[string]bool{
@@ -757,8 +1196,9 @@ func (s *Server) handleHealthCheck(c *gin.Context) {
 // handleVectorSearch 处理向量搜索请求
 func (s *Server) handleVectorSearch(c *gin.Context) {
 	var req struct {
-		Query string `json:"query" binding:"required"`
-		TopK  int    `json:"top_k,omitempty"`
+		Query   string              `json:"query" binding:"required"`
+		TopK    int                 `json:"top_k,omitempty"`
+		Filters data.DocumentFilter `json:"filters,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -777,7 +1217,7 @@ func (s *Server) handleVectorSearch(c *gin.Context) {
 	}
 
 	// 执行向量搜索
-	documents, err := s.dbManager.SearchDocuments(req.Query, req.TopK)
+	documents, err := s.dbManager.SearchDocumentsWithFilter(req.Query, req.TopK, req.Filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("搜索失败: %v", err)})
 		return
@@ -850,7 +1290,7 @@ func (s *Server) handleGetDocument(c *gin.Context) {
 	c.JSON(http.StatusOK, doc)
 }
 
-// handleSyncRepo 处理仓库同步请求
+// handleSyncRepo 处理仓库同步请求，同步过程以后台任务执行并立即返回任务ID
 func (s *Server) handleSyncRepo(c *gin.Context) {
 	var req struct {
 		RepoURL     string `json:"repo_url" binding:"required"`
@@ -874,28 +1314,51 @@ func (s *Server) handleSyncRepo(c *gin.Context) {
 		return
 	}
 
-	// 初始化库管理器
-	repoManager := data.NewRepositoryManager(s.config)
+	job := s.jobs.Start("repo_sync", func(ctx context.Context, job *Job) (interface{}, error) {
+		// 初始化库管理器
+		repoManager := data.NewRepositoryManager(s.config)
 
-	// 克隆仓库
-	repoPath, err := repoManager.CloneRepository(req.RepoURL, accessToken)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("克隆仓库失败: %v", err)})
-		return
-	}
+		// 克隆仓库
+		job.SetPhase(JobPhaseCloning, req.RepoURL)
+		repoPath, err := repoManager.CloneRepository(req.RepoURL, accessToken)
+		if err != nil {
+			return nil, fmt.Errorf("克隆仓库失败: %v", err)
+		}
 
-	// 准备数据库（读取文档并索引）
-	err = s.dbManager.PrepareDatabase(repoPath, accessToken)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("准备数据库失败: %v", err)})
-		return
-	}
+		// 已有的克隆需要拉取最新提交
+		if err := data.UpdateRepository(repoPath); err != nil {
+			return nil, fmt.Errorf("更新仓库失败: %v", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "仓库已成功同步并索引",
-		"path":    repoPath,
+		// 准备数据库（读取文档并索引）
+		job.SetPhase(JobPhaseIndexing, "")
+		if err := s.dbManager.PrepareDatabaseContext(ctx, repoPath, accessToken); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("准备数据库失败: %v", err)
+		}
+
+		result := gin.H{
+			"status":  "success",
+			"message": "仓库已成功同步并索引",
+			"path":    repoPath,
+		}
+
+		// 检查最近保存的 Wiki 中有哪些页面因新提交而过期
+		if wiki, err := s.wikis.Latest(data.RepoKey(req.RepoURL), "", "", ""); err == nil {
+			if report, err := detectWikiDrift(wiki, repoPath, ""); err != nil {
+				log.Printf("检测过期页面失败: %v", err)
+			} else {
+				result["drift"] = report
+			}
+		}
+		return result, nil
 	})
+	respondJobAccepted(c, job)
 }
 
 // handleIndexVectors 处理向量索引请求
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+)
+
+func TestSlugifyRef(t *testing.T) {
+	cases := map[string]string{
+		"v1.2.0":          "v1-2-0",
+		"release/2024.01": "release-2024-01",
+		"Feature Branch":  "feature-branch",
+		"0123abc":         "0123abc",
+	}
+	for ref, want := range cases {
+		if got := slugifyRef(ref); got != want {
+			t.Errorf("slugifyRef(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestResolvePageCitationsPinsCommit(t *testing.T) {
+	s := &Server{prompts: loadPrompts("")}
+	sources := []models.Citation{{Index: 1, FilePath: "main.go", StartLine: 3, EndLine: 8}}
+	commit := "0123456789abcdef0123456789abcdef01234567"
+
+	content, citations, files := s.resolvePageCitations(context.Background(), "Entry point [1].", sources, "https://github.com/owner/repo", commit)
+	if !strings.Contains(content, "https://github.com/owner/repo/blob/"+commit+"/main.go#L3-L8") || strings.Contains(content, "/blob/HEAD/") {
+		t.Errorf("citation not linked to the indexed commit:\n%s", content)
+	}
+	if len(citations) != 1 || len(files) != 1 || files[0] != "main.go" {
+		t.Errorf("unexpected citations: %+v %v", citations, files)
+	}
+
+	if content, _, _ := s.resolvePageCitations(context.Background(), "Entry point [1].", sources, "https://github.com/owner/repo", ""); !strings.Contains(content, "/blob/HEAD/main.go") {
+		t.Errorf("expected HEAD without a commit:\n%s", content)
+	}
+}
+
+func TestSourceLabelFollowsLanguage(t *testing.T) {
+	s := &Server{prompts: loadPrompts("")}
+	source := models.Citation{FilePath: "main.go", StartLine: 3, EndLine: 8}
+
+	if got := s.sourceLabel(withLanguage(context.Background(), "en"))(source); got != "File: main.go (lines 3-8)" {
+		t.Errorf("unexpected en label: %q", got)
+	}
+	if got := s.sourceLabel(withLanguage(context.Background(), "zh"))(source); got != "文件: main.go (第 3-8 行)" {
+		t.Errorf("unexpected zh label: %q", got)
+	}
+	query := s.promptText(withLanguage(context.Background(), "en"), "module_query", prompts.Data{Module: "api"}, "")
+	if !strings.Contains(query, "api directory") {
+		t.Errorf("unexpected en module query: %q", query)
+	}
+}
@@ -0,0 +1,219 @@
+// internal/api/import.go
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/export"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize 是导入内容的最大字节数
+const maxImportSize = 64 << 20
+
+// handleImportWiki 导入 JSON 导出或逐页 Markdown 导出的 zip 包，保存为仓库的 Wiki
+// 内容可以是请求体，也可以是 multipart 表单中的 file 字段；repo_url、ref、commit、language
+// 可以通过查询参数或表单字段指定，覆盖导入内容中的值
+// 与已有 Wiki 的 ID 相同时，内容有变化的页面记录为新版本
+func (s *Server) handleImportWiki(c *gin.Context) {
+	payload, err := readImportPayload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
+		return
+	}
+
+	var imported *export.Wiki
+	format := importParam(c, "format")
+	if format == "" && export.IsZip(payload) {
+		format = "markdown"
+	}
+	switch strings.ToLower(format) {
+	case "", "json":
+		imported, err = export.ParseJSON(payload)
+	case "markdown", "md":
+		imported, err = export.ParseMarkdownZip(payload, export.MarkdownOptions{Footer: s.config.Export.Markdown.Footer})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的导入格式"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析导入内容失败: %v", err)})
+		return
+	}
+
+	for name, field := range map[string]*string{"repo_url": &imported.RepoURL, "ref": &imported.Ref, "commit": &imported.Commit, "language": &imported.Language} {
+		if value := importParam(c, name); value != "" {
+			*field = value
+		}
+	}
+	if imported.RepoURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少仓库地址 repo_url"})
+		return
+	}
+	if imported.Commit == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少源码提交 commit"})
+		return
+	}
+	// commit 和 language 会成为 Wiki ID 和存储文件名的一部分，只接受完整的提交 SHA 和支持的语言
+	imported.Commit = strings.ToLower(imported.Commit)
+	if !commitPattern.MatchString(imported.Commit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "commit 必须是完整的十六进制提交 SHA"})
+		return
+	}
+	if imported.Language, err = prompts.NormalizeLanguage(imported.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	problems, warnings := imported.Validate()
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "导入的 Wiki 无效", "problems": problems, "warnings": warnings})
+		return
+	}
+
+	wiki := importedWiki(imported)
+	if existing, err := s.wikis.Get(wiki.RepoKey, wiki.ID); err == nil {
+		wiki = mergeImportedWiki(existing, wiki, c.GetString("username"))
+	} else if !errors.Is(err, data.ErrWikiNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取 Wiki 失败: %v", err)})
+		return
+	}
+	if err := s.wikis.Save(wiki); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存 Wiki 失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wiki": wiki.Summary(), "warnings": warnings})
+}
+
+// commitPattern 匹配完整的 SHA-1 或 SHA-256 提交 ID
+var commitPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// readImportPayload 读取 multipart 表单中的 file 字段或整个请求体
+func readImportPayload(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("缺少文件 file: %v", err)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("请求体为空")
+	}
+	return payload, nil
+}
+
+// importParam 读取表单字段或查询参数
+func importParam(c *gin.Context, name string) string {
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if value := c.PostForm(name); value != "" {
+			return value
+		}
+	}
+	return c.Query(name)
+}
+
+// importedWiki 将导入内容转换为待保存的 Wiki，并根据页面和章节重建大纲，以便之后重新生成单个页面
+func importedWiki(w *export.Wiki) *models.Wiki {
+	plan := &models.WikiPlan{Title: w.Title, Description: w.Description, Sections: w.Sections}
+	for _, page := range w.Pages {
+		plan.Pages = append(plan.Pages, models.PlannedPage{
+			ID:           page.ID,
+			Title:        page.Title,
+			FilePaths:    page.FilePaths,
+			Importance:   page.Importance,
+			RelatedPages: page.RelatedPages,
+			Section:      page.Section,
+		})
+	}
+	wiki := &models.Wiki{
+		RepoURL:     w.RepoURL,
+		RepoKey:     data.RepoKey(w.RepoURL),
+		Ref:         w.Ref,
+		Commit:      w.Commit,
+		Provider:    "import",
+		Language:    w.Language,
+		GeneratedAt: time.Now().UTC(),
+		Plan:        plan,
+		Pages:       w.Pages,
+	}
+	wiki.ID = data.WikiID(wiki.Ref, wiki.Commit, wiki.Language)
+	return wiki
+}
+
+// mergeImportedWiki 将导入内容合并到同一 ID 的已有 Wiki：保留版本历史和生成信息，
+// 内容或标题有变化的页面记录为人工导入的新版本，导入内容中没有的页面被删除
+func mergeImportedWiki(existing, imported *models.Wiki, author string) *models.Wiki {
+	merged := *existing
+	merged.Pages = nil
+	merged.Revisions = make(map[string][]models.PageRevision)
+	for _, page := range imported.Pages {
+		if history, ok := existing.Revisions[page.ID]; ok {
+			merged.Revisions[page.ID] = history
+		}
+	}
+
+	// 保留已有大纲中页面的描述
+	descriptions := make(map[string]string)
+	if existing.Plan != nil {
+		for _, planned := range existing.Plan.Pages {
+			descriptions[planned.ID] = planned.Description
+		}
+		imported.Plan.Mode = existing.Plan.Mode
+	}
+	for i := range imported.Plan.Pages {
+		imported.Plan.Pages[i].Description = descriptions[imported.Plan.Pages[i].ID]
+	}
+	merged.Plan = imported.Plan
+
+	var statuses []models.PageStatus
+	for _, status := range existing.PageStatus {
+		if imported.FindPage(status.ID) != nil {
+			statuses = append(statuses, status)
+		}
+	}
+	merged.PageStatus = statuses
+
+	for _, page := range imported.Pages {
+		current := existing.FindPage(page.ID)
+		if current == nil {
+			merged.Pages = append(merged.Pages, page)
+			continue
+		}
+		if current.Title == page.Title && current.Content == page.Content {
+			page.Revision = current.Revision
+			page.HumanAuthored = current.HumanAuthored
+			page.Citations = current.Citations
+			merged.Pages = append(merged.Pages, page)
+			continue
+		}
+		// RecordRevision 从当前页面记录初始版本，因此先放入原有页面再替换
+		merged.Pages = append(merged.Pages, *current)
+		merged.RecordRevision(page, models.PageRevision{
+			Source:        models.RevisionSourceImported,
+			HumanAuthored: true,
+			Author:        author,
+			Comment:       "导入",
+			Commit:        merged.Commit,
+		})
+	}
+	return &merged
+}
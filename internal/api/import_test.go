@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/config"
+)
+
+func TestImportWikiValidatesParams(t *testing.T) {
+	s, _ := newWikiTestServer(t)
+	s.config = &config.Config{}
+	s.router.POST("/wiki/import", s.handleImportWiki)
+
+	body := `{"repo_url": "https://github.com/owner/repo", "pages": [{"id": "a", "title": "A", "content": "x"}]}`
+	cases := []struct {
+		query string
+		code  int
+	}{
+		{"?commit=../../etc", http.StatusBadRequest},
+		{"?commit=abc123", http.StatusBadRequest},
+		{"?commit=0123456789abcdef0123456789abcdef01234567&language=klingon", http.StatusBadRequest},
+		{"?commit=0123456789ABCDEF0123456789ABCDEF01234567&language=EN-us", http.StatusOK},
+	}
+	for _, tc := range cases {
+		w := serve(s, http.MethodPost, "/wiki/import"+tc.query, body)
+		if w.Code != tc.code {
+			t.Errorf("%s: expected %d, got %d %s", tc.query, tc.code, w.Code, w.Body)
+		}
+	}
+
+	wikis, err := s.wikis.List("github.com_owner_repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, wiki := range wikis {
+		if wiki.Language == "en" && strings.HasPrefix(wiki.Commit, "0123456789abcdef") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("imported wiki not stored with normalized commit and language: %+v", wikis)
+	}
+}
@@ -0,0 +1,482 @@
+// internal/api/jobs.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Job status values
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCanceled  = "canceled"
+)
+
+// Job phase values
+const (
+	JobPhaseQueued      = "queued"
+	JobPhaseCloning     = "cloning"
+	JobPhaseAnalyzing   = "analyzing"
+	JobPhaseIndexing    = "indexing"
+	JobPhasePlanning    = "planning"
+	JobPhaseGenerating  = "generating"
+	JobPhaseTranslating = "translating"
+	JobPhaseCommitting  = "committing"
+	JobPhaseDone        = "done"
+)
+
+// Job event types
+const (
+	JobEventPhase         = "phase"
+	JobEventProgress      = "progress"
+	JobEventPageStarted   = "page_started"
+	JobEventPageCompleted = "page_completed"
+	JobEventToken         = "token"
+	JobEventDone          = "done"
+	JobEventDropped       = "dropped" // 订阅者处理过慢被断开，任务仍在运行
+)
+
+const (
+	jobRetention         = 24 * time.Hour // 已结束任务的保留时间
+	jobSubscriberBuffer  = 512            // 每个事件订阅者的缓冲区大小
+	jobEventKeepAlive    = 15 * time.Second
+	maxJobHistoryEntries = 2000 // 为迟到订阅者保留的事件数上限
+)
+
+// JobEvent 表示任务执行过程中的一个事件
+type JobEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+	Time time.Time   `json:"time"`
+}
+
+// JobProgress 表示任务当前阶段的进度
+type JobProgress struct {
+	Current int `json:"current"`
+	Total   int `json:"total"`
+}
+
+// JobSnapshot 是任务状态的只读快照，用于 API 响应
+type JobSnapshot struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Status     string      `json:"status"`
+	Phase      string      `json:"phase"`
+	Message    string      `json:"message,omitempty"`
+	Progress   JobProgress `json:"progress"`
+	Error      string      `json:"error,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// progressReporter 接收长时间操作的阶段、进度和页面生成事件
+type progressReporter interface {
+	SetPhase(phase, message string)
+	SetProgress(current, total int)
+	PageStarted(id, title string, attempt int)
+	PageCompleted(status models.PageStatus, page *models.WikiPage)
+	PageToken(id, delta string)
+}
+
+// nopReporter 是不记录任何进度的 progressReporter
+type nopReporter struct{}
+
+func (nopReporter) SetPhase(string, string)                           {}
+func (nopReporter) SetProgress(int, int)                              {}
+func (nopReporter) PageStarted(string, string, int)                   {}
+func (nopReporter) PageCompleted(models.PageStatus, *models.WikiPage) {}
+func (nopReporter) PageToken(string, string)                          {}
+
+// Job 表示一个后台任务
+type Job struct {
+	mu         sync.RWMutex
+	id         string
+	jobType    string
+	status     string
+	phase      string
+	message    string
+	progress   JobProgress
+	err        string
+	result     interface{}
+	createdAt  time.Time
+	updatedAt  time.Time
+	finishedAt *time.Time
+	cancel     context.CancelFunc
+
+	// 事件订阅
+	history     []JobEvent
+	subscribers map[chan JobEvent]struct{}
+}
+
+// ID 返回任务ID
+func (j *Job) ID() string {
+	return j.id
+}
+
+// SetPhase 更新任务阶段并重置进度
+func (j *Job) SetPhase(phase, message string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.phase = phase
+	j.message = message
+	j.progress = JobProgress{}
+	j.updatedAt = time.Now()
+	j.publishLocked(JobEventPhase, gin.H{"phase": phase, "message": message})
+}
+
+// SetProgress 更新当前阶段的进度
+func (j *Job) SetProgress(current, total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.progress = JobProgress{Current: current, Total: total}
+	j.updatedAt = time.Now()
+	j.publishLocked(JobEventProgress, j.progress)
+}
+
+// PageStarted 记录页面开始生成
+func (j *Job) PageStarted(id, title string, attempt int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.publishLocked(JobEventPageStarted, gin.H{"id": id, "title": title, "attempt": attempt})
+}
+
+// PageCompleted 记录页面生成结束，成功时附带页面内容
+func (j *Job) PageCompleted(status models.PageStatus, page *models.WikiPage) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.publishLocked(JobEventPageCompleted, gin.H{"status": status, "page": page})
+}
+
+// PageToken 推送页面正在生成的文本片段
+func (j *Job) PageToken(id, delta string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.publishLocked(JobEventToken, gin.H{"id": id, "delta": delta})
+}
+
+// Subscribe 订阅任务事件，返回已发生的事件（不含文本片段）和后续事件的通道
+// 任务结束后通道会被关闭；调用方使用完毕后必须调用返回的取消函数
+func (j *Job) Subscribe() ([]JobEvent, <-chan JobEvent, func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	replay := make([]JobEvent, len(j.history))
+	copy(replay, j.history)
+
+	// 多留一个位置，保证断开订阅者时总能送达 dropped 事件
+	ch := make(chan JobEvent, jobSubscriberBuffer+1)
+	if j.finishedAt != nil {
+		close(ch)
+		return replay, ch, func() {}
+	}
+
+	if j.subscribers == nil {
+		j.subscribers = make(map[chan JobEvent]struct{})
+	}
+	j.subscribers[ch] = struct{}{}
+
+	return replay, ch, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publishLocked 向订阅者广播事件，调用方必须持有写锁
+// 文本片段不记录历史，订阅者缓冲区满时直接丢弃；其他事件在缓冲区满时向该订阅者发送 dropped 事件后断开，
+// 客户端据此区分任务结束和订阅被断开，可重新订阅获取历史
+func (j *Job) publishLocked(eventType string, data interface{}) {
+	event := JobEvent{Type: eventType, Data: data, Time: time.Now()}
+	if eventType != JobEventToken && len(j.history) < maxJobHistoryEntries {
+		j.history = append(j.history, event)
+	}
+
+	for ch := range j.subscribers {
+		if len(ch) < jobSubscriberBuffer {
+			ch <- event
+			continue
+		}
+		if eventType != JobEventToken {
+			ch <- JobEvent{Type: JobEventDropped, Data: gin.H{"error": "事件积压过多，订阅已断开，请重新订阅"}, Time: event.Time}
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Snapshot 返回任务的当前状态
+func (j *Job) Snapshot(includeResult bool) JobSnapshot {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	snapshot := JobSnapshot{
+		ID:         j.id,
+		Type:       j.jobType,
+		Status:     j.status,
+		Phase:      j.phase,
+		Message:    j.message,
+		Progress:   j.progress,
+		Error:      j.err,
+		CreatedAt:  j.createdAt,
+		UpdatedAt:  j.updatedAt,
+		FinishedAt: j.finishedAt,
+	}
+	if includeResult {
+		snapshot.Result = j.result
+	}
+	return snapshot
+}
+
+// finished 检查任务是否已经结束
+func (j *Job) finished() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.finishedAt != nil
+}
+
+// finish 记录任务的最终状态
+func (j *Job) finish(result interface{}, err error, canceled bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	j.updatedAt = now
+	j.finishedAt = &now
+	switch {
+	case canceled:
+		j.status = JobStatusCanceled
+		j.err = "任务已取消"
+	case err != nil:
+		j.status = JobStatusFailed
+		j.err = err.Error()
+	default:
+		j.status = JobStatusSucceeded
+		j.phase = JobPhaseDone
+		j.message = ""
+		j.result = result
+	}
+
+	j.publishLocked(JobEventDone, gin.H{"status": j.status, "error": j.err})
+	for ch := range j.subscribers {
+		close(ch)
+	}
+	j.subscribers = nil
+}
+
+// JobFunc 是任务的执行函数，返回值会作为任务结果保存
+type JobFunc func(ctx context.Context, job *Job) (interface{}, error)
+
+// JobManager 管理后台任务的执行、查询和取消
+type JobManager struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewJobManager 创建一个新的任务管理器
+func NewJobManager() *JobManager {
+	return &JobManager{
+		jobs: make(map[string]*Job),
+	}
+}
+
+// Start 在后台启动一个任务并立即返回
+func (m *JobManager) Start(jobType string, run JobFunc) *Job {
+	m.cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	job := &Job{
+		id:        uuid.New().String(),
+		jobType:   jobType,
+		status:    JobStatusQueued,
+		phase:     JobPhaseQueued,
+		createdAt: now,
+		updatedAt: now,
+		cancel:    cancel,
+	}
+
+	m.mu.Lock()
+	m.jobs[job.id] = job
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("任务 %s 发生异常: %v", job.id, r)
+				job.finish(nil, fmt.Errorf("任务异常: %v", r), false)
+			}
+		}()
+
+		job.mu.Lock()
+		job.status = JobStatusRunning
+		job.updatedAt = time.Now()
+		job.mu.Unlock()
+
+		result, err := run(ctx, job)
+		canceled := errors.Is(ctx.Err(), context.Canceled)
+		if err != nil && !canceled {
+			log.Printf("任务 %s (%s) 失败: %v", job.id, jobType, err)
+		}
+		job.finish(result, err, canceled)
+	}()
+
+	return job
+}
+
+// Get 根据ID获取任务
+func (m *JobManager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// List 返回所有任务，按创建时间倒序排列
+func (m *JobManager) List() []*Job {
+	m.mu.RLock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].createdAt.After(jobs[j].createdAt)
+	})
+	return jobs
+}
+
+// Cancel 取消一个正在运行的任务
+func (m *JobManager) Cancel(id string) error {
+	job, ok := m.Get(id)
+	if !ok {
+		return fmt.Errorf("任务 %s 不存在", id)
+	}
+	if job.finished() {
+		return fmt.Errorf("任务 %s 已结束", id)
+	}
+	job.cancel()
+	return nil
+}
+
+// cleanup 删除超过保留时间的已结束任务
+func (m *JobManager) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, job := range m.jobs {
+		job.mu.RLock()
+		expired := job.finishedAt != nil && time.Since(*job.finishedAt) > jobRetention
+		job.mu.RUnlock()
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// respondJobAccepted 返回任务已创建的响应
+func respondJobAccepted(c *gin.Context, job *Job) {
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":     job.ID(),
+		"status":     JobStatusQueued,
+		"status_url": "/jobs/" + job.ID(),
+	})
+}
+
+// handleListJobs 处理任务列表请求
+func (s *Server) handleListJobs(c *gin.Context) {
+	jobs := s.jobs.List()
+	snapshots := make([]JobSnapshot, 0, len(jobs))
+	for _, job := range jobs {
+		snapshots = append(snapshots, job.Snapshot(false))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  snapshots,
+		"count": len(snapshots),
+	})
+}
+
+// handleGetJob 处理任务状态查询请求，已完成的任务会包含结果
+func (s *Server) handleGetJob(c *gin.Context) {
+	job, ok := s.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, job.Snapshot(true))
+}
+
+// handleCancelJob 处理任务取消请求
+func (s *Server) handleCancelJob(c *gin.Context) {
+	id := c.Param("id")
+	if _, ok := s.jobs.Get(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+	if err := s.jobs.Cancel(id); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("任务 '%s' 已请求取消", id),
+	})
+}
+
+// handleJobEvents 以 SSE 推送任务事件：先重放已发生的阶段和页面事件，再实时推送后续事件和文本片段
+func (s *Server) handleJobEvents(c *gin.Context) {
+	job, ok := s.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	replay, events, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+
+	// 设置内容类型为SSE
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	for _, event := range replay {
+		c.SSEvent(event.Type, event)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(jobEventKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return event.Type != JobEventDone && event.Type != JobEventDropped
+		case <-keepAlive.C:
+			c.SSEvent("ping", gin.H{"time": time.Now()})
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
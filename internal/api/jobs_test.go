@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitJob 等待任务结束并返回最终状态
+func waitJob(t *testing.T, job *Job) JobSnapshot {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !job.finished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish", job.ID())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return job.Snapshot(true)
+}
+
+func TestJobManagerLifecycle(t *testing.T) {
+	m := NewJobManager()
+
+	release := make(chan struct{})
+	running := make(chan struct{})
+	ok := m.Start("wiki_generation", func(ctx context.Context, job *Job) (interface{}, error) {
+		close(running)
+		job.SetPhase(JobPhaseGenerating, "")
+		job.SetProgress(1, 2)
+		<-release
+		return "result", nil
+	})
+	<-running
+	if snapshot := ok.Snapshot(true); snapshot.Status != JobStatusRunning || snapshot.Result != nil {
+		t.Errorf("expected a running job without result, got %+v", snapshot)
+	}
+	close(release)
+	snapshot := waitJob(t, ok)
+	if snapshot.Status != JobStatusSucceeded || snapshot.Phase != JobPhaseDone || snapshot.Result != "result" || snapshot.FinishedAt == nil {
+		t.Errorf("unexpected finished job: %+v", snapshot)
+	}
+	if snapshot := ok.Snapshot(false); snapshot.Result != nil {
+		t.Errorf("result should only be included on request")
+	}
+
+	failed := m.Start("repo_sync", func(ctx context.Context, job *Job) (interface{}, error) {
+		job.SetPhase(JobPhaseIndexing, "")
+		return nil, errors.New("索引失败")
+	})
+	if snapshot := waitJob(t, failed); snapshot.Status != JobStatusFailed || snapshot.Error != "索引失败" || snapshot.Phase != JobPhaseIndexing {
+		t.Errorf("unexpected failed job: %+v", snapshot)
+	}
+
+	panicked := m.Start("repo_sync", func(ctx context.Context, job *Job) (interface{}, error) {
+		panic("boom")
+	})
+	if snapshot := waitJob(t, panicked); snapshot.Status != JobStatusFailed {
+		t.Errorf("panicking job should fail: %+v", snapshot)
+	}
+
+	// 按创建时间倒序列出
+	jobs := m.List()
+	if len(jobs) != 3 || jobs[0] != panicked || jobs[2] != ok {
+		t.Errorf("unexpected job order: %v", jobs)
+	}
+	if job, found := m.Get(failed.ID()); !found || job != failed {
+		t.Errorf("Get did not return the job")
+	}
+	if _, found := m.Get("missing"); found {
+		t.Errorf("Get found an unknown job")
+	}
+}
+
+func TestJobManagerCancel(t *testing.T) {
+	m := NewJobManager()
+
+	started := make(chan struct{})
+	job := m.Start("wiki_generation", func(ctx context.Context, job *Job) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+	if err := m.Cancel(job.ID()); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if snapshot := waitJob(t, job); snapshot.Status != JobStatusCanceled || snapshot.Result != nil {
+		t.Errorf("unexpected canceled job: %+v", snapshot)
+	}
+
+	// 已结束或不存在的任务不能取消
+	if err := m.Cancel(job.ID()); err == nil {
+		t.Errorf("canceling a finished job should fail")
+	}
+	if err := m.Cancel("missing"); err == nil {
+		t.Errorf("canceling an unknown job should fail")
+	}
+}
+
+func TestJobSubscribe(t *testing.T) {
+	m := NewJobManager()
+	step := make(chan struct{})
+	job := m.Start("wiki_generation", func(ctx context.Context, job *Job) (interface{}, error) {
+		job.SetPhase(JobPhaseGenerating, "")
+		<-step
+		job.PageToken("overview", "chunk")
+		job.SetProgress(1, 1)
+		<-step
+		return nil, nil
+	})
+
+	// 等待第一个阶段事件后订阅，已发生的事件会被重放
+	for {
+		if replay, _, unsubscribe := job.Subscribe(); len(replay) > 0 {
+			unsubscribe()
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	replay, events, unsubscribe := job.Subscribe()
+	if len(replay) != 1 || replay[0].Type != JobEventPhase {
+		t.Fatalf("unexpected replay: %+v", replay)
+	}
+	_, other, unsubscribeOther := job.Subscribe()
+	unsubscribeOther()
+	if _, open := <-other; open {
+		t.Errorf("unsubscribed channel should be closed")
+	}
+
+	step <- struct{}{}
+	if event := <-events; event.Type != JobEventToken {
+		t.Errorf("expected a token event, got %+v", event)
+	}
+	if event := <-events; event.Type != JobEventProgress {
+		t.Errorf("expected a progress event, got %+v", event)
+	}
+	step <- struct{}{}
+	if event := <-events; event.Type != JobEventDone {
+		t.Errorf("expected a done event, got %+v", event)
+	}
+	if _, open := <-events; open {
+		t.Errorf("channel should be closed after the job finished")
+	}
+	unsubscribe()
+
+	// 文本片段不进入历史；任务结束后订阅只得到历史和已关闭的通道
+	replay, events, _ = job.Subscribe()
+	var types []string
+	for _, event := range replay {
+		types = append(types, event.Type)
+	}
+	if strings.Join(types, ",") != "phase,progress,done" {
+		t.Errorf("unexpected history: %v", types)
+	}
+	if _, open := <-events; open {
+		t.Errorf("subscribing to a finished job should return a closed channel")
+	}
+}
+
+func TestJobSubscriberDropped(t *testing.T) {
+	job := &Job{}
+	_, events, unsubscribe := job.Subscribe()
+	defer unsubscribe()
+
+	job.mu.Lock()
+	for i := 0; i < jobSubscriberBuffer+10; i++ {
+		job.publishLocked(JobEventToken, nil)
+	}
+	// 缓冲区已满，丢弃文本片段但保留订阅；其他事件使订阅断开并收到 dropped 事件
+	if len(job.subscribers) != 1 {
+		t.Fatalf("token overflow should not drop the subscriber")
+	}
+	job.publishLocked(JobEventProgress, JobProgress{Current: 1, Total: 2})
+	job.mu.Unlock()
+
+	var last JobEvent
+	count := 0
+	for event := range events {
+		last = event
+		count++
+	}
+	if count != jobSubscriberBuffer+1 || last.Type != JobEventDropped {
+		t.Errorf("expected %d buffered events ending with dropped, got %d ending with %q", jobSubscriberBuffer+1, count, last.Type)
+	}
+}
@@ -0,0 +1,196 @@
+// internal/api/page_runner.go
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/rag"
+)
+
+// 页面生成的默认并发与重试设置
+const (
+	defaultPageConcurrency = 4
+	defaultPageTimeout     = 300 * time.Second
+	defaultPageMaxRetries  = 2
+)
+
+// pageRetryBaseDelay 是第一次重试前的等待时间，之后每次翻倍
+var pageRetryBaseDelay = 2 * time.Second
+
+// Page status values
+const (
+	PageStatusOK     = "ok"
+	PageStatusFailed = "failed"
+)
+
+// pageTask 表示一个待生成的页面
+type pageTask struct {
+	id    string
+	title string
+	run   func(ctx context.Context) (models.WikiPage, error)
+}
+
+// pageRunnerSettings 从配置中读取并发、超时和重试设置
+func (s *Server) pageRunnerSettings() (concurrency int, timeout time.Duration, maxRetries int) {
+	concurrency, timeout, maxRetries = defaultPageConcurrency, defaultPageTimeout, defaultPageMaxRetries
+	if s.config == nil {
+		return
+	}
+	if s.config.Wiki.Concurrency > 0 {
+		concurrency = s.config.Wiki.Concurrency
+	}
+	if s.config.Wiki.PageTimeoutSeconds > 0 {
+		timeout = time.Duration(s.config.Wiki.PageTimeoutSeconds) * time.Second
+	}
+	if s.config.Wiki.MaxRetries > 0 {
+		maxRetries = s.config.Wiki.MaxRetries
+	} else if s.config.Wiki.MaxRetries < 0 {
+		maxRetries = 0 // 负数表示禁用重试
+	}
+	return
+}
+
+// runPageTasks 以有限并发生成页面，返回的页面和状态与任务顺序一致
+// 生成失败的页面不会出现在页面列表中，但会在状态列表中记录错误
+// repoPath 不为空时按配置核对页面内容；每个页面在修复图表和核对之后才报告完成，推送的页面即最终保存的页面
+func (s *Server) runPageTasks(ctx context.Context, reporter progressReporter, repoPath string, tasks []pageTask) ([]models.WikiPage, []models.PageStatus) {
+	concurrency, timeout, maxRetries := s.pageRunnerSettings()
+
+	// 用于修复无效图表的提供者，获取失败时只校验不修复
+	provider, _ := s.manager.GetActiveProvider()
+
+	var ground func(content string) (string, *models.GroundingReport)
+	if repoPath != "" {
+		ground = s.pageGrounder(repoPath)
+	}
+
+	var doneMu sync.Mutex
+	done := 0
+	reporter.SetProgress(0, len(tasks))
+
+	results := make([]*models.WikiPage, len(tasks))
+	statuses := make([]models.PageStatus, len(tasks))
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task pageTask) {
+			defer wg.Done()
+
+			status := models.PageStatus{ID: task.id, Title: task.title}
+			start := time.Now()
+			defer func() {
+				status.DurationMs = time.Since(start).Milliseconds()
+				statuses[i] = status
+				reporter.PageCompleted(status, results[i])
+
+				doneMu.Lock()
+				done++
+				reporter.SetProgress(done, len(tasks))
+				doneMu.Unlock()
+			}()
+			// 生成、修复图表或核对时的异常只让当前页面失败，不影响其他页面和服务
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("生成页面 %s 时发生异常: %v", task.id, r)
+					results[i] = nil
+					status.Status = PageStatusFailed
+					status.Error = fmt.Sprintf("页面生成异常: %v", r)
+				}
+			}()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				status.Status = PageStatusFailed
+				status.Error = ctx.Err().Error()
+				return
+			}
+
+			page, attempts, err := runPageWithRetry(ctx, reporter, task, timeout, maxRetries)
+			status.Attempts = attempts
+			if err != nil {
+				log.Printf("生成页面 %s 失败（尝试 %d 次）: %v", task.id, attempts, err)
+				status.Status = PageStatusFailed
+				status.Error = err.Error()
+				return
+			}
+			page.Content, status.Diagrams = s.repairPageDiagrams(ctx, provider, page.Content, timeout)
+			if ground != nil {
+				page.Content, status.Grounding = ground(page.Content)
+			}
+			status.Status = PageStatusOK
+			results[i] = &page
+		}(i, task)
+	}
+	wg.Wait()
+
+	pages := make([]models.WikiPage, 0, len(tasks))
+	for _, page := range results {
+		if page != nil {
+			pages = append(pages, *page)
+		}
+	}
+	return pages, statuses
+}
+
+// runPageWithRetry 在单页超时内执行任务，遇到临时错误时按指数退避重试
+func runPageWithRetry(ctx context.Context, reporter progressReporter, task pageTask, timeout time.Duration, maxRetries int) (models.WikiPage, int, error) {
+	// 将生成中的文本片段推送给进度接收者
+	ctx = rag.WithChunkObserver(ctx, func(chunk string) {
+		reporter.PageToken(task.id, chunk)
+	})
+
+	var lastErr error
+	for attempt := 1; attempt <= maxRetries+1; attempt++ {
+		reporter.PageStarted(task.id, task.title, attempt)
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		page, err := task.run(attemptCtx)
+		cancel()
+		if err == nil {
+			return page, attempt, nil
+		}
+		lastErr = err
+
+		if ctx.Err() != nil || !rag.IsTransientError(err) || attempt > maxRetries {
+			return models.WikiPage{}, attempt, lastErr
+		}
+
+		delay := pageRetryBaseDelay << (attempt - 1)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return models.WikiPage{}, attempt, ctx.Err()
+		}
+	}
+	return models.WikiPage{}, maxRetries + 1, lastErr
+}
+
+// pageGrounder 建立仓库的符号索引，返回按配置核对页面中引用的文件和代码符号、标注或删除未验证内容的函数
+// 未启用核对或建立索引失败时返回 nil
+func (s *Server) pageGrounder(repoPath string) func(content string) (string, *models.GroundingReport) {
+	mode := data.GroundingAnnotate
+	if s.config != nil && s.config.Wiki.Grounding != "" {
+		mode = s.config.Wiki.Grounding
+	}
+	if mode == data.GroundingOff {
+		return nil
+	}
+
+	index, err := data.BuildSymbolIndex(repoPath)
+	if err != nil {
+		log.Printf("建立符号索引失败，跳过内容核对: %v", err)
+		return nil
+	}
+	return func(content string) (string, *models.GroundingReport) {
+		return data.VerifyGrounding(content, index, mode)
+	}
+}
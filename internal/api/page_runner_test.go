@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/rag"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// attemptRecorder 记录每次开始生成页面的尝试次数
+type attemptRecorder struct {
+	nopReporter
+	mu       sync.Mutex
+	attempts []int
+}
+
+func (r *attemptRecorder) PageStarted(id, title string, attempt int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, attempt)
+}
+
+// failingTask 返回一个前 failures 次调用返回 err、之后成功的任务
+func failingTask(failures int, err error) (pageTask, *int) {
+	calls := 0
+	return pageTask{id: "overview", title: "Overview", run: func(ctx context.Context) (models.WikiPage, error) {
+		calls++
+		if calls <= failures {
+			return models.WikiPage{}, err
+		}
+		return models.WikiPage{ID: "overview"}, nil
+	}}, &calls
+}
+
+func setRetryDelay(t *testing.T, delay time.Duration) {
+	t.Helper()
+	previous := pageRetryBaseDelay
+	pageRetryBaseDelay = delay
+	t.Cleanup(func() { pageRetryBaseDelay = previous })
+}
+
+func TestRunPageWithRetry(t *testing.T) {
+	setRetryDelay(t, 10*time.Millisecond)
+	transient := &openai.APIError{HTTPStatusCode: 503}
+
+	// 临时错误按指数退避重试：10ms + 20ms
+	reporter := &attemptRecorder{}
+	task, calls := failingTask(2, transient)
+	start := time.Now()
+	page, attempts, err := runPageWithRetry(context.Background(), reporter, task, time.Second, 2)
+	if err != nil || page.ID != "overview" || attempts != 3 || *calls != 3 {
+		t.Fatalf("expected success on the third attempt, got %v after %d attempts", err, attempts)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("retries did not back off: %v", elapsed)
+	}
+	if len(reporter.attempts) != 3 || reporter.attempts[2] != 3 {
+		t.Errorf("unexpected started events: %v", reporter.attempts)
+	}
+
+	// 重试次数用尽后返回最后一个错误
+	task, calls = failingTask(5, transient)
+	if _, attempts, err := runPageWithRetry(context.Background(), nopReporter{}, task, time.Second, 1); !errors.Is(err, transient) || attempts != 2 || *calls != 2 {
+		t.Errorf("expected 2 attempts ending in the transient error, got %v after %d attempts", err, attempts)
+	}
+
+	// 非临时错误不重试
+	permanent := &openai.APIError{HTTPStatusCode: 400}
+	task, calls = failingTask(1, permanent)
+	if _, attempts, err := runPageWithRetry(context.Background(), nopReporter{}, task, time.Second, 2); !errors.Is(err, permanent) || attempts != 1 || *calls != 1 {
+		t.Errorf("permanent errors should not be retried: %v after %d attempts", err, attempts)
+	}
+
+	// 单次尝试超时属于临时错误
+	slow := 0
+	task = pageTask{id: "slow", run: func(ctx context.Context) (models.WikiPage, error) {
+		slow++
+		if slow == 1 {
+			<-ctx.Done()
+			return models.WikiPage{}, ctx.Err()
+		}
+		return models.WikiPage{ID: "slow"}, nil
+	}}
+	if _, attempts, err := runPageWithRetry(context.Background(), nopReporter{}, task, 20*time.Millisecond, 1); err != nil || attempts != 2 {
+		t.Errorf("timed out attempt should be retried: %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRunPageWithRetryCancel(t *testing.T) {
+	setRetryDelay(t, time.Hour)
+
+	// 等待重试期间取消时立即返回
+	ctx, cancel := context.WithCancel(context.Background())
+	task, calls := failingTask(5, &openai.APIError{HTTPStatusCode: 429})
+	time.AfterFunc(20*time.Millisecond, cancel)
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := runPageWithRetry(ctx, nopReporter{}, task, time.Second, 3)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || *calls != 1 {
+			t.Errorf("expected cancellation after one attempt, got %v after %d calls", err, *calls)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runPageWithRetry did not return after cancellation")
+	}
+
+	// 已取消的任务不再重试
+	task, calls = failingTask(1, context.Canceled)
+	if _, attempts, err := runPageWithRetry(ctx, nopReporter{}, task, time.Second, 3); err == nil || attempts != 1 || *calls != 1 {
+		t.Errorf("canceled context should stop retries: %v after %d attempts", err, attempts)
+	}
+}
+
+func TestPageGrounder(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := "Start in main.go, not `missing.go`."
+
+	tests := []struct {
+		name    string
+		config  *config.Config
+		want    string
+		enabled bool
+	}{
+		{name: "no config", want: "Start in main.go, not `missing.go` *(未验证)*.", enabled: true},
+		{name: "default", config: &config.Config{}, want: "Start in main.go, not `missing.go` *(未验证)*.", enabled: true},
+		{name: "strip", config: &config.Config{Wiki: config.WikiConfig{Grounding: data.GroundingStrip}}, want: "Start in main.go, not.", enabled: true},
+		{name: "off", config: &config.Config{Wiki: config.WikiConfig{Grounding: data.GroundingOff}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ground := (&Server{config: tt.config}).pageGrounder(root)
+			if !tt.enabled {
+				if ground != nil {
+					t.Fatal("grounding should be disabled")
+				}
+				return
+			}
+			got, report := ground(content)
+			if got != tt.want || strings.Join(report.Unverified, ",") != "missing.go" {
+				t.Errorf("got %q (%+v), want %q", got, report, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPageTasksRecoversPanics(t *testing.T) {
+	s := &Server{config: &config.Config{}, manager: rag.NewRAGManager(&config.Config{})}
+	tasks := []pageTask{
+		{id: "broken", title: "Broken", run: func(ctx context.Context) (models.WikiPage, error) {
+			// 只有分号的图表头曾导致校验时越界
+			return models.WikiPage{ID: "broken", Content: "```mermaid\n;\n```\n"}, nil
+		}},
+		{id: "panics", title: "Panics", run: func(ctx context.Context) (models.WikiPage, error) {
+			panic("boom")
+		}},
+		{id: "overview", title: "Overview", run: func(ctx context.Context) (models.WikiPage, error) {
+			return models.WikiPage{ID: "overview", Content: "ok"}, nil
+		}},
+	}
+	pages, statuses := s.runPageTasks(context.Background(), nopReporter{}, "", tasks)
+	if len(pages) != 2 || pages[0].ID != "broken" || pages[1].ID != "overview" {
+		t.Errorf("unexpected pages: %+v", pages)
+	}
+	if statuses[0].Status != PageStatusOK || statuses[0].Diagrams == nil || statuses[0].Diagrams.Invalid != 1 {
+		t.Errorf("invalid diagram should be reported, not crash: %+v", statuses[0])
+	}
+	if statuses[1].Status != PageStatusFailed || !strings.Contains(statuses[1].Error, "boom") {
+		t.Errorf("panicking page should be marked failed: %+v", statuses[1])
+	}
+}
@@ -0,0 +1,97 @@
+// internal/api/prompts.go
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/internal/rag"
+)
+
+// languageKey 是上下文中输出语言的键
+type languageKey struct{}
+
+// withLanguage 返回携带输出语言的上下文，页面和回答的提示词按该语言渲染
+func withLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// language 返回上下文中的输出语言，未设置时使用配置的默认语言
+func (s *Server) language(ctx context.Context) string {
+	if lang, _ := ctx.Value(languageKey{}).(string); lang != "" {
+		return lang
+	}
+	return s.defaultLanguage()
+}
+
+// defaultLanguage 返回配置的默认输出语言，配置无效时使用内置默认值
+func (s *Server) defaultLanguage() string {
+	if s.config != nil {
+		if lang, err := prompts.NormalizeLanguage(s.config.Wiki.Language); err == nil && lang != "" {
+			return lang
+		}
+	}
+	return prompts.DefaultLanguage
+}
+
+// requestLanguage 校验请求中的语言参数，为空时使用默认语言
+func (s *Server) requestLanguage(lang string) (string, error) {
+	lang, err := prompts.NormalizeLanguage(lang)
+	if err != nil {
+		return "", err
+	}
+	if lang == "" {
+		lang = s.defaultLanguage()
+	}
+	return lang, nil
+}
+
+// renderPrompt 以上下文中的语言渲染提示词模板，并带上用户对页面的补充要求
+func (s *Server) renderPrompt(ctx context.Context, name string, data prompts.Data) (string, error) {
+	data.Guidance = pageGuidance(ctx)
+	return s.prompts.Render(s.language(ctx), name, data)
+}
+
+// promptText 渲染标题等短文本，模板出错时记录日志并返回 fallback
+func (s *Server) promptText(ctx context.Context, name string, data prompts.Data, fallback string) string {
+	text, err := s.prompts.Render(s.language(ctx), name, data)
+	if err != nil || text == "" {
+		log.Printf("渲染 %s 失败: %v", name, err)
+		return fallback
+	}
+	return text
+}
+
+// pageText 返回以上下文中的语言渲染确定性页面固定文字的函数
+func (s *Server) pageText(ctx context.Context) data.PageText {
+	return func(name string, d prompts.Data) string {
+		return s.promptText(ctx, name, d, name)
+	}
+}
+
+// sourceLabel 返回以上下文中的语言渲染提示词中来源说明的函数
+func (s *Server) sourceLabel(ctx context.Context) rag.SourceLabel {
+	return func(source models.Citation) string {
+		d := prompts.Data{File: source.FilePath, From: strconv.Itoa(source.StartLine), To: strconv.Itoa(source.EndLine)}
+		return s.promptText(ctx, "source_label", d, fmt.Sprintf("File: %s (lines %d-%d)", source.FilePath, source.StartLine, source.EndLine))
+	}
+}
+
+// loadPrompts 加载提示词模板，自定义目录加载失败时退回到内置模板
+func loadPrompts(dir string) *prompts.Library {
+	library, err := prompts.Load(dir)
+	if err == nil {
+		return library
+	}
+	log.Printf("加载提示词模板失败，使用内置模板: %v", err)
+	library, err = prompts.Load("")
+	if err != nil {
+		log.Fatalf("加载内置提示词模板失败: %v", err)
+	}
+	return library
+}
@@ -14,6 +14,11 @@ func (s *Server) RegisterRoutes() {
 	{
 		// 聊天相关
 		auth.POST("/chat/completions/stream", s.handleChatCompletions)
+		auth.POST("/chat/sessions", s.handleCreateChatSession)
+		auth.GET("/chat/sessions", s.handleListChatSessions)
+		auth.GET("/chat/sessions/:id", s.handleGetChatSession)
+		auth.DELETE("/chat/sessions/:id", s.handleDeleteChatSession)
+		auth.POST("/chat/sessions/:id/messages", s.handleChatSessionMessage)
 
 		// 文档相关
 		auth.POST("/docs/search", s.handleVectorSearch)
@@ -23,11 +28,28 @@ func (s *Server) RegisterRoutes() {
 		// Wiki相关
 		auth.POST("/wiki/generate", s.handleGenerateWiki)
 		auth.POST("/wiki/export", s.handleExportWiki)
+		auth.POST("/wiki/import", s.handleImportWiki)
+		auth.POST("/wiki/changelog", s.handleGenerateChangelog)
+		auth.GET("/wikis", s.handleListWikis)
+		auth.GET("/wikis/:repo", s.handleGetWiki)
+		auth.GET("/wikis/:repo/pages/:pageId", s.handleGetWikiPage)
+		auth.PUT("/wikis/:repo/pages/:pageId", s.handleEditPage)
+		auth.POST("/wikis/:repo/pages/:pageId/regenerate", s.handleRegeneratePage)
+		auth.GET("/wikis/:repo/pages/:pageId/revisions", s.handleListPageRevisions)
+		auth.GET("/wikis/:repo/staleness", s.handleWikiStaleness)
+		auth.POST("/wikis/:repo/refresh", s.handleRefreshWiki)
+		auth.POST("/wikis/:repo/translate", s.handleTranslateWiki)
 
 		// 仓库相关
 		auth.POST("/repo/analyze", s.handleAnalyzeRepo)
 		auth.POST("/repo/sync", s.handleSyncRepo)
 
+		// 后台任务相关
+		auth.GET("/jobs", s.handleListJobs)
+		auth.GET("/jobs/:id", s.handleGetJob)
+		auth.DELETE("/jobs/:id", s.handleCancelJob)
+		auth.GET("/jobs/:id/events", s.handleJobEvents)
+
 		// 向量相关
 		auth.POST("/vectors/search", s.handleVectorSearch)
 		auth.POST("/vectors/index", s.handleIndexVectors)
@@ -0,0 +1,243 @@
+// internal/api/translate.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/internal/rag"
+	"github.com/gin-gonic/gin"
+)
+
+// maxTranslateChunkChars 是单次翻译请求的最大文本长度
+const maxTranslateChunkChars = 6000
+
+// errPlaceholdersChanged 表示译文没有完整保留占位符
+var errPlaceholdersChanged = errors.New("译文没有完整保留代码、链接和引用的占位符")
+
+// translateWikiRequest 表示将已保存 Wiki 翻译为其他语言的请求
+type translateWikiRequest struct {
+	Language  string `json:"language" binding:"required"` // 目标语言，例如 en、ja
+	Overwrite bool   `json:"overwrite,omitempty"`         // 是否覆盖目标语言版本中人工编辑过的页面
+}
+
+// handleTranslateWiki 以后台任务将已保存的 Wiki 翻译为目标语言，结果保存为同一提交上的另一语言版本
+func (s *Server) handleTranslateWiki(c *gin.Context) {
+	var req translateWikiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
+		return
+	}
+	target, err := prompts.NormalizeLanguage(req.Language)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+	if target == s.wikiLanguage(wiki) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Wiki 已经是 %s 版本", target)})
+		return
+	}
+
+	// 提前检查 RAG 提供者，避免创建注定失败的任务
+	if _, err := s.manager.GetActiveProvider(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 RAG 提供者失败: %v", err)})
+		return
+	}
+
+	job := s.jobs.Start("wiki_translate", func(ctx context.Context, job *Job) (interface{}, error) {
+		return s.runWikiTranslation(ctx, job, wiki, target, req.Overwrite)
+	})
+	respondJobAccepted(c, job)
+}
+
+// runWikiTranslation 逐页翻译 Wiki，不重新检索代码；翻译失败的页面保留原文并在页面状态中记录错误
+func (s *Server) runWikiTranslation(ctx context.Context, reporter progressReporter, wiki *models.Wiki, target string, overwrite bool) (interface{}, error) {
+	provider, err := s.manager.GetActiveProvider()
+	if err != nil {
+		return nil, fmt.Errorf("获取 RAG 提供者失败: %v", err)
+	}
+	source := s.wikiLanguage(wiki)
+	ctx = withLanguage(ctx, target)
+
+	tasks := make([]pageTask, 0, len(wiki.Pages))
+	for _, page := range wiki.Pages {
+		page := page
+		tasks = append(tasks, pageTask{
+			id:    page.ID,
+			title: page.Title,
+			run: func(ctx context.Context) (models.WikiPage, error) {
+				return s.translatePage(ctx, provider, page, source)
+			},
+		})
+	}
+
+	reporter.SetPhase(JobPhaseTranslating, target)
+	pages, statuses := s.runPageTasks(ctx, reporter, "", tasks)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	translatedPages := make(map[string]models.WikiPage, len(pages))
+	for _, page := range pages {
+		translatedPages[page.ID] = page
+	}
+
+	translated := &models.Wiki{
+		RepoURL:        wiki.RepoURL,
+		RepoKey:        wiki.RepoKey,
+		Ref:            wiki.Ref,
+		Commit:         wiki.Commit,
+		Provider:       provider.Name(),
+		Model:          rag.ModelName(provider),
+		Mode:           wiki.Mode,
+		Language:       target,
+		GeneratedAt:    time.Now().UTC(),
+		Plan:           s.translatePlan(ctx, provider, wiki.Plan, translatedPages, source),
+		PageStatus:     statuses,
+		PromptVersions: s.prompts.Versions(target),
+		TranslatedFrom: wiki.ID,
+	}
+	for _, page := range wiki.Pages {
+		if t, ok := translatedPages[page.ID]; ok {
+			page = t
+		}
+		translated.Pages = append(translated.Pages, page)
+	}
+	if previous, err := s.wikis.Latest(wiki.RepoKey, wiki.Ref, wiki.Commit, target); err == nil {
+		preserveHumanEdits(translated, previous, overwrite)
+	}
+
+	if err := s.wikis.Save(translated); err != nil {
+		return nil, fmt.Errorf("保存 Wiki 失败: %v", err)
+	}
+	return gin.H{
+		"wiki":        translated.Summary(),
+		"page_status": statuses,
+	}, nil
+}
+
+// translatePage 翻译页面的标题和正文，代码块、图表、链接和引用原样保留
+func (s *Server) translatePage(ctx context.Context, provider rag.RAGProvider, page models.WikiPage, source string) (models.WikiPage, error) {
+	title, err := s.translateMarkdown(ctx, provider, page.Title, source)
+	if err != nil {
+		return models.WikiPage{}, err
+	}
+	content, err := s.translateMarkdown(ctx, provider, page.Content, source)
+	if err != nil {
+		return models.WikiPage{}, err
+	}
+
+	page.Title = strings.TrimSpace(title)
+	page.Content = content
+	page.Revision = 0
+	page.HumanAuthored = false
+	return page, nil
+}
+
+// translateMarkdown 遮盖不应翻译的内容后分段翻译，再还原被遮盖的内容
+func (s *Server) translateMarkdown(ctx context.Context, provider rag.RAGProvider, content, source string) (string, error) {
+	masked, kept := data.MaskMarkdown(content)
+	chunks := data.SplitMarkdown(masked, maxTranslateChunkChars)
+	parts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		part, err := s.translateText(ctx, provider, chunk, source)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return data.UnmaskMarkdown(strings.Join(parts, "\n\n"), kept)
+}
+
+// translateText 翻译一段已遮盖的文本，译文中的占位符与原文不一致时重试一次
+func (s *Server) translateText(ctx context.Context, provider rag.RAGProvider, text, source string) (string, error) {
+	if !data.HasTranslatableText(text) {
+		return text, nil
+	}
+	prompt, err := s.renderPrompt(ctx, "translate", prompts.Data{Content: text, SourceLanguage: prompts.LanguageName(source)})
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		response, err := rag.GenerateText(ctx, provider, prompt)
+		if err != nil {
+			return "", err
+		}
+		response = unwrapTranslation(response)
+		if data.SamePlaceholders(text, response) {
+			return response, nil
+		}
+	}
+	return "", errPlaceholdersChanged
+}
+
+// translatePlan 翻译大纲中的标题，页面标题使用已翻译页面的标题，翻译失败时保留原文
+func (s *Server) translatePlan(ctx context.Context, provider rag.RAGProvider, plan *models.WikiPlan, pages map[string]models.WikiPage, source string) *models.WikiPlan {
+	if plan == nil {
+		return nil
+	}
+	translated := *plan
+	translated.Pages = append([]models.PlannedPage(nil), plan.Pages...)
+	translated.Sections = append([]models.WikiSection(nil), plan.Sections...)
+	for i := range translated.Pages {
+		if page, ok := pages[translated.Pages[i].ID]; ok {
+			translated.Pages[i].Title = page.Title
+		}
+	}
+
+	// 大纲标题、说明和章节标题逐行放在一次请求中翻译
+	lines := []string{plan.Title, strings.ReplaceAll(plan.Description, "\n", " ")}
+	for _, section := range plan.Sections {
+		lines = append(lines, section.Title)
+	}
+	result, err := s.translateText(ctx, provider, strings.Join(lines, "\n"), source)
+	if err != nil {
+		return &translated
+	}
+	out := strings.Split(result, "\n")
+	if len(out) != len(lines) {
+		return &translated
+	}
+	translated.Title = strings.TrimSpace(out[0])
+	translated.Description = strings.TrimSpace(out[1])
+	for i := range translated.Sections {
+		translated.Sections[i].Title = strings.TrimSpace(out[i+2])
+	}
+	return &translated
+}
+
+// wikiLanguage 返回 Wiki 的语言，未记录语言的旧 Wiki 视为默认语言
+func (s *Server) wikiLanguage(wiki *models.Wiki) string {
+	if wiki.Language != "" {
+		return wiki.Language
+	}
+	return s.defaultLanguage()
+}
+
+// unwrapTranslation 去掉模型在译文外层添加的代码块标记
+// 原文中的代码块都已被遮盖，译文中出现的 ``` 只可能是多余的包裹
+func unwrapTranslation(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") {
+		text = strings.TrimSuffix(text, "```")
+		if i := strings.Index(text, "\n"); i >= 0 {
+			text = text[i+1:]
+		} else {
+			text = ""
+		}
+	}
+	return strings.TrimSpace(text)
+}
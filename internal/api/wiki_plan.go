@@ -0,0 +1,411 @@
+// internal/api/wiki_plan.go
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/internal/rag"
+)
+
+// Wiki 规划模式
+const (
+	PlanModeConcise       = "concise"
+	PlanModeComprehensive = "comprehensive"
+)
+
+const (
+	maxPlanTreeEntries   = 600   // 规划提示中列出的最大文件数
+	maxPlanReadmeChars   = 8000  // 规划提示中 README 的最大字符数
+	maxPageFileChars     = 12000 // 单个文件放入页面上下文的最大字符数
+	maxPageContextChars  = 60000 // 页面上下文的最大字符数
+	conciseMaxPages      = 6
+	comprehensiveMaxPage = 16
+)
+
+// pageIDPattern 用于清理页面ID中的非法字符
+var pageIDPattern = regexp.MustCompile(`[^a-z0-9\-]+`)
+
+// normalizePlanMode 规范化规划模式，未知值使用简洁模式
+func normalizePlanMode(mode string) string {
+	if strings.EqualFold(mode, PlanModeComprehensive) {
+		return PlanModeComprehensive
+	}
+	return PlanModeConcise
+}
+
+// planWikiStructure 让模型根据文件树和 README 规划 Wiki 结构
+func (s *Server) planWikiStructure(ctx context.Context, analysis map[string]interface{}, repoPath, repoURL, mode string, provider rag.RAGProvider) (*models.WikiPlan, error) {
+	structure, _ := analysis["structure"].(map[string]interface{})
+	files := flattenStructure(structure)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("仓库中没有可规划的文件")
+	}
+
+	tree := files
+	if len(tree) > maxPlanTreeEntries {
+		tree = tree[:maxPlanTreeEntries]
+	}
+
+	prompt, err := s.renderPrompt(ctx, "plan", prompts.Data{
+		RepoURL: repoURL,
+		Tree:    strings.Join(tree, "\n"),
+		Readme:  readReadme(repoPath),
+		Mode:    mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := rag.GenerateText(ctx, provider, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := parseWikiPlan(response)
+	if err != nil {
+		return nil, err
+	}
+	plan.Mode = mode
+
+	maxPages := conciseMaxPages
+	if mode == PlanModeComprehensive {
+		maxPages = comprehensiveMaxPage
+	}
+	if err := validateWikiPlan(plan, files, maxPages); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// parseWikiPlan 从模型输出中解析 JSON 格式的大纲
+// 模型可能用代码块包裹 JSON，或在前后附加说明文字，因此从每个 "{" 开始尝试解析一个完整的 JSON 对象，
+// 忽略其后的内容
+func parseWikiPlan(response string) (*models.WikiPlan, error) {
+	var firstErr error
+	for offset := 0; ; {
+		start := strings.Index(response[offset:], "{")
+		if start < 0 {
+			break
+		}
+		start += offset
+		offset = start + 1
+
+		var plan models.WikiPlan
+		if err := json.NewDecoder(strings.NewReader(response[start:])).Decode(&plan); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		return &plan, nil
+	}
+	if firstErr != nil {
+		return nil, fmt.Errorf("解析 JSON 大纲失败: %v", firstErr)
+	}
+	return nil, fmt.Errorf("模型输出中未找到大纲")
+}
+
+// validateWikiPlan 校验并修正大纲：规范化ID、过滤不存在的文件和页面引用、补全章节
+func validateWikiPlan(plan *models.WikiPlan, files []string, maxPages int) error {
+	fileSet := make(map[string]bool, len(files))
+	dirSet := make(map[string]bool)
+	for _, f := range files {
+		fileSet[f] = true
+		for dir := filepath.ToSlash(filepath.Dir(f)); dir != "." && dir != "/"; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			dirSet[dir] = true
+		}
+	}
+
+	// 规范化页面
+	idMap := make(map[string]string)
+	seen := make(map[string]bool)
+	var pages []models.PlannedPage
+	for _, page := range plan.Pages {
+		if strings.TrimSpace(page.Title) == "" {
+			continue
+		}
+		id := normalizePageID(page.ID)
+		if id == "" {
+			id = normalizePageID(page.Title)
+		}
+		if id == "" {
+			id = fmt.Sprintf("page-%d", len(pages)+1)
+		}
+		for base, n := id, 2; seen[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		seen[id] = true
+		idMap[page.ID] = id
+		page.ID = id
+		page.Title = strings.TrimSpace(page.Title)
+
+		switch strings.ToLower(page.Importance) {
+		case "high", "medium", "low":
+			page.Importance = strings.ToLower(page.Importance)
+		default:
+			page.Importance = "medium"
+		}
+
+		// 只保留仓库中存在的文件和目录
+		var validPaths []string
+		for _, p := range page.FilePaths {
+			p = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(p)), "./")
+			trimmed := strings.TrimSuffix(p, "/")
+			if fileSet[p] {
+				validPaths = append(validPaths, p)
+			} else if dirSet[trimmed] {
+				validPaths = append(validPaths, trimmed+"/")
+			}
+		}
+		page.FilePaths = validPaths
+
+		pages = append(pages, page)
+		if len(pages) >= maxPages {
+			break
+		}
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("大纲中没有有效的页面")
+	}
+
+	// 修正页面之间的引用
+	for i := range pages {
+		var related []string
+		relatedSeen := make(map[string]bool)
+		for _, r := range pages[i].RelatedPages {
+			id, ok := idMap[r]
+			if !ok {
+				id = normalizePageID(r)
+			}
+			if seen[id] && id != pages[i].ID && !relatedSeen[id] {
+				related = append(related, id)
+				relatedSeen[id] = true
+			}
+		}
+		pages[i].RelatedPages = related
+	}
+
+	// 修正章节，每个页面只属于一个章节
+	assigned := make(map[string]bool)
+	var sections []models.WikiSection
+	for _, sec := range plan.Sections {
+		secID := normalizePageID(sec.ID)
+		if secID == "" {
+			secID = normalizePageID(sec.Title)
+		}
+		if secID == "" {
+			secID = fmt.Sprintf("section-%d", len(sections)+1)
+		}
+		var secPages []string
+		for _, ref := range sec.Pages {
+			id, ok := idMap[ref]
+			if !ok {
+				id = normalizePageID(ref)
+			}
+			if seen[id] && !assigned[id] {
+				secPages = append(secPages, id)
+				assigned[id] = true
+			}
+		}
+		if len(secPages) > 0 {
+			title := strings.TrimSpace(sec.Title)
+			if title == "" {
+				title = secID
+			}
+			sections = append(sections, models.WikiSection{ID: secID, Title: title, Pages: secPages})
+		}
+	}
+
+	var unassigned []string
+	for _, page := range pages {
+		if !assigned[page.ID] {
+			unassigned = append(unassigned, page.ID)
+		}
+	}
+	if len(unassigned) > 0 {
+		sections = append(sections, models.WikiSection{ID: "general", Title: "概览", Pages: unassigned})
+	}
+
+	sectionOf := make(map[string]string)
+	for _, sec := range sections {
+		for _, id := range sec.Pages {
+			sectionOf[id] = sec.ID
+		}
+	}
+	for i := range pages {
+		pages[i].Section = sectionOf[pages[i].ID]
+	}
+
+	plan.Pages = pages
+	plan.Sections = sections
+	return nil
+}
+
+// generatePlannedPage 根据大纲中分配的文件生成单个页面，commit 是 repoPath 中检出的提交
+func (s *Server) generatePlannedPage(ctx context.Context, page models.PlannedPage, plan *models.WikiPlan, repoPath, repoURL, commit string, provider rag.RAGProvider) (models.WikiPage, error) {
+	var context strings.Builder
+	var sources []models.Citation
+
+	// 优先使用分配给页面的文件内容
+	for _, file := range expandPlannedFiles(repoPath, page.FilePaths) {
+		if context.Len() >= maxPageContextChars {
+			break
+		}
+		content, err := os.ReadFile(filepath.Join(repoPath, file))
+		if err != nil {
+			continue
+		}
+		text := truncateText(string(content), maxPageFileChars)
+		source := models.Citation{
+			Index:     len(sources) + 1,
+			FilePath:  file,
+			StartLine: 1,
+			EndLine:   strings.Count(text, "\n") + 1,
+			Commit:    commit,
+		}
+		context.WriteString(rag.FormatSource(source, text, s.sourceLabel(ctx)))
+		sources = append(sources, source)
+	}
+
+	// 使用检索结果补充上下文
+	query := page.Title
+	if page.Description != "" {
+		query += ": " + page.Description
+	}
+	if docs, err := provider.RetrieveDocuments(query); err == nil && context.Len() < maxPageContextChars {
+		retrieved, retrievedSources := rag.NumberDocuments(docs, len(sources)+1, maxPageContextChars-context.Len(), s.sourceLabel(ctx))
+		context.WriteString(retrieved)
+		sources = append(sources, retrievedSources...)
+	}
+
+	// 列出相关页面，便于模型在正文中引用
+	var relatedTitles []string
+	for _, id := range page.RelatedPages {
+		for _, p := range plan.Pages {
+			if p.ID == id {
+				relatedTitles = append(relatedTitles, fmt.Sprintf("%s (%s)", p.Title, p.ID))
+			}
+		}
+	}
+
+	prompt, err := s.renderPrompt(ctx, "page", prompts.Data{
+		Repo:        getRepoNameFromURL(repoURL),
+		Title:       page.Title,
+		Description: page.Description,
+		Related:     strings.Join(relatedTitles, ", "),
+		Context:     context.String(),
+	})
+	if err != nil {
+		return models.WikiPage{}, err
+	}
+
+	content, err := rag.GenerateText(ctx, provider, prompt)
+	if err != nil {
+		return models.WikiPage{}, err
+	}
+	content, citations, filePaths := s.resolvePageCitations(ctx, content, sources, repoURL, commit)
+
+	return models.WikiPage{
+		ID:           page.ID,
+		Title:        page.Title,
+		Content:      content,
+		FilePaths:    filePaths,
+		Importance:   page.Importance,
+		RelatedPages: page.RelatedPages,
+		Section:      page.Section,
+		Citations:    citations,
+	}, nil
+}
+
+// expandPlannedFiles 将页面分配的文件和目录展开为文件列表，目录只展开其直接包含的文件
+func expandPlannedFiles(repoPath string, paths []string) []string {
+	var files []string
+	for _, p := range paths {
+		if !strings.HasSuffix(p, "/") {
+			files = append(files, p)
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(repoPath, p))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, p+entry.Name())
+			}
+		}
+	}
+	return files
+}
+
+// flattenStructure 将仓库结构转换为排序后的相对文件路径列表
+func flattenStructure(structure map[string]interface{}) []string {
+	var files []string
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		for name, child := range node {
+			if strings.HasPrefix(name, ".") || isNonEssentialDir(name) {
+				continue
+			}
+			p := prefix + name
+			if sub, ok := child.(map[string]interface{}); ok {
+				walk(p+"/", sub)
+			} else {
+				files = append(files, p)
+			}
+		}
+	}
+	walk("", structure)
+	sort.Strings(files)
+	return files
+}
+
+// readReadme 读取仓库根目录下的 README 文件
+func readReadme(repoPath string) string {
+	entries, err := os.ReadDir(repoPath)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(strings.ToLower(entry.Name()), "readme") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(repoPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+		return truncateText(string(content), maxPlanReadmeChars)
+	}
+	return "(无 README)"
+}
+
+// normalizePageID 将任意字符串规范化为页面ID
+func normalizePageID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	id = strings.NewReplacer(" ", "-", "_", "-", "/", "-", ".", "-").Replace(id)
+	id = pageIDPattern.ReplaceAllString(id, "")
+	return strings.Trim(id, "-")
+}
+
+// truncateText 按字节上限截断文本，不会截断多字节字符
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "\n... (已截断)"
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWikiPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		pages    int
+		wantErr  bool
+	}{
+		{name: "plain", response: `{"title": "Demo", "pages": [{"id": "overview", "title": "Overview"}]}`, pages: 1},
+		{name: "fenced", response: "```json\n{\"title\": \"Demo\", \"pages\": [{\"id\": \"a\", \"title\": \"A\"}, {\"id\": \"b\", \"title\": \"B\"}]}\n```", pages: 2},
+		{name: "surrounding prose", response: "Here is the outline:\n{\"pages\": [{\"id\": \"a\", \"title\": \"A\"}]}\nLet me know if you want {more} pages.", pages: 1},
+		{name: "no json", response: "I cannot plan this repository.", wantErr: true},
+		{name: "truncated", response: `{"title": "Demo", "pages": [`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := parseWikiPlan(tt.response)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", plan)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseWikiPlan: %v", err)
+			}
+			if len(plan.Pages) != tt.pages {
+				t.Errorf("expected %d pages, got %+v", tt.pages, plan.Pages)
+			}
+		})
+	}
+}
+
+func TestValidateWikiPlan(t *testing.T) {
+	files := []string{"README.md", "cmd/main.go", "internal/store/store.go", "internal/store/cache.go"}
+	plan, err := parseWikiPlan(`{
+		"sections": [{"id": "Core", "title": "Core", "pages": ["Store", "missing"]}],
+		"pages": [
+			{"id": "Store", "title": "Store", "file_paths": ["./internal/store/", "internal/nope.go"], "importance": "HIGH", "related_pages": ["cli", "ghost", "Store"]},
+			{"id": "store", "title": "Store again", "file_paths": ["README.md"], "importance": "urgent"},
+			{"id": "cli", "title": "CLI", "file_paths": ["cmd/main.go"], "related_pages": ["Store", "Store"]},
+			{"id": "", "title": "   "}
+		]}`)
+	if err != nil {
+		t.Fatalf("parseWikiPlan: %v", err)
+	}
+	if err := validateWikiPlan(plan, files, 10); err != nil {
+		t.Fatalf("validateWikiPlan: %v", err)
+	}
+
+	var ids []string
+	for _, page := range plan.Pages {
+		ids = append(ids, page.ID)
+	}
+	if got := strings.Join(ids, ","); got != "store,store-2,cli" {
+		t.Fatalf("duplicate or empty pages not normalized: %s", got)
+	}
+	store := plan.Pages[0]
+	if strings.Join(store.FilePaths, ",") != "internal/store/" || store.Importance != "high" {
+		t.Errorf("unexpected store page: %+v", store)
+	}
+	// 不存在的页面和指向自身的引用被去掉
+	if strings.Join(store.RelatedPages, ",") != "cli" {
+		t.Errorf("dangling related pages kept: %v", store.RelatedPages)
+	}
+	if plan.Pages[1].Importance != "medium" || strings.Join(plan.Pages[2].RelatedPages, ",") != "store" {
+		t.Errorf("unexpected pages: %+v", plan.Pages[1:])
+	}
+
+	// 未分配到章节的页面归入默认章节
+	if len(plan.Sections) != 2 || strings.Join(plan.Sections[0].Pages, ",") != "store" ||
+		strings.Join(plan.Sections[1].Pages, ",") != "store-2,cli" || plan.Pages[2].Section != "general" {
+		t.Errorf("unexpected sections: %+v", plan.Sections)
+	}
+
+	// 页面数量受上限约束
+	limited, _ := parseWikiPlan(`{"pages": [{"id": "a", "title": "A"}, {"id": "b", "title": "B"}, {"id": "c", "title": "C"}]}`)
+	if err := validateWikiPlan(limited, files, 2); err != nil || len(limited.Pages) != 2 {
+		t.Errorf("page limit not applied: %v %+v", err, limited.Pages)
+	}
+}
+
+func TestValidateWikiPlanEmpty(t *testing.T) {
+	// 没有有效页面时返回错误，由调用方退回到默认结构
+	for _, response := range []string{`{}`, `{"pages": []}`, `{"pages": [{"id": "x", "title": ""}]}`} {
+		plan, err := parseWikiPlan(response)
+		if err != nil {
+			t.Fatalf("parseWikiPlan(%s): %v", response, err)
+		}
+		if err := validateWikiPlan(plan, []string{"main.go"}, 6); err == nil {
+			t.Errorf("expected an error for %s", response)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+// internal/api/wikis.go
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/internal/rag"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errPageNotFound      = errors.New("页面不存在")
+	errPageHumanAuthored = errors.New("页面已被人工编辑，需要设置 overwrite 才能覆盖")
+)
+
+// handleListWikis 列出已保存的 Wiki 元数据，可通过 repo_url 查询参数按仓库过滤
+func (s *Server) handleListWikis(c *gin.Context) {
+	repoKey := ""
+	if repoURL := c.Query("repo_url"); repoURL != "" {
+		repoKey = data.RepoKey(repoURL)
+	}
+
+	summaries, err := s.wikis.List(repoKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取 Wiki 列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"wikis": summaries})
+}
+
+// handleGetWiki 返回仓库最新的 Wiki
+// 支持 id、ref、commit 查询参数以获取指定版本
+func (s *Server) handleGetWiki(c *gin.Context) {
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, wiki)
+}
+
+// handleGetWikiPage 返回已保存 Wiki 中的单个页面
+func (s *Server) handleGetWikiPage(c *gin.Context) {
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+
+	page := wiki.FindPage(c.Param("pageId"))
+	if page == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"wiki": wiki.Summary(),
+		"page": page,
+	})
+}
+
+// lookupWiki 根据路径中的仓库键和查询参数查找 Wiki，失败时直接写入错误响应
+func (s *Server) lookupWiki(c *gin.Context) (*models.Wiki, bool) {
+	repoKey := c.Param("repo")
+
+	var wiki *models.Wiki
+	var err error
+	if id := c.Query("id"); id != "" {
+		wiki, err = s.wikis.Get(repoKey, id)
+	} else {
+		wiki, err = s.wikis.Latest(repoKey, c.Query("ref"), c.Query("commit"), c.Query("language"))
+	}
+
+	if errors.Is(err, data.ErrWikiNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wiki 不存在"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("读取 Wiki 失败: %v", err)})
+		return nil, false
+	}
+	return wiki, true
+}
+
+// regeneratePageRequest 表示单个页面的重新生成请求
+type regeneratePageRequest struct {
+	Guidance    string `json:"guidance,omitempty"`  // 附加到提示词中的指导意见
+	Overwrite   bool   `json:"overwrite,omitempty"` // 是否覆盖人工编辑过的页面
+	GitHubToken string `json:"github_token,omitempty"`
+	GitLabToken string `json:"gitlab_token,omitempty"`
+}
+
+// handleRegeneratePage 以后台任务重新生成已保存 Wiki 中的单个页面
+func (s *Server) handleRegeneratePage(c *gin.Context) {
+	var req regeneratePageRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
+		return
+	}
+
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+	pageID := c.Param("pageId")
+	page := wiki.FindPage(pageID)
+	if page == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errPageNotFound.Error()})
+		return
+	}
+	if page.HumanAuthored && !req.Overwrite {
+		c.JSON(http.StatusConflict, gin.H{"error": errPageHumanAuthored.Error()})
+		return
+	}
+
+	// 提前检查 RAG 提供者，避免创建注定失败的任务
+	if _, err := s.manager.GetActiveProvider(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 RAG 提供者失败: %v", err)})
+		return
+	}
+
+	author := c.GetString("username")
+	job := s.jobs.Start("page_regenerate", func(ctx context.Context, job *Job) (interface{}, error) {
+		return s.runPageRegeneration(ctx, job, wiki, pageID, author, req)
+	})
+	respondJobAccepted(c, job)
+}
+
+// runPageRegeneration 在 Wiki 的源码提交上重新生成单个页面，并保存为新版本
+func (s *Server) runPageRegeneration(ctx context.Context, reporter progressReporter, wiki *models.Wiki, pageID, author string, req regeneratePageRequest) (interface{}, error) {
+	ctx = withLanguage(ctx, wiki.Language)
+	provider, err := s.manager.GetActiveProvider()
+	if err != nil {
+		return nil, fmt.Errorf("获取 RAG 提供者失败: %v", err)
+	}
+
+	accessToken := req.GitHubToken
+	if accessToken == "" {
+		accessToken = req.GitLabToken
+	}
+
+	repoManager := data.NewRepositoryManager(s.config)
+
+	// 克隆仓库并检出生成 Wiki 时的提交
+	reporter.SetPhase(JobPhaseCloning, wiki.RepoURL)
+	repoPath, err := repoManager.CloneRepository(wiki.RepoURL, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("克隆仓库失败: %v", err)
+	}
+	if repoPath, _, err = data.CheckoutWorktree(repoPath, wiki.Commit); err != nil {
+		return nil, fmt.Errorf("检出 %s 失败: %v", wiki.Commit, err)
+	}
+
+	reporter.SetPhase(JobPhaseAnalyzing, "")
+	analysis, err := repoManager.AnalyzeRepository(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("分析仓库失败: %v", err)
+	}
+
+	reporter.SetPhase(JobPhaseIndexing, "")
+	if err := s.prepareRetriever(ctx, provider, repoPath, accessToken); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("准备检索器失败: %v", err)
+	}
+
+	task, err := s.pageTaskFor(ctx, wiki, pageID, analysis, repoPath, wiki.Commit, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter.SetPhase(JobPhaseGenerating, pageID)
+	pages, statuses := s.runPageTasks(withPageGuidance(ctx, req.Guidance), reporter, repoPath, []pageTask{task})
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("重新生成页面失败: %s", statuses[0].Error)
+	}
+	page, updated, err := s.saveRegeneratedPage(wiki, pages[0], statuses[0], author, req)
+	if err != nil {
+		return nil, fmt.Errorf("保存页面失败: %v", err)
+	}
+
+	return gin.H{
+		"page": page,
+		"wiki": updated.Summary(),
+	}, nil
+}
+
+// saveRegeneratedPage 将重新生成的页面保存为 Wiki 的新版本
+// 页面不存在时返回 errPageNotFound，页面在生成期间被人工编辑且未设置 overwrite 时返回 errPageHumanAuthored
+func (s *Server) saveRegeneratedPage(wiki *models.Wiki, page models.WikiPage, status models.PageStatus, author string, req regeneratePageRequest) (models.WikiPage, *models.Wiki, error) {
+	updated, err := s.wikis.Update(wiki.RepoKey, wiki.ID, func(w *models.Wiki) error {
+		current := w.FindPage(page.ID)
+		if current == nil {
+			return errPageNotFound
+		}
+		// 任务排队期间页面可能已被人工编辑
+		if current.HumanAuthored && !req.Overwrite {
+			return errPageHumanAuthored
+		}
+		inheritPageMetadata(&page, *current)
+		page = w.RecordRevision(page, models.PageRevision{
+			Source:   models.RevisionSourceRegenerated,
+			Author:   author,
+			Guidance: req.Guidance,
+			Commit:   w.Commit,
+		})
+		setPageStatus(w, status)
+		return nil
+	})
+	if err != nil {
+		return models.WikiPage{}, nil, err
+	}
+	return page, updated, nil
+}
+
+// editPageRequest 表示人工编辑页面的请求
+type editPageRequest struct {
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content" binding:"required"`
+	Author  string `json:"author,omitempty"` // 启用 JWT 时使用令牌中的用户名
+	Comment string `json:"comment,omitempty"`
+}
+
+// handleEditPage 保存人工编辑的页面内容，作为新版本并标记为人工编写
+func (s *Server) handleEditPage(c *gin.Context) {
+	var req editPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的请求: %v", err)})
+		return
+	}
+
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+
+	author := c.GetString("username")
+	if author == "" {
+		author = req.Author
+	}
+
+	pageID := c.Param("pageId")
+	var page models.WikiPage
+	updated, err := s.wikis.Update(wiki.RepoKey, wiki.ID, func(w *models.Wiki) error {
+		current := w.FindPage(pageID)
+		if current == nil {
+			return errPageNotFound
+		}
+		page = *current
+		page.Content = req.Content
+		if req.Title != "" {
+			page.Title = req.Title
+		}
+		page = w.RecordRevision(page, models.PageRevision{
+			Source:        models.RevisionSourceEdited,
+			HumanAuthored: true,
+			Author:        author,
+			Comment:       req.Comment,
+			Commit:        w.Commit,
+		})
+		return nil
+	})
+	if errors.Is(err, errPageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存页面失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page": page,
+		"wiki": updated.Summary(),
+	})
+}
+
+// handleListPageRevisions 返回页面的版本历史
+func (s *Server) handleListPageRevisions(c *gin.Context) {
+	wiki, ok := s.lookupWiki(c)
+	if !ok {
+		return
+	}
+
+	pageID := c.Param("pageId")
+	page := wiki.FindPage(pageID)
+	if page == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errPageNotFound.Error()})
+		return
+	}
+
+	revisions := wiki.Revisions[pageID]
+	if len(revisions) == 0 {
+		// 尚未修改过的页面只有生成时的版本
+		revisions = []models.PageRevision{{
+			Revision:  1,
+			Title:     page.Title,
+			Content:   page.Content,
+			Source:    models.RevisionSourceGenerated,
+			Commit:    wiki.Commit,
+			CreatedAt: wiki.GeneratedAt,
+		}}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page_id":   pageID,
+		"revisions": revisions,
+	})
+}
+
+// pageTaskFor 根据已保存 Wiki 的大纲构建单个页面的生成任务，commit 是 repoPath 中检出的提交
+func (s *Server) pageTaskFor(ctx context.Context, wiki *models.Wiki, pageID string, analysis map[string]interface{}, repoPath, commit string, provider rag.RAGProvider) (pageTask, error) {
+	if wiki.Plan != nil {
+		for _, planned := range wiki.Plan.Pages {
+			if planned.ID != pageID {
+				continue
+			}
+			planned := planned
+			return pageTask{
+				id:    planned.ID,
+				title: planned.Title,
+				run: func(ctx context.Context) (models.WikiPage, error) {
+					return s.generatePlannedPage(ctx, planned, wiki.Plan, repoPath, wiki.RepoURL, commit, provider)
+				},
+			}, nil
+		}
+	}
+
+	for _, task := range s.defaultPageTasks(ctx, analysis, wiki.RepoURL, commit, provider) {
+		if task.id == pageID {
+			return task, nil
+		}
+	}
+
+	// 确定性页面直接重新构建
+	if pageID == data.OwnershipPageID {
+		return pageTask{
+			id:    pageID,
+			title: s.promptText(ctx, "activity_section", prompts.Data{}, "Activity"),
+			run: func(ctx context.Context) (models.WikiPage, error) {
+				history, err := data.CachedHistory(repoPath)
+				if err != nil {
+					return models.WikiPage{}, err
+				}
+				return data.BuildOwnershipPage(history, s.pageText(ctx)), nil
+			},
+		}, nil
+	}
+	if pageID == data.APIReferenceIndexID || strings.HasPrefix(pageID, "api-") {
+		return pageTask{
+			id:    pageID,
+			title: pageID,
+			run: func(ctx context.Context) (models.WikiPage, error) {
+				pages, err := data.BuildAPIReference(repoPath, wiki.RepoURL, commit, s.pageText(ctx))
+				if err != nil {
+					return models.WikiPage{}, err
+				}
+				for _, page := range pages {
+					if page.ID == pageID {
+						return page, nil
+					}
+				}
+				return models.WikiPage{}, errPageNotFound
+			},
+		}, nil
+	}
+
+	return pageTask{}, fmt.Errorf("无法确定页面 %s 的生成方式", pageID)
+}
+
+// setPageStatus 更新或追加页面的生成状态
+func setPageStatus(wiki *models.Wiki, status models.PageStatus) {
+	for i := range wiki.PageStatus {
+		if wiki.PageStatus[i].ID == status.ID {
+			wiki.PageStatus[i] = status
+			return
+		}
+	}
+	wiki.PageStatus = append(wiki.PageStatus, status)
+}
+
+// preserveHumanEdits 将上一版 Wiki 中的人工编辑和版本历史带入新生成的 Wiki
+// overwrite 为 false 时人工编辑的页面保持不变，否则新生成的内容作为新版本覆盖它们
+func preserveHumanEdits(wiki, previous *models.Wiki, overwrite bool) {
+	for id, history := range previous.Revisions {
+		if wiki.Revisions == nil {
+			wiki.Revisions = make(map[string][]models.PageRevision)
+		}
+		wiki.Revisions[id] = append([]models.PageRevision(nil), history...)
+	}
+
+	for i := range wiki.Pages {
+		page := wiki.Pages[i]
+		if old := previous.FindPage(page.ID); old != nil && old.HumanAuthored && !overwrite {
+			wiki.Pages[i] = *old
+			continue
+		}
+		if len(wiki.Revisions[page.ID]) > 0 {
+			wiki.RecordRevision(page, models.PageRevision{
+				Source: models.RevisionSourceGenerated,
+				Commit: wiki.Commit,
+			})
+		}
+	}
+
+	// 新大纲中不再包含的人工页面同样保留
+	if !overwrite {
+		for _, old := range previous.Pages {
+			if old.HumanAuthored && wiki.FindPage(old.ID) == nil {
+				wiki.Pages = append(wiki.Pages, old)
+			}
+		}
+	}
+}
+
+// pageGuidanceKey 是上下文中用户指导意见的键
+type pageGuidanceKey struct{}
+
+// withPageGuidance 返回携带用户指导意见的上下文，页面生成时会将其加入提示词
+func withPageGuidance(ctx context.Context, guidance string) context.Context {
+	return context.WithValue(ctx, pageGuidanceKey{}, guidance)
+}
+
+// pageGuidance 返回上下文中的用户指导意见
+func pageGuidance(ctx context.Context) string {
+	guidance, _ := ctx.Value(pageGuidanceKey{}).(string)
+	return strings.TrimSpace(guidance)
+}
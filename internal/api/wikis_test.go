@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/data"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// newWikiTestServer 返回使用临时 Wiki 存储的服务器，并保存一个包含 overview 页面的 Wiki
+func newWikiTestServer(t *testing.T) (*Server, *models.Wiki) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s := &Server{
+		router:  gin.New(),
+		wikis:   data.NewWikiStore(&config.Config{Wiki: config.WikiConfig{StorePath: t.TempDir()}}),
+		prompts: loadPrompts(""),
+	}
+	s.router.PUT("/wikis/:repo/pages/:pageId", s.handleEditPage)
+	s.router.POST("/wikis/:repo/pages/:pageId/regenerate", s.handleRegeneratePage)
+	s.router.GET("/wikis/:repo/pages/:pageId/revisions", s.handleListPageRevisions)
+
+	wiki := &models.Wiki{
+		RepoURL: "https://github.com/owner/repo",
+		Ref:     "main",
+		Commit:  "0123456789abcdef0123456789abcdef01234567",
+		Pages: []models.WikiPage{
+			{ID: "overview", Title: "Overview", Content: "generated", Section: "general"},
+			{ID: "setup", Title: "Setup", Content: "setup"},
+		},
+	}
+	if err := s.wikis.Save(wiki); err != nil {
+		t.Fatal(err)
+	}
+	return s, wiki
+}
+
+// serve 向服务器发送 JSON 请求并返回响应
+func serve(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSaveRegeneratedPage(t *testing.T) {
+	s, wiki := newWikiTestServer(t)
+	pagePath := "/wikis/" + wiki.RepoKey + "/pages/overview"
+
+	if w := serve(s, http.MethodPut, pagePath, `{"content": "edited by hand", "author": "alice"}`); w.Code != http.StatusOK {
+		t.Fatalf("edit failed: %d %s", w.Code, w.Body)
+	}
+
+	// 人工编辑的页面不会被覆盖，接口在创建任务前返回冲突
+	if w := serve(s, http.MethodPost, pagePath+"/regenerate", `{}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a human-authored page, got %d", w.Code)
+	}
+	status := models.PageStatus{ID: "overview", Status: "ok", Attempts: 1}
+	regenerated := models.WikiPage{ID: "overview", Title: "Overview", Content: "regenerated"}
+	if _, _, err := s.saveRegeneratedPage(wiki, regenerated, status, "bob", regeneratePageRequest{}); !errors.Is(err, errPageHumanAuthored) {
+		t.Fatalf("expected errPageHumanAuthored, got %v", err)
+	}
+	stored, _ := s.wikis.Get(wiki.RepoKey, wiki.ID)
+	if page := stored.FindPage("overview"); page.Content != "edited by hand" || !page.HumanAuthored {
+		t.Errorf("human edit was overwritten: %+v", page)
+	}
+
+	// 设置 overwrite 后保存为新版本，并继承章节等元数据
+	page, updated, err := s.saveRegeneratedPage(wiki, regenerated, status, "bob", regeneratePageRequest{Overwrite: true, Guidance: "shorter"})
+	if err != nil {
+		t.Fatalf("saveRegeneratedPage: %v", err)
+	}
+	if page.Revision != 3 || page.HumanAuthored || page.Section != "general" || page.Content != "regenerated" {
+		t.Errorf("unexpected regenerated page: %+v", page)
+	}
+	if len(updated.PageStatus) != 1 || updated.PageStatus[0].ID != "overview" {
+		t.Errorf("page status not recorded: %+v", updated.PageStatus)
+	}
+
+	// 版本历史按版本号升序排列
+	w := serve(s, http.MethodGet, pagePath+"/revisions", "")
+	var body struct {
+		Revisions []models.PageRevision `json:"revisions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	var sources []string
+	for i, rev := range body.Revisions {
+		if rev.Revision != i+1 {
+			t.Errorf("revision %d has number %d", i, rev.Revision)
+		}
+		sources = append(sources, rev.Source)
+	}
+	if got := strings.Join(sources, ","); got != "generated,edited,regenerated" {
+		t.Errorf("unexpected revision history: %s", got)
+	}
+	if last := body.Revisions[2]; last.Author != "bob" || last.Guidance != "shorter" || last.Content != "regenerated" {
+		t.Errorf("unexpected latest revision: %+v", last)
+	}
+}
+
+func TestRegenerateUnknownPage(t *testing.T) {
+	s, wiki := newWikiTestServer(t)
+
+	if w := serve(s, http.MethodPost, "/wikis/"+wiki.RepoKey+"/pages/missing/regenerate", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown page, got %d", w.Code)
+	}
+	// 页面在任务排队期间被删除
+	page := models.WikiPage{ID: "missing", Content: "regenerated"}
+	if _, _, err := s.saveRegeneratedPage(wiki, page, models.PageStatus{ID: "missing"}, "", regeneratePageRequest{}); !errors.Is(err, errPageNotFound) {
+		t.Errorf("expected errPageNotFound, got %v", err)
+	}
+}
+
+func TestPreserveHumanEdits(t *testing.T) {
+	previous := &models.Wiki{
+		Commit: "old",
+		Pages: []models.WikiPage{
+			{ID: "overview", Title: "Overview", Content: "generated"},
+			{ID: "notes", Title: "Notes", Content: "notes"},
+			{ID: "setup", Title: "Setup", Content: "old setup"},
+		},
+	}
+	previous.RecordRevision(models.WikiPage{ID: "overview", Title: "Overview", Content: "edited"}, models.PageRevision{
+		Source:        models.RevisionSourceEdited,
+		HumanAuthored: true,
+	})
+	previous.RecordRevision(models.WikiPage{ID: "notes", Title: "Notes", Content: "hand notes"}, models.PageRevision{
+		Source:        models.RevisionSourceEdited,
+		HumanAuthored: true,
+	})
+
+	newWiki := func() *models.Wiki {
+		return &models.Wiki{
+			Commit: "new",
+			Pages: []models.WikiPage{
+				{ID: "overview", Title: "Overview", Content: "fresh"},
+				{ID: "setup", Title: "Setup", Content: "new setup"},
+			},
+		}
+	}
+
+	// 不覆盖时保留人工编辑的页面，包括新大纲中已不存在的页面
+	kept := newWiki()
+	preserveHumanEdits(kept, previous, false)
+	if page := kept.FindPage("overview"); page.Content != "edited" || !page.HumanAuthored || page.Revision != 2 {
+		t.Errorf("human edit not preserved: %+v", page)
+	}
+	if page := kept.FindPage("notes"); page == nil || page.Content != "hand notes" {
+		t.Errorf("human page missing from the new outline was dropped: %+v", page)
+	}
+	if page := kept.FindPage("setup"); page.Content != "new setup" || len(kept.Revisions["setup"]) != 0 {
+		t.Errorf("untouched page should be replaced without history: %+v", page)
+	}
+	if len(kept.Revisions["overview"]) != 2 {
+		t.Errorf("history not carried over: %+v", kept.Revisions["overview"])
+	}
+
+	// 覆盖时新内容作为新版本追加到历史之后
+	overwritten := newWiki()
+	preserveHumanEdits(overwritten, previous, true)
+	page := overwritten.FindPage("overview")
+	if page.Content != "fresh" || page.HumanAuthored || page.Revision != 3 {
+		t.Errorf("overwrite did not record a new revision: %+v", page)
+	}
+	history := overwritten.Revisions["overview"]
+	if len(history) != 3 || history[2].Source != models.RevisionSourceGenerated || history[2].Commit != "new" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+	if overwritten.FindPage("notes") != nil {
+		t.Errorf("overwrite should not keep pages outside the new outline")
+	}
+	// 上一版的历史不受影响
+	if len(previous.Revisions["overview"]) != 2 {
+		t.Errorf("previous history was modified: %+v", previous.Revisions["overview"])
+	}
+}
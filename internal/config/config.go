@@ -63,6 +63,64 @@ type AuthConfig struct {
 	EnableJWT bool `yaml:"enable_jwt"`
 }
 
+// WikiConfig holds wiki generation configuration
+type WikiConfig struct {
+	Concurrency        int    `yaml:"concurrency"`           // Max pages generated in parallel, default: 4
+	PageTimeoutSeconds int    `yaml:"page_timeout_seconds"`  // Timeout for a single page attempt, default: 300
+	MaxRetries         int    `yaml:"max_retries"`           // Retries for transient provider failures, default: 2
+	StorePath          string `yaml:"store_path,omitempty"`  // Directory for persisted wikis, default: ~/.deepwiki/wikis
+	Grounding          string `yaml:"grounding,omitempty"`   // Handling of unverifiable references: "annotate" (default), "strip" or "off"
+	DiagramRepairs     int    `yaml:"diagram_repairs"`       // Attempts to repair an invalid Mermaid diagram, default: 2
+	Language           string `yaml:"language,omitempty"`    // Default output language for wikis and chat: en, zh, ja, ko, es, de or fr, default: zh
+	PromptsDir         string `yaml:"prompts_dir,omitempty"` // Directory with <language>/<name>.tmpl files overriding the built-in prompts
+}
+
+// ChatConfig holds how chat conversations are sent to the model
+type ChatConfig struct {
+	HistoryTokens  int    `yaml:"history_tokens"`       // Token budget for earlier turns sent with each question, default: 4000; negative sends no history
+	Overflow       string `yaml:"overflow,omitempty"`   // Turns beyond the budget: "summarize" (default) or "truncate"
+	RewriteQueries bool   `yaml:"rewrite_queries"`      // Rewrite follow-up questions into standalone retrieval queries
+	MemoryTurns    int    `yaml:"memory_turns"`         // Recent turns of a conversation used to re-rank retrieval, default: 50
+	StorePath      string `yaml:"store_path,omitempty"` // Directory for persisted chat sessions, default: ~/.deepwiki/chats
+	FileChars      int    `yaml:"file_chars"`           // Characters of a pinned file sent in full before it is cut into relevant chunks, default: 24000
+}
+
+// ConfluenceConfig holds the Confluence space that wiki exports can be pushed to
+type ConfluenceConfig struct {
+	BaseURL  string `yaml:"base_url,omitempty"`  // REST base URL, e.g. https://example.atlassian.net/wiki
+	SpaceKey string `yaml:"space_key,omitempty"` // Target space key
+	Username string `yaml:"username,omitempty"`  // Basic auth user; when empty APIToken is sent as a bearer token
+	APIToken string `yaml:"api_token,omitempty"` // API token or personal access token
+	ParentID string `yaml:"parent_id,omitempty"` // Optional page the wiki root page is created under
+	Mermaid  string `yaml:"mermaid,omitempty"`   // Mermaid diagrams as "code" macros (default) or "attachment" files
+}
+
+// GitExportConfig holds defaults for committing wikis into the repository itself
+type GitExportConfig struct {
+	Dir          string `yaml:"dir,omitempty"`           // Folder on the code branch the pages are written to (default docs/wiki)
+	OrphanBranch string `yaml:"orphan_branch,omitempty"` // Branch used for orphan-branch exports (default deepwiki)
+	AuthorName   string `yaml:"author_name,omitempty"`   // Commit author, defaults to DeepWiki-Go
+	AuthorEmail  string `yaml:"author_email,omitempty"`
+	// Tokens used to push exports, keyed by repository URL; a request's own github_token or gitlab_token takes precedence
+	PushTokens map[string]string `yaml:"push_tokens,omitempty"`
+}
+
+// MarkdownExportConfig holds the header and footer of Markdown exports.
+// Header and Footer may use the {title}, {description}, {repo}, {repo_url}, {commit} and {language} placeholders.
+type MarkdownExportConfig struct {
+	Header   string `yaml:"header,omitempty"`    // Replaces the default title and description at the top of the document
+	Footer   string `yaml:"footer,omitempty"`    // Appended to the document (and to every file of per-page exports)
+	TOCTitle string `yaml:"toc_title,omitempty"` // Heading of the table of contents, defaults by wiki language
+}
+
+// ExportConfig holds wiki export configuration
+type ExportConfig struct {
+	MermaidScript string               `yaml:"mermaid_script,omitempty"` // Local mermaid.min.js bundled into HTML exports for offline diagram rendering
+	Markdown      MarkdownExportConfig `yaml:"markdown"`
+	Confluence    ConfluenceConfig     `yaml:"confluence"`
+	Git           GitExportConfig      `yaml:"git"`
+}
+
 // Config holds the overall application configuration
 type Config struct {
 	Server       ServerConfig       `yaml:"server"`
@@ -74,6 +132,9 @@ type Config struct {
 	FileFilters  FileFiltersConfig  `yaml:"file_filters"`
 	OpenAIAPIKey string             `yaml:"openai_api_key"`
 	Auth         AuthConfig         `yaml:"auth"`
+	Wiki         WikiConfig         `yaml:"wiki"`
+	Chat         ChatConfig         `yaml:"chat"`
+	Export       ExportConfig       `yaml:"export"`
 }
 
 // LoadConfig loads configuration from a YAML file
@@ -111,6 +172,9 @@ func LoadConfig(configPath string) (*Config, error) {
 	if openAIAPIKeyEnv := os.Getenv("OPENAI_API_KEY"); openAIAPIKeyEnv != "" {
 		config.OpenAIAPIKey = openAIAPIKeyEnv
 	}
+	if confluenceTokenEnv := os.Getenv("CONFLUENCE_API_TOKEN"); confluenceTokenEnv != "" {
+		config.Export.Confluence.APIToken = confluenceTokenEnv
+	}
 	// Add more environment variable overrides as needed
 
 	log.Println("Configuration loaded successfully")
@@ -0,0 +1,438 @@
+// internal/data/apiref.go
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+	"github.com/deepwiki-go/pkg/utils"
+)
+
+// APIReferenceIndexID 是 API 参考索引页面的ID
+const APIReferenceIndexID = "api-reference"
+
+// PageText 返回确定性生成的页面中固定文字的本地化文本，name 为提示词库中的模板名
+type PageText func(name string, d prompts.Data) string
+
+// apiPackage 表示从源码中解析出的一个 Go 包
+type apiPackage struct {
+	relDir     string // 相对于仓库根目录的目录，根目录为 "."
+	importPath string
+	doc        *doc.Package
+	fset       *token.FileSet
+	files      []string // 相对路径，已排序
+	imports    []string // 仓库内被导入的包目录
+}
+
+// BuildAPIReference 基于 Go 文档注释为仓库中的每个 Go 包生成 API 参考页面
+// 返回的第一个页面为索引页，其余页面按包路径排序；仓库中没有 Go 包时返回空切片
+// 标题和固定文字通过 text 按输出语言渲染
+func BuildAPIReference(repoPath, repoURL, ref string, text PageText) ([]models.WikiPage, error) {
+	modulePath := readModulePath(repoPath)
+
+	var packages []*apiPackage
+	err := filepath.Walk(repoPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if p != repoPath && skipAPIDir(info.Name()) {
+			return filepath.SkipDir
+		}
+
+		pkg, err := parseAPIPackage(repoPath, p, modulePath)
+		if err != nil {
+			// 单个包解析失败不影响其他包
+			log.Printf("解析 Go 包 %s 失败，跳过: %v", p, err)
+			return nil
+		}
+		if pkg != nil {
+			packages = append(packages, pkg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历仓库失败: %v", err)
+	}
+
+	if len(packages) == 0 {
+		return []models.WikiPage{}, nil
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].relDir < packages[j].relDir
+	})
+
+	pageIDs := apiPageIDs(packages)
+
+	pages := make([]models.WikiPage, 0, len(packages)+1)
+	pages = append(pages, buildAPIIndexPage(packages, pageIDs, text))
+	for _, pkg := range packages {
+		pages = append(pages, buildAPIPackagePage(pkg, pageIDs, repoURL, ref, text))
+	}
+
+	return pages, nil
+}
+
+// parseAPIPackage 解析目录中的非测试 Go 文件，目录中没有 Go 包时返回 nil
+func parseAPIPackage(repoPath, dir, modulePath string) (*apiPackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按包名对文件分组，一个目录中可能因构建标签存在多个包
+	fset := token.NewFileSet()
+	byPackage := make(map[string]map[string]*ast.File)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		fullPath := filepath.Join(dir, name)
+		f, err := parser.ParseFile(fset, fullPath, nil, parser.ParseComments)
+		if err != nil {
+			// 跳过无法解析的文件，保留同一个包中其他文件的文档
+			log.Printf("解析 Go 文件 %s 失败，跳过: %v", fullPath, err)
+			continue
+		}
+		if byPackage[f.Name.Name] == nil {
+			byPackage[f.Name.Name] = make(map[string]*ast.File)
+		}
+		byPackage[f.Name.Name][fullPath] = f
+	}
+	if len(byPackage) == 0 {
+		return nil, nil
+	}
+
+	// 选择文件最多的那个包
+	var pkgName string
+	for name, files := range byPackage {
+		if pkgName == "" || len(files) > len(byPackage[pkgName]) ||
+			(len(files) == len(byPackage[pkgName]) && name < pkgName) {
+			pkgName = name
+		}
+	}
+	pkgFiles := byPackage[pkgName]
+
+	relDir, err := filepath.Rel(repoPath, dir)
+	if err != nil {
+		return nil, err
+	}
+	relDir = filepath.ToSlash(relDir)
+
+	importPath := modulePath
+	if relDir != "." {
+		importPath = path.Join(modulePath, relDir)
+	}
+
+	fileNames := make([]string, 0, len(pkgFiles))
+	for name := range pkgFiles {
+		fileNames = append(fileNames, name)
+	}
+	sort.Strings(fileNames)
+	files := make([]*ast.File, 0, len(fileNames))
+	for _, name := range fileNames {
+		files = append(files, pkgFiles[name])
+	}
+
+	docPkg, err := doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &apiPackage{
+		relDir:     relDir,
+		importPath: importPath,
+		doc:        docPkg,
+		fset:       fset,
+	}
+	for _, name := range fileNames {
+		rel, err := filepath.Rel(repoPath, name)
+		if err != nil {
+			continue
+		}
+		pkg.files = append(pkg.files, filepath.ToSlash(rel))
+	}
+
+	// 记录仓库内部的导入关系
+	if modulePath != "" {
+		for _, imp := range docPkg.Imports {
+			if imp == modulePath {
+				pkg.imports = append(pkg.imports, ".")
+			} else if strings.HasPrefix(imp, modulePath+"/") {
+				pkg.imports = append(pkg.imports, strings.TrimPrefix(imp, modulePath+"/"))
+			}
+		}
+	}
+
+	return pkg, nil
+}
+
+// buildAPIIndexPage 生成列出所有包的索引页面
+func buildAPIIndexPage(packages []*apiPackage, pageIDs map[string]string, text PageText) models.WikiPage {
+	title := text("api_section", prompts.Data{})
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("# %s\n\n", title))
+	content.WriteString(text("api_intro", prompts.Data{}) + "\n\n")
+	content.WriteString(text("api_index_columns", prompts.Data{}) + "\n")
+	content.WriteString("| --- | --- | --- |\n")
+
+	related := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		id := pageIDs[pkg.relDir]
+		related = append(related, id)
+		content.WriteString(fmt.Sprintf("| [%s](#%s) | `%s` | %s |\n",
+			pkg.doc.Name, id, pkg.importPath, escapeTableCell(doc.Synopsis(pkg.doc.Doc))))
+	}
+
+	return models.WikiPage{
+		ID:           APIReferenceIndexID,
+		Title:        title,
+		Content:      content.String(),
+		FilePaths:    []string{},
+		Importance:   "high",
+		RelatedPages: related,
+	}
+}
+
+// buildAPIPackagePage 生成单个包的 API 参考页面
+func buildAPIPackagePage(pkg *apiPackage, pageIDs map[string]string, repoURL, ref string, text PageText) models.WikiPage {
+	d := pkg.doc
+	var content strings.Builder
+
+	content.WriteString(fmt.Sprintf("# package %s\n\n", d.Name))
+	if pkg.importPath != "" {
+		content.WriteString(fmt.Sprintf("```go\nimport %q\n```\n\n", pkg.importPath))
+	}
+	if d.Doc != "" {
+		content.WriteString(strings.TrimSpace(d.Doc))
+		content.WriteString("\n\n")
+	}
+
+	exportedCount := 0
+
+	if len(d.Consts) > 0 {
+		content.WriteString(fmt.Sprintf("## %s\n\n", text("api_constants", prompts.Data{})))
+		for _, v := range d.Consts {
+			writeAPIDecl(&content, pkg, v.Decl, v.Doc, repoURL, ref)
+			exportedCount += len(v.Names)
+		}
+	}
+
+	if len(d.Vars) > 0 {
+		content.WriteString(fmt.Sprintf("## %s\n\n", text("api_variables", prompts.Data{})))
+		for _, v := range d.Vars {
+			writeAPIDecl(&content, pkg, v.Decl, v.Doc, repoURL, ref)
+			exportedCount += len(v.Names)
+		}
+	}
+
+	if len(d.Funcs) > 0 {
+		content.WriteString(fmt.Sprintf("## %s\n\n", text("api_functions", prompts.Data{})))
+		for _, f := range d.Funcs {
+			content.WriteString(fmt.Sprintf("### func %s\n\n", f.Name))
+			writeAPIDecl(&content, pkg, f.Decl, f.Doc, repoURL, ref)
+			exportedCount++
+		}
+	}
+
+	if len(d.Types) > 0 {
+		content.WriteString(fmt.Sprintf("## %s\n\n", text("api_types", prompts.Data{})))
+		for _, t := range d.Types {
+			content.WriteString(fmt.Sprintf("### type %s\n\n", t.Name))
+			writeAPIDecl(&content, pkg, t.Decl, t.Doc, repoURL, ref)
+			exportedCount++
+
+			for _, v := range t.Consts {
+				writeAPIDecl(&content, pkg, v.Decl, v.Doc, repoURL, ref)
+			}
+			for _, v := range t.Vars {
+				writeAPIDecl(&content, pkg, v.Decl, v.Doc, repoURL, ref)
+			}
+			for _, f := range t.Funcs {
+				content.WriteString(fmt.Sprintf("#### func %s\n\n", f.Name))
+				writeAPIDecl(&content, pkg, f.Decl, f.Doc, repoURL, ref)
+				exportedCount++
+			}
+			for _, m := range t.Methods {
+				content.WriteString(fmt.Sprintf("#### func (%s) %s\n\n", m.Recv, m.Name))
+				writeAPIDecl(&content, pkg, m.Decl, m.Doc, repoURL, ref)
+				exportedCount++
+			}
+		}
+	}
+
+	if exportedCount == 0 {
+		content.WriteString(text("api_no_exports", prompts.Data{}) + "\n")
+	}
+
+	// 关联页面：索引页以及仓库内被导入的包
+	related := []string{APIReferenceIndexID}
+	seen := map[string]bool{APIReferenceIndexID: true}
+	imports := append([]string(nil), pkg.imports...)
+	sort.Strings(imports)
+	for _, imp := range imports {
+		if id, ok := pageIDs[imp]; ok && !seen[id] && imp != pkg.relDir {
+			related = append(related, id)
+			seen[id] = true
+		}
+	}
+
+	name := pkg.relDir
+	if name == "." {
+		name = d.Name
+	}
+	title := text("api_package_title", prompts.Data{Module: name})
+
+	return models.WikiPage{
+		ID:           pageIDs[pkg.relDir],
+		Title:        title,
+		Content:      content.String(),
+		FilePaths:    pkg.files,
+		Importance:   apiPackageImportance(pkg, exportedCount),
+		RelatedPages: related,
+	}
+}
+
+// writeAPIDecl 写入一个声明的签名、源码链接和文档注释
+func writeAPIDecl(w *strings.Builder, pkg *apiPackage, decl ast.Decl, docText, repoURL, ref string) {
+	if decl == nil {
+		return
+	}
+
+	w.WriteString("```go\n")
+	w.WriteString(formatDecl(pkg.fset, decl))
+	w.WriteString("\n```\n\n")
+
+	start := pkg.fset.Position(decl.Pos())
+	end := pkg.fset.Position(decl.End())
+	relPath := filepath.Base(start.Filename)
+	if pkg.relDir != "." {
+		relPath = pkg.relDir + "/" + relPath
+	}
+	w.WriteString(fmt.Sprintf("[%s#L%d](%s)\n\n", relPath, start.Line,
+		utils.BlobURL(repoURL, ref, relPath, start.Line, end.Line)))
+
+	if docText = strings.TrimSpace(docText); docText != "" {
+		w.WriteString(docText)
+		w.WriteString("\n\n")
+	}
+}
+
+// formatDecl 打印声明的签名，函数体会被省略
+func formatDecl(fset *token.FileSet, decl ast.Decl) string {
+	if fn, ok := decl.(*ast.FuncDecl); ok {
+		sig := *fn
+		sig.Body = nil
+		sig.Doc = nil
+		decl = &sig
+	} else if gen, ok := decl.(*ast.GenDecl); ok {
+		stripped := *gen
+		stripped.Doc = nil
+		decl = &stripped
+	}
+
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, fset, decl); err != nil {
+		return fmt.Sprintf("// 无法打印声明: %v", err)
+	}
+	return buf.String()
+}
+
+// apiPackageImportance 根据包的位置和导出规模估算重要性
+func apiPackageImportance(pkg *apiPackage, exportedCount int) string {
+	switch {
+	case pkg.doc.Name == "main" || exportedCount == 0:
+		return "low"
+	case pkg.relDir == "." || exportedCount >= 20:
+		return "high"
+	default:
+		return "medium"
+	}
+}
+
+// apiPageID 根据包目录生成页面ID
+func apiPageID(relDir string) string {
+	if relDir == "." || relDir == "" {
+		return "api-root"
+	}
+	id := strings.ToLower(relDir)
+	id = strings.NewReplacer("/", "-", "_", "-", ".", "-").Replace(id)
+	return "api-" + id
+}
+
+// apiPageIDs 为每个包分配唯一的页面ID
+// 不同的目录可能得到相同的ID（例如 pkg/foo_bar 和 pkg/foo/bar），按包路径顺序为后出现的包添加数字后缀
+func apiPageIDs(packages []*apiPackage) map[string]string {
+	ids := make(map[string]string, len(packages))
+	used := map[string]bool{APIReferenceIndexID: true}
+	for _, pkg := range packages {
+		base := apiPageID(pkg.relDir)
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[id] = true
+		ids[pkg.relDir] = id
+	}
+	return ids
+}
+
+// readModulePath 从 go.mod 中读取模块路径，不存在时返回空字符串
+func readModulePath(repoPath string) string {
+	f, err := os.Open(filepath.Join(repoPath, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			modulePath := strings.TrimSpace(strings.TrimPrefix(line, "module "))
+			if unquoted, err := strconv.Unquote(modulePath); err == nil {
+				modulePath = unquoted
+			}
+			return modulePath
+		}
+	}
+	return ""
+}
+
+// skipAPIDir 检查生成 API 参考时是否应跳过该目录
+func skipAPIDir(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+	switch name {
+	case "vendor", "testdata", "node_modules", "third_party":
+		return true
+	}
+	return false
+}
+
+// escapeTableCell 转义 Markdown 表格单元格中的特殊字符
+func escapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
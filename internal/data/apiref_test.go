@@ -0,0 +1,143 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/prompts"
+)
+
+func writeTestFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	full := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testPageText 返回以内置提示词模板渲染指定语言固定文字的函数
+func testPageText(t *testing.T, lang string) PageText {
+	t.Helper()
+	lib, err := prompts.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(name string, d prompts.Data) string {
+		text, err := lib.Render(lang, name, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return text
+	}
+}
+
+func TestBuildAPIReference(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, "go.mod", "module example.com/demo\n\ngo 1.21\n")
+	writeTestFile(t, root, "store/store.go", `// Package store 保存数据
+package store
+
+// Store 表示一个键值存储
+type Store struct{ items map[string]string }
+
+// New 创建存储
+func New() *Store { return &Store{} }
+
+// Get 读取一个值
+func (s *Store) Get(key string) string { return s.items[key] }
+
+func helper() {}
+`)
+	writeTestFile(t, root, "store/store_test.go", "package store\n\nfunc TestHidden() {}\n")
+	writeTestFile(t, root, "api/api.go", `package api
+
+import "example.com/demo/store"
+
+// Serve 启动服务
+func Serve(s *store.Store) error { return nil }
+`)
+
+	pages, err := BuildAPIReference(root, "https://github.com/acme/demo", "abc123", testPageText(t, "en"))
+	if err != nil {
+		t.Fatalf("BuildAPIReference returned error: %v", err)
+	}
+	if len(pages) != 3 {
+		t.Fatalf("expected index + 2 package pages, got %d", len(pages))
+	}
+	if pages[0].ID != APIReferenceIndexID {
+		t.Errorf("first page should be the index, got %q", pages[0].ID)
+	}
+
+	apiPage, storePage := pages[1], pages[2]
+	if apiPage.ID != "api-api" || storePage.ID != "api-store" {
+		t.Fatalf("unexpected page ids: %q, %q", apiPage.ID, storePage.ID)
+	}
+
+	// 标题和固定文字使用输出语言
+	if pages[0].Title != "API Reference" || storePage.Title != "API Reference: store" || !strings.Contains(storePage.Content, "## Types") {
+		t.Errorf("unexpected titles: %q, %q", pages[0].Title, storePage.Title)
+	}
+	if !strings.HasPrefix(pages[0].Content, "# API Reference\n") || !strings.Contains(pages[0].Content, "| Package | Import path | Synopsis |") {
+		t.Errorf("index not rendered in English:\n%s", pages[0].Content)
+	}
+	for _, want := range []string{"func New() *Store", "func (s *Store) Get(key string) string", "Get 读取一个值",
+		"https://github.com/acme/demo/blob/abc123/store/store.go#L"} {
+		if !strings.Contains(storePage.Content, want) {
+			t.Errorf("store page missing %q", want)
+		}
+	}
+	if strings.Contains(storePage.Content, "helper") || strings.Contains(storePage.Content, "TestHidden") {
+		t.Errorf("store page should not list unexported or test identifiers")
+	}
+	if len(storePage.FilePaths) != 1 || storePage.FilePaths[0] != "store/store.go" {
+		t.Errorf("unexpected file paths: %v", storePage.FilePaths)
+	}
+
+	related := strings.Join(apiPage.RelatedPages, ",")
+	if related != APIReferenceIndexID+",api-store" {
+		t.Errorf("unexpected related pages for api package: %s", related)
+	}
+}
+
+func TestBuildAPIReferenceDistinctPages(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, "go.mod", "module example.com/demo\n")
+	writeTestFile(t, root, "pkg/foo_bar/a.go", "package foo_bar\n\n// A 导出\nfunc A() {}\n")
+	writeTestFile(t, root, "pkg/foo/bar/b.go", "package bar\n\n// B 导出\nfunc B() {}\n")
+	writeTestFile(t, root, "reference/r.go", "package reference\n\n// R 导出\nfunc R() {}\n")
+	// 同一个包中有一个文件无法解析时，保留其他文件
+	writeTestFile(t, root, "broken/ok.go", "package broken\n\n// OK 导出\nfunc OK() {}\n")
+	writeTestFile(t, root, "broken/bad.go", "package broken\n\nfunc {\n")
+
+	pages, err := BuildAPIReference(root, "", "", testPageText(t, "zh"))
+	if err != nil {
+		t.Fatalf("BuildAPIReference returned error: %v", err)
+	}
+	ids := make(map[string]string)
+	for _, page := range pages {
+		if other, ok := ids[page.ID]; ok {
+			t.Errorf("page id %q used by both %q and %q", page.ID, other, page.Title)
+		}
+		ids[page.ID] = page.Title
+	}
+	if len(pages) != 5 {
+		t.Fatalf("expected index + 4 package pages, got %d: %v", len(pages), ids)
+	}
+	var broken string
+	for _, page := range pages {
+		if strings.HasSuffix(page.Title, "broken") {
+			broken = page.Content
+		}
+	}
+	if !strings.Contains(broken, "func OK()") {
+		t.Errorf("package with one unparsable file should keep the others, got %q", broken)
+	}
+	if index := pages[0].Content; strings.Count(index, "(#api-pkg-foo-bar") != 2 {
+		t.Errorf("index should link both colliding packages: %s", index)
+	}
+}
@@ -0,0 +1,213 @@
+// internal/data/changelog.go
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+)
+
+// conventionalCommitPattern 匹配约定式提交标题，例如 "feat(api)!: 新增接口"
+var conventionalCommitPattern = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// conventionalTypes 是可识别的约定式提交类型
+var conventionalTypes = map[string]bool{
+	"feat": true, "fix": true, "perf": true, "refactor": true, "docs": true,
+	"test": true, "build": true, "ci": true, "chore": true, "style": true, "revert": true,
+}
+
+// ResolveCommit 将分支、标签或 SHA 解析为完整的提交 SHA
+// 本地不存在的分支会尝试使用 origin/ 前缀解析
+func ResolveCommit(repoPath, ref string) (string, error) {
+	if ref == "" {
+		return "", fmt.Errorf("引用不能为空")
+	}
+	for _, candidate := range []string{ref, "origin/" + ref} {
+		output, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", candidate+"^{commit}")
+		if err == nil {
+			return strings.TrimSpace(string(output)), nil
+		}
+	}
+	return "", fmt.Errorf("无法解析引用 %s", ref)
+}
+
+// FetchRefs 从远程仓库获取最新的分支和标签
+func FetchRefs(repoPath string) error {
+	_, err := runGit(repoPath, "fetch", "--tags", "--prune", "origin")
+	return err
+}
+
+// CollectChangelog 收集两个引用之间的提交记录和差异统计
+func CollectChangelog(repoPath, fromRef, toRef string) (*models.Changelog, error) {
+	fromCommit, err := ResolveCommit(repoPath, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := ResolveCommit(repoPath, toRef)
+	if err != nil {
+		return nil, err
+	}
+
+	changelog := &models.Changelog{
+		FromRef:    fromRef,
+		ToRef:      toRef,
+		FromCommit: fromCommit,
+		ToCommit:   toCommit,
+		Commits:    []models.ChangelogCommit{},
+		ByType:     make(map[string][]models.ChangelogCommit),
+		ByModule:   make(map[string][]string),
+	}
+
+	output, err := runGit(repoPath, "log", "--no-merges", "--name-only",
+		"--format="+logRecordSep+"%H"+logFieldSep+"%an"+logFieldSep+"%aI"+logFieldSep+"%s",
+		fromCommit+".."+toCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range strings.Split(string(output), logRecordSep) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		lines := strings.Split(record, "\n")
+		fields := strings.SplitN(lines[0], logFieldSep, 4)
+		if len(fields) < 4 {
+			continue
+		}
+
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		commit := models.ChangelogCommit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Subject: fields[3],
+		}
+		commit.ShortSHA = shortSHA(commit.SHA)
+		commit.Type, commit.Scope, commit.Breaking = parseConventionalSubject(commit.Subject)
+
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				commit.Files = append(commit.Files, line)
+			}
+		}
+		commit.Module = primaryModule(commit.Files)
+
+		changelog.Commits = append(changelog.Commits, commit)
+		changelog.ByType[commit.Type] = append(changelog.ByType[commit.Type], commit)
+		changelog.ByModule[commit.Module] = append(changelog.ByModule[commit.Module], commit.SHA)
+	}
+
+	// 差异统计
+	numstat, err := runGit(repoPath, "diff", "--numstat", fromCommit, toCommit)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(numstat), "\n") {
+		added, deleted, _, ok := parseNumstat(line)
+		if !ok {
+			continue
+		}
+		changelog.FilesChanged++
+		changelog.Insertions += added
+		changelog.Deletions += deleted
+	}
+
+	return changelog, nil
+}
+
+// parseConventionalSubject 从提交标题中解析约定式提交的类型、范围和破坏性变更标记
+func parseConventionalSubject(subject string) (commitType, scope string, breaking bool) {
+	match := conventionalCommitPattern.FindStringSubmatch(subject)
+	if match == nil {
+		return "other", "", false
+	}
+	commitType = strings.ToLower(match[1])
+	if !conventionalTypes[commitType] {
+		return "other", "", false
+	}
+	return commitType, match[2], match[3] == "!"
+}
+
+// primaryModule 返回提交中涉及文件最多的顶级目录，根目录文件归入 "."
+func primaryModule(files []string) string {
+	if len(files) == 0 {
+		return "."
+	}
+	counts := make(map[string]int)
+	for _, f := range files {
+		counts[parentDirs(f, 1)[0]]++
+	}
+	modules := make([]string, 0, len(counts))
+	for m := range counts {
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if counts[modules[i]] != counts[modules[j]] {
+			return counts[modules[i]] > counts[modules[j]]
+		}
+		return modules[i] < modules[j]
+	})
+	return modules[0]
+}
+
+// shortSHA 返回提交 SHA 的前 7 位
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// ChangelogTypeOrder 定义发布说明中提交类型的展示顺序
+var ChangelogTypeOrder = []string{"feat", "fix", "perf", "refactor", "docs", "test", "build", "ci", "style", "chore", "revert", "other"}
+
+// FormatChangelogSummary 将变更汇总格式化为按类型和模块分组的纯文本，用作生成发布说明的上下文
+// 统计项的名称通过 text 按输出语言渲染
+func FormatChangelogSummary(changelog *models.Changelog, text PageText) string {
+	label := func(name string) string { return text(name, prompts.Data{}) }
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("%s: %s (%s) .. %s (%s)\n", label("changelog_range"), changelog.FromRef, shortSHA(changelog.FromCommit),
+		changelog.ToRef, shortSHA(changelog.ToCommit)))
+	summary.WriteString(fmt.Sprintf("%s: %d, %s: %d, %s: %d, %s: %d\n\n",
+		label("changelog_commits"), len(changelog.Commits), label("changelog_files"), changelog.FilesChanged,
+		label("changelog_insertions"), changelog.Insertions, label("changelog_deletions"), changelog.Deletions))
+
+	for _, commitType := range ChangelogTypeOrder {
+		commits := changelog.ByType[commitType]
+		if len(commits) == 0 {
+			continue
+		}
+		summary.WriteString(fmt.Sprintf("## %s\n", commitType))
+
+		// 在类型内按模块分组
+		byModule := make(map[string][]models.ChangelogCommit)
+		var modules []string
+		for _, c := range commits {
+			if _, ok := byModule[c.Module]; !ok {
+				modules = append(modules, c.Module)
+			}
+			byModule[c.Module] = append(byModule[c.Module], c)
+		}
+		sort.Strings(modules)
+
+		for _, module := range modules {
+			summary.WriteString(fmt.Sprintf("### %s\n", module))
+			for _, c := range byModule[module] {
+				breaking := ""
+				if c.Breaking {
+					breaking = " [BREAKING]"
+				}
+				summary.WriteString(fmt.Sprintf("- %s %s%s (%s)\n", c.ShortSHA, c.Subject, breaking, c.Author))
+			}
+		}
+		summary.WriteString("\n")
+	}
+
+	return summary.String()
+}
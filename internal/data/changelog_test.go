@@ -0,0 +1,73 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollectChangelog(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.commit("Alice", "", "initial", map[string]string{"main.go": "package main\n"})
+	repo.git(repo.path, "tag", "v1.0.0")
+	repo.commit("Alice", "", "feat(api)!: add export endpoint", map[string]string{
+		"api/export.go": "package api\n\nfunc Export() {}\n",
+		"api/routes.go": "package api\n",
+		"README.md":     "# Demo\n",
+	})
+	repo.commit("Bob", "", "fix: handle empty input", map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	repo.commit("Bob", "", "Update docs", map[string]string{"docs/guide.md": "# Guide\n"})
+	repo.git(repo.path, "tag", "v1.1.0")
+
+	changelog, err := CollectChangelog(repo.path, "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changelog.Commits) != 3 || changelog.FilesChanged != 5 || changelog.Insertions != 8 || changelog.Deletions != 0 {
+		t.Errorf("unexpected stats: %d commits, %d files, +%d -%d",
+			len(changelog.Commits), changelog.FilesChanged, changelog.Insertions, changelog.Deletions)
+	}
+
+	feat := changelog.ByType["feat"]
+	if len(feat) != 1 || feat[0].Scope != "api" || !feat[0].Breaking || feat[0].Module != "api" || len(feat[0].Files) != 3 {
+		t.Errorf("unexpected feat commit: %+v", feat)
+	}
+	if fix := changelog.ByType["fix"]; len(fix) != 1 || fix[0].Module != "." || fix[0].Author != "Bob" {
+		t.Errorf("unexpected fix commit: %+v", fix)
+	}
+	if other := changelog.ByType["other"]; len(other) != 1 || other[0].Module != "docs" {
+		t.Errorf("unconventional subjects should be grouped as other: %+v", other)
+	}
+	if len(changelog.ByModule["api"]) != 1 || len(changelog.ByModule["."]) != 1 {
+		t.Errorf("unexpected modules: %+v", changelog.ByModule)
+	}
+
+	summary := FormatChangelogSummary(changelog, testPageText(t, "en"))
+	feature := strings.Index(summary, "## feat\n### api\n")
+	fix := strings.Index(summary, "## fix\n### .\n")
+	if feature < 0 || fix < feature || !strings.Contains(summary, "add export endpoint [BREAKING] (Alice)") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+
+	if _, err := CollectChangelog(repo.path, "v0.9.0", "v1.1.0"); err == nil {
+		t.Error("unknown refs should be rejected")
+	}
+}
+
+func TestParseConventionalSubject(t *testing.T) {
+	cases := []struct {
+		subject, commitType, scope string
+		breaking                   bool
+	}{
+		{"feat: add export", "feat", "", false},
+		{"Fix(store)!: drop legacy format", "fix", "store", true},
+		{"docs(readme): typo", "docs", "readme", false},
+		{"wip: experiment", "other", "", false},
+		{"Merge branch 'main'", "other", "", false},
+	}
+	for _, tc := range cases {
+		commitType, scope, breaking := parseConventionalSubject(tc.subject)
+		if commitType != tc.commitType || scope != tc.scope || breaking != tc.breaking {
+			t.Errorf("parseConventionalSubject(%q) = %s %q %v", tc.subject, commitType, scope, breaking)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// internal/data/chatstore.go
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// ErrChatSessionNotFound 表示请求的聊天会话不存在或不属于当前用户
+var ErrChatSessionNotFound = errors.New("聊天会话不存在")
+
+// ChatStore 将聊天会话持久化到本地磁盘
+// 目录结构为 <basePath>/<用户ID的哈希>/<会话ID>.json，用户只能访问自己目录下的会话
+type ChatStore struct {
+	mu       sync.RWMutex
+	basePath string
+}
+
+// NewChatStore 创建聊天会话存储，未配置路径时使用默认根目录下的 chats 目录
+func NewChatStore(cfg *config.Config) *ChatStore {
+	basePath := cfg.Chat.StorePath
+	if basePath == "" {
+		basePath = filepath.Join(utils.GetDefaultRootPath(), "chats")
+	}
+	return &ChatStore{basePath: basePath}
+}
+
+// Create 为用户创建并保存一个新的会话，填充 ID 和时间
+func (s *ChatStore) Create(session *models.ChatSession) error {
+	if session.Owner == "" || session.RepoURL == "" {
+		return errors.New("创建聊天会话需要用户和仓库URL")
+	}
+	session.ID = uuid.New().String()
+	session.CreatedAt = time.Now().UTC()
+	session.UpdatedAt = session.CreatedAt
+	if session.Messages == nil {
+		session.Messages = []models.ChatMessage{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(session)
+}
+
+// Get 读取用户的某个会话
+func (s *ChatStore) Get(owner, id string) (*models.ChatSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.load(owner, id)
+}
+
+// Update 在写锁内读取、修改并保存用户的某个会话，fn 返回错误时不会保存
+func (s *ChatStore) Update(owner, id string, fn func(session *models.ChatSession) error) (*models.ChatSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.load(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(session); err != nil {
+		return nil, err
+	}
+	session.UpdatedAt = time.Now().UTC()
+	if err := s.write(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Delete 删除用户的某个会话
+func (s *ChatStore) Delete(owner, id string) error {
+	if !validSessionID(id) {
+		return ErrChatSessionNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(owner, id)); err != nil {
+		if os.IsNotExist(err) {
+			return ErrChatSessionNotFound
+		}
+		return fmt.Errorf("删除聊天会话失败: %v", err)
+	}
+	return nil
+}
+
+// List 列出用户的会话，repoURL 非空时只列出该仓库的会话，按更新时间倒序排列
+func (s *ChatStore) List(owner, repoURL string) ([]models.ChatSessionSummary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summaries := []models.ChatSessionSummary{}
+	entries, err := os.ReadDir(s.ownerDir(owner))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return summaries, nil
+		}
+		return nil, fmt.Errorf("读取聊天会话目录失败: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		session, err := s.load(owner, entry.Name()[:len(entry.Name())-len(".json")])
+		if err != nil {
+			return nil, err
+		}
+		if repoURL != "" && session.RepoURL != repoURL {
+			continue
+		}
+		summaries = append(summaries, session.Summary())
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries, nil
+}
+
+// write 将会话写入磁盘，调用方需持有写锁
+func (s *ChatStore) write(session *models.ChatSession) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化聊天会话失败: %v", err)
+	}
+	if err := os.MkdirAll(s.ownerDir(session.Owner), 0755); err != nil {
+		return fmt.Errorf("创建聊天会话目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免读取到写了一半的文件
+	path := s.path(session.Owner, session.ID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入聊天会话失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入聊天会话失败: %v", err)
+	}
+	return nil
+}
+
+// load 读取并解析用户的某个会话，调用方需持有锁
+func (s *ChatStore) load(owner, id string) (*models.ChatSession, error) {
+	if !validSessionID(id) {
+		return nil, ErrChatSessionNotFound
+	}
+	data, err := os.ReadFile(s.path(owner, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrChatSessionNotFound
+		}
+		return nil, fmt.Errorf("读取聊天会话失败: %v", err)
+	}
+
+	var session models.ChatSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("解析聊天会话 %s 失败: %v", id, err)
+	}
+	if session.Owner != owner {
+		return nil, ErrChatSessionNotFound
+	}
+	return &session, nil
+}
+
+// ownerDir 返回用户的会话目录，用户ID经过哈希以便安全地用作目录名
+func (s *ChatStore) ownerDir(owner string) string {
+	sum := sha256.Sum256([]byte(owner))
+	return filepath.Join(s.basePath, hex.EncodeToString(sum[:8]))
+}
+
+// path 返回会话文件的路径
+func (s *ChatStore) path(owner, id string) string {
+	return filepath.Join(s.ownerDir(owner), id+".json")
+}
+
+// validSessionID 检查会话ID是否为 Create 生成的 UUID
+func validSessionID(id string) bool {
+	_, err := uuid.Parse(id)
+	return err == nil && validStoreName(id)
+}
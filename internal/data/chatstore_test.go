@@ -0,0 +1,60 @@
+package data
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/models"
+)
+
+func TestChatStore(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Chat.StorePath = t.TempDir()
+	store := NewChatStore(cfg)
+
+	first := &models.ChatSession{Owner: "alice", RepoURL: "https://github.com/acme/demo", Ref: "main"}
+	second := &models.ChatSession{Owner: "alice", RepoURL: "https://github.com/acme/other"}
+	for _, session := range []*models.ChatSession{first, second, {Owner: "../bob", RepoURL: "https://github.com/acme/demo"}} {
+		if err := store.Create(session); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	updated, err := store.Update("alice", first.ID, func(session *models.ChatSession) error {
+		session.Messages = append(session.Messages, models.ChatMessage{Role: "user", Content: "hi"}, models.ChatMessage{Role: "assistant", Content: "hello"})
+		return nil
+	})
+	if err != nil || len(updated.Messages) != 2 {
+		t.Fatalf("update: %v", err)
+	}
+
+	sessions, err := store.List("alice", "")
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("list: %v %+v", err, sessions)
+	}
+	if sessions[0].ID != first.ID || sessions[0].MessageCount != 2 {
+		t.Errorf("most recently updated session should come first: %+v", sessions)
+	}
+	if sessions, _ := store.List("alice", "https://github.com/acme/other"); len(sessions) != 1 || sessions[0].ID != second.ID {
+		t.Errorf("unexpected filtered sessions: %+v", sessions)
+	}
+
+	// 其他用户既不能读取也不能删除
+	if _, err := store.Get("mallory", first.ID); !errors.Is(err, ErrChatSessionNotFound) {
+		t.Errorf("other users should not see the session: %v", err)
+	}
+	if err := store.Delete("mallory", first.ID); !errors.Is(err, ErrChatSessionNotFound) {
+		t.Errorf("other users should not delete the session: %v", err)
+	}
+	if _, err := store.Get("alice", "../"+first.ID); !errors.Is(err, ErrChatSessionNotFound) {
+		t.Errorf("invalid ids should be rejected: %v", err)
+	}
+
+	if err := store.Delete("alice", first.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get("alice", first.ID); !errors.Is(err, ErrChatSessionNotFound) {
+		t.Errorf("deleted session still readable: %v", err)
+	}
+}
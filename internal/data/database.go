@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/deepwiki-go/internal/config"
 	"github.com/deepwiki-go/internal/models"
@@ -195,6 +196,12 @@ func generateDocID(identifier string) int64 {
 // PrepareDatabase prepares the Milvus collection for the given repository.
 // It reads documents, generates embeddings, and inserts them into Milvus.
 func (dm *DatabaseManager) PrepareDatabase(repoURLOrPath string, accessToken string) error {
+	return dm.PrepareDatabaseContext(context.Background(), repoURLOrPath, accessToken)
+}
+
+// PrepareDatabaseContext is PrepareDatabase with cancellation: indexing stops
+// before the next document once ctx is done and ctx.Err() is returned.
+func (dm *DatabaseManager) PrepareDatabaseContext(ctx context.Context, repoURLOrPath string, accessToken string) error {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
 
@@ -214,10 +221,28 @@ func (dm *DatabaseManager) PrepareDatabase(repoURLOrPath string, accessToken str
 		return fmt.Errorf("failed to read documents: %w", err)
 	}
 
+	// Attach last author / last commit metadata so it can be used in retrieval filters
+	if history, err := CachedHistory(localRepoPath); err != nil {
+		log.Printf("Warning: failed to analyze git history for %s: %v", localRepoPath, err)
+	} else {
+		annotateWithHistory(documents, history)
+	}
+
+	// Record the indexed commit so citations can link to the exact source revision
+	if commit, err := HeadCommit(localRepoPath); err == nil {
+		for i := range documents {
+			documents[i].MetaData["commit"] = commit
+		}
+	}
+
 	log.Printf("Read %d documents. Generating embeddings and inserting into Milvus...", len(documents))
 	addedCount := 0
 	for _, doc := range documents {
-		if err := dm.addDocumentInternal(&doc); err != nil {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Indexing of %s canceled after %d documents", repoURLOrPath, addedCount)
+			return err
+		}
+		if err := dm.addDocumentInternal(ctx, &doc); err != nil {
 			// Log error but continue processing other documents
 			log.Printf("Error adding document '%s' to Milvus: %v", doc.MetaData["file_path"], err)
 		} else {
@@ -242,9 +267,7 @@ func (dm *DatabaseManager) createRepo(repoURLOrPath string, accessToken string)
 
 // addDocumentInternal adds a single document to Milvus (used internally by PrepareDatabase)
 // Assumes lock is already held if called from PrepareDatabase
-func (dm *DatabaseManager) addDocumentInternal(doc *models.Document) error {
-	ctx := context.Background()
-
+func (dm *DatabaseManager) addDocumentInternal(ctx context.Context, doc *models.Document) error {
 	// Generate embedding
 	embedding, err := dm.getEmbedding(doc.Text)
 	if err != nil {
@@ -362,6 +385,8 @@ func (dm *DatabaseManager) readAllDocuments(path string) ([]models.Document, err
 					"is_implementation": isImplementation,
 					"title":             relativePath,
 					"token_count":       tokenCount,
+					"start_line":        1,
+					"end_line":          strings.Count(string(content), "\n") + 1,
 				},
 			}
 			documents = append(documents, doc)
@@ -426,6 +451,8 @@ func (dm *DatabaseManager) readAllDocuments(path string) ([]models.Document, err
 					"is_implementation": false,
 					"title":             relativePath,
 					"token_count":       tokenCount,
+					"start_line":        1,
+					"end_line":          strings.Count(string(content), "\n") + 1,
 				},
 			}
 			documents = append(documents, doc)
@@ -436,6 +463,77 @@ func (dm *DatabaseManager) readAllDocuments(path string) ([]models.Document, err
 	return documents, nil
 }
 
+// DocumentFilter restricts search results by document metadata.
+// Zero values mean "no restriction".
+type DocumentFilter struct {
+	Author     string    `json:"author,omitempty"`      // matches last_author (case-insensitive)
+	Since      time.Time `json:"since"`                 // last_commit_date >= Since
+	Until      time.Time `json:"until"`                 // last_commit_date <= Until
+	PathPrefix string    `json:"path_prefix,omitempty"` // file_path prefix
+}
+
+// IsEmpty reports whether the filter has no restrictions.
+func (f DocumentFilter) IsEmpty() bool {
+	return f.Author == "" && f.Since.IsZero() && f.Until.IsZero() && f.PathPrefix == ""
+}
+
+// Match reports whether a document's metadata satisfies the filter.
+func (f DocumentFilter) Match(doc models.Document) bool {
+	if f.PathPrefix != "" {
+		filePath, _ := doc.MetaData["file_path"].(string)
+		if !strings.HasPrefix(filePath, f.PathPrefix) {
+			return false
+		}
+	}
+	if f.Author != "" {
+		author, _ := doc.MetaData["last_author"].(string)
+		if !strings.EqualFold(author, f.Author) {
+			return false
+		}
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		dateStr, _ := doc.MetaData["last_commit_date"].(string)
+		date, err := time.Parse(time.RFC3339, dateStr)
+		if err != nil {
+			return false
+		}
+		if !f.Since.IsZero() && date.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && date.After(f.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterOverfetchFactor controls how many extra candidates are fetched when filtering,
+// since the metadata filter is applied after the vector search.
+const filterOverfetchFactor = 4
+
+// SearchDocumentsWithFilter searches Milvus and keeps only documents matching the filter.
+func (dm *DatabaseManager) SearchDocumentsWithFilter(query string, topK int, filter DocumentFilter) ([]models.Document, error) {
+	if filter.IsEmpty() {
+		return dm.SearchDocuments(query, topK)
+	}
+
+	candidates, err := dm.SearchDocuments(query, topK*filterOverfetchFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]models.Document, 0, topK)
+	for _, doc := range candidates {
+		if filter.Match(doc) {
+			documents = append(documents, doc)
+			if len(documents) >= topK {
+				break
+			}
+		}
+	}
+	return documents, nil
+}
+
 // SearchDocuments searches Milvus for documents similar to the query.
 func (dm *DatabaseManager) SearchDocuments(query string, topK int) ([]models.Document, error) {
 	dm.mu.RLock()
@@ -589,7 +687,7 @@ func (dm *DatabaseManager) AddDocument(doc *models.Document) error {
 		return errors.New("DatabaseManager not initialized")
 	}
 
-	err := dm.addDocumentInternal(doc)
+	err := dm.addDocumentInternal(context.Background(), doc)
 	if err != nil {
 		return err
 	}
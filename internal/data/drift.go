@@ -0,0 +1,108 @@
+// internal/data/drift.go
+package data
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+// ChangedFiles 返回两个提交之间发生变更的文件，重命名的文件同时包含新旧路径
+func ChangedFiles(repoPath, fromCommit, toCommit string) ([]string, error) {
+	if fromCommit == toCommit {
+		return []string{}, nil
+	}
+	output, err := runGit(repoPath, "diff", "--name-status", "-M", fromCommit, toCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	files := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		// 格式为 "M\tpath" 或 "R100\told\tnew"
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		for _, f := range fields[1:] {
+			if f != "" && !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// LatestCommit 返回引用在远程上的最新提交，分支优先使用 origin/ 下的版本
+func LatestCommit(repoPath, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if output, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", "origin/"+ref+"^{commit}"); err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	return ResolveCommit(repoPath, ref)
+}
+
+// DetectDrift 根据页面引用的文件和两个提交之间的差异，找出 Wiki 中过期的页面
+// 页面的 FilePaths 中以 / 结尾的条目按目录前缀匹配
+func DetectDrift(wiki *models.Wiki, repoPath, toCommit string) (*models.DriftReport, error) {
+	changed, err := ChangedFiles(repoPath, wiki.Commit, toCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DriftReport{
+		RepoKey:      wiki.RepoKey,
+		WikiID:       wiki.ID,
+		Ref:          wiki.Ref,
+		FromCommit:   wiki.Commit,
+		ToCommit:     toCommit,
+		ChangedFiles: changed,
+		StalePages:   []models.PageDrift{},
+		CheckedAt:    time.Now().UTC(),
+	}
+
+	for _, page := range wiki.Pages {
+		if len(page.FilePaths) == 0 {
+			report.UntrackedPages = append(report.UntrackedPages, page.ID)
+			continue
+		}
+		var matched []string
+		for _, file := range changed {
+			if pageReferencesFile(page.FilePaths, file) {
+				matched = append(matched, file)
+			}
+		}
+		if len(matched) > 0 {
+			report.StalePages = append(report.StalePages, models.PageDrift{
+				PageID:        page.ID,
+				Title:         page.Title,
+				ChangedFiles:  matched,
+				HumanAuthored: page.HumanAuthored,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// pageReferencesFile 判断页面引用的路径是否覆盖指定文件
+func pageReferencesFile(filePaths []string, file string) bool {
+	for _, p := range filePaths {
+		p = strings.TrimPrefix(p, "./")
+		if p == "" {
+			continue
+		}
+		if strings.HasSuffix(p, "/") {
+			if strings.HasPrefix(file, p) {
+				return true
+			}
+		} else if file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	return false
+}
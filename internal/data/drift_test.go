@@ -0,0 +1,86 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+func TestDetectDrift(t *testing.T) {
+	repo := newTestRepo(t)
+	from := repo.commit("Alice", "", "first", map[string]string{
+		"main.go":          "package main\n",
+		"store/store.go":   "package store\n",
+		"store/cache.go":   "package store\n",
+		"docs/guide.md":    "# Guide\n",
+		"util/log.go":      "package util\n",
+		"storage/table.go": "package storage\n",
+	})
+	repo.git(repo.path, "mv", "util/log.go", "util/logger.go")
+	to := repo.commit("Bob", "", "second", map[string]string{
+		"store/cache.go":   "package store\n\nvar size = 1\n",
+		"storage/table.go": "package storage\n\nvar rows = 1\n",
+	})
+
+	wiki := &models.Wiki{
+		RepoKey: "github.com_acme_demo",
+		ID:      "main-" + shortSHA(from),
+		Commit:  from,
+		Pages: []models.WikiPage{
+			{ID: "overview", Title: "Overview", FilePaths: []string{"main.go", "docs/"}},
+			{ID: "store", Title: "Store", FilePaths: []string{"./store"}, HumanAuthored: true},
+			{ID: "logging", Title: "Logging", FilePaths: []string{"util/log.go"}},
+			{ID: "notes", Title: "Notes"},
+		},
+	}
+	report, err := DetectDrift(wiki, repo.path, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(report.ChangedFiles, ","); got != "storage/table.go,store/cache.go,util/log.go,util/logger.go" {
+		t.Errorf("unexpected changed files: %s", got)
+	}
+	var stale []string
+	for _, page := range report.StalePages {
+		stale = append(stale, page.PageID+":"+strings.Join(page.ChangedFiles, "+"))
+	}
+	// store 目录不匹配 storage 目录，重命名的文件按旧路径匹配
+	if got := strings.Join(stale, " "); got != "store:store/cache.go logging:util/log.go" {
+		t.Errorf("unexpected stale pages: %s", got)
+	}
+	if !report.StalePages[0].HumanAuthored {
+		t.Errorf("human-authored flag not reported: %+v", report.StalePages[0])
+	}
+	if len(report.UntrackedPages) != 1 || report.UntrackedPages[0] != "notes" {
+		t.Errorf("unexpected untracked pages: %v", report.UntrackedPages)
+	}
+
+	// 同一提交没有变更
+	if report, err := DetectDrift(wiki, repo.path, from); err != nil || len(report.ChangedFiles) != 0 || len(report.StalePages) != 0 {
+		t.Errorf("expected no drift at the source commit: %v %+v", err, report)
+	}
+}
+
+func TestPageReferencesFile(t *testing.T) {
+	cases := []struct {
+		paths []string
+		file  string
+		want  bool
+	}{
+		{[]string{"main.go"}, "main.go", true},
+		{[]string{"./main.go"}, "main.go", true},
+		{[]string{"main.go"}, "cmd/main.go", false},
+		{[]string{"store"}, "store/store.go", true},
+		{[]string{"store"}, "storage/table.go", false},
+		{[]string{"store/"}, "store/sub/file.go", true},
+		{[]string{"store/"}, "storefront.go", false},
+		{[]string{"", "./"}, "main.go", false},
+		{nil, "main.go", false},
+	}
+	for _, tc := range cases {
+		if got := pageReferencesFile(tc.paths, tc.file); got != tc.want {
+			t.Errorf("pageReferencesFile(%q, %q) = %v, want %v", tc.paths, tc.file, got, tc.want)
+		}
+	}
+}
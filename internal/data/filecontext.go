@@ -0,0 +1,372 @@
+// internal/data/filecontext.go
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+// maxPinnedFileSize 是聊天中可以固定的最大文件大小
+const maxPinnedFileSize = 4 << 20
+
+// pinnedChunkLines 是大文件按行切分的片段大小
+const pinnedChunkLines = 80
+
+// relativeImportPattern 匹配 JavaScript/TypeScript 中的相对导入
+var relativeImportPattern = regexp.MustCompile(`(?:\bfrom\s+|\brequire\(\s*|\bimport\s*\(?\s*)['"](\.{1,2}/[^'"]+)['"]`)
+
+// scriptExtensions 是按相对导入查找关联文件的脚本扩展名
+var scriptExtensions = map[string]bool{".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true}
+
+// FileExcerpt 是文件中的一段连续内容
+type FileExcerpt struct {
+	StartLine int
+	EndLine   int
+	Text      string
+}
+
+// FileContext 是聊天中固定在检索结果之前的文件内容
+type FileContext struct {
+	Path       string // 相对仓库根目录的路径
+	Commit     string // 读取时仓库检出的提交
+	StartLine  int    // 请求的行范围，0 表示整个文件
+	EndLine    int
+	TotalLines int           // 文件的总行数
+	Excerpts   []FileExcerpt // 按行号排列的片段
+	Partial    bool          // 内容超出预算，只保留了文件开头和与问题最相关的片段
+}
+
+// CleanRepoPath 将请求中的文件路径规范为相对仓库根目录的路径，拒绝绝对路径和指向仓库之外的路径
+func CleanRepoPath(filePath string) (string, error) {
+	p := strings.TrimSpace(filepath.ToSlash(filePath))
+	if p == "" || path.IsAbs(p) || filepath.IsAbs(filePath) {
+		return "", fmt.Errorf("无效的文件路径 %q", filePath)
+	}
+	p = path.Clean(p)
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") || p == ".git" || strings.HasPrefix(p, ".git/") {
+		return "", fmt.Errorf("无效的文件路径 %q", filePath)
+	}
+	return p, nil
+}
+
+// ReadFileContext 读取仓库中的文件作为聊天上下文
+// startLine 和 endLine 从 1 开始限定行范围，endLine 为 0 表示到文件末尾，两者都为 0 时读取整个文件；
+// 内容超过 maxChars 时按行切分为片段，保留文件开头和与 query 最相关的片段
+func ReadFileContext(repoPath, filePath string, startLine, endLine int, query string, maxChars int) (*FileContext, error) {
+	rel, err := CleanRepoPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if startLine < 0 || endLine < 0 || (endLine > 0 && endLine < startLine) {
+		return nil, fmt.Errorf("无效的行范围 %d-%d", startLine, endLine)
+	}
+
+	full := filepath.Join(repoPath, filepath.FromSlash(rel))
+	// 符号链接不能指向仓库之外
+	if resolved, err := filepath.EvalSymlinks(full); err == nil {
+		root, _ := filepath.EvalSymlinks(repoPath)
+		if within, err := filepath.Rel(root, resolved); err != nil || within == ".." || strings.HasPrefix(within, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("无效的文件路径 %q", filePath)
+		}
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("文件 %s 不存在", rel)
+		}
+		return nil, fmt.Errorf("读取文件 %s 失败: %v", rel, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s 是目录", rel)
+	}
+	if info.Size() > maxPinnedFileSize {
+		return nil, fmt.Errorf("文件 %s 过大", rel)
+	}
+	content, err := os.ReadFile(full)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件 %s 失败: %v", rel, err)
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil, fmt.Errorf("%s 是二进制文件", rel)
+	}
+
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n"), "\n")
+	file := &FileContext{Path: rel, StartLine: startLine, EndLine: endLine, TotalLines: len(lines)}
+	if commit, err := HeadCommit(repoPath); err == nil {
+		file.Commit = commit
+	}
+
+	first, last := 1, len(lines)
+	if startLine > 0 {
+		if startLine > len(lines) {
+			return nil, fmt.Errorf("起始行 %d 超出文件 %s 的行数 %d", startLine, rel, len(lines))
+		}
+		first = startLine
+	}
+	if endLine > 0 && endLine < last {
+		last = endLine
+	}
+
+	excerpt := FileExcerpt{StartLine: first, EndLine: last, Text: strings.Join(lines[first-1:last], "\n")}
+	if maxChars <= 0 || len(excerpt.Text) <= maxChars {
+		file.Excerpts = []FileExcerpt{excerpt}
+		return file, nil
+	}
+	file.Excerpts = selectExcerpts(lines, first, last, query, maxChars)
+	file.Partial = true
+	return file, nil
+}
+
+// selectExcerpts 将 [first, last] 行按固定行数切分，保留第一个片段和与 query 共有词最多的片段，总长度不超过 maxChars
+func selectExcerpts(lines []string, first, last int, query string, maxChars int) []FileExcerpt {
+	var chunks []FileExcerpt
+	for start := first; start <= last; start += pinnedChunkLines {
+		end := start + pinnedChunkLines - 1
+		if end > last {
+			end = last
+		}
+		chunks = append(chunks, FileExcerpt{StartLine: start, EndLine: end, Text: strings.Join(lines[start-1:end], "\n")})
+	}
+
+	terms := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(query, -1) {
+		terms[strings.ToLower(word)] = true
+	}
+	scores := make([]int, len(chunks))
+	for i, chunk := range chunks {
+		text := strings.ToLower(chunk.Text)
+		for term := range terms {
+			if strings.Contains(text, term) {
+				scores[i]++
+			}
+		}
+	}
+
+	// 第一个片段包含包声明和导入，总是保留；其余片段按得分和位置排序
+	order := make([]int, 0, len(chunks))
+	for i := 1; i < len(chunks); i++ {
+		order = append(order, i)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	order = append([]int{0}, order...)
+
+	var selected []FileExcerpt
+	used := 0
+	for _, i := range order {
+		if len(selected) > 0 && used+len(chunks[i].Text) > maxChars {
+			continue
+		}
+		selected = append(selected, chunks[i])
+		used += len(chunks[i].Text)
+	}
+	sort.Slice(selected, func(a, b int) bool { return selected[a].StartLine < selected[b].StartLine })
+	return selected
+}
+
+// Label 返回文件路径和行范围，例如 internal/api/chat.go:10-40
+func (f *FileContext) Label() string {
+	if f.StartLine == 0 && f.EndLine == 0 {
+		return f.Path
+	}
+	start, end := f.StartLine, f.EndLine
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 || end > f.TotalLines {
+		end = f.TotalLines
+	}
+	return f.Path + ":" + strconv.Itoa(start) + "-" + strconv.Itoa(end)
+}
+
+// Documents 将文件片段转换为带行号元数据的文档，以便与检索结果一起编号引用
+func (f *FileContext) Documents() []models.Document {
+	docs := make([]models.Document, 0, len(f.Excerpts))
+	for _, excerpt := range f.Excerpts {
+		meta := map[string]interface{}{
+			"file_path":  f.Path,
+			"start_line": excerpt.StartLine,
+			"end_line":   excerpt.EndLine,
+		}
+		if f.Commit != "" {
+			meta["commit"] = f.Commit
+		}
+		docs = append(docs, models.Document{
+			ID:       fmt.Sprintf("%s#L%d-L%d", f.Path, excerpt.StartLine, excerpt.EndLine),
+			Title:    f.Path,
+			Text:     excerpt.Text,
+			MetaData: meta,
+		})
+	}
+	return docs
+}
+
+// Covers 判断文件的 [start, end] 行是否已经包含在固定的片段中，start 为 0 表示整个文件
+func (f *FileContext) Covers(filePath string, start, end int) bool {
+	if filePath != f.Path {
+		return false
+	}
+	if start == 0 {
+		return !f.Partial && f.StartLine == 0 && f.EndLine == 0
+	}
+	if end < start {
+		end = start
+	}
+	for _, excerpt := range f.Excerpts {
+		if start >= excerpt.StartLine && end <= excerpt.EndLine {
+			return true
+		}
+	}
+	return false
+}
+
+// RelatedFiles 描述与某个文件相关的目录：它所在的包、它导入的仓库内的包以及导入它的包
+// Go 文件按 go.mod 中的模块路径解析导入，JavaScript/TypeScript 文件按相对导入解析
+type RelatedFiles struct {
+	File      string
+	Package   string          // 文件所在的目录
+	Imports   map[string]bool // 文件导入的目录
+	Importers map[string]bool // 导入该文件或其所在包的文件的目录
+}
+
+// FindRelatedFiles 查找仓库中与 filePath 相关的目录
+func FindRelatedFiles(repoPath, filePath string) (*RelatedFiles, error) {
+	rel, err := CleanRepoPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	related := &RelatedFiles{
+		File:      rel,
+		Package:   path.Dir(rel),
+		Imports:   make(map[string]bool),
+		Importers: make(map[string]bool),
+	}
+
+	ext := path.Ext(rel)
+	switch {
+	case ext == ".go":
+		related.findGoImports(repoPath)
+	case scriptExtensions[ext]:
+		related.findScriptImports(repoPath)
+	}
+	delete(related.Imports, related.Package)
+	delete(related.Importers, related.Package)
+	return related, nil
+}
+
+// findGoImports 收集 Go 文件导入的仓库内包，以及导入该文件所在包的其他包
+func (r *RelatedFiles) findGoImports(repoPath string) {
+	modulePath := readModulePath(repoPath)
+	if modulePath == "" {
+		return
+	}
+	fset := token.NewFileSet()
+	if f, err := parser.ParseFile(fset, filepath.Join(repoPath, filepath.FromSlash(r.File)), nil, parser.ImportsOnly); err == nil {
+		for _, spec := range f.Imports {
+			if dir, ok := moduleDir(modulePath, spec.Path.Value); ok {
+				r.Imports[dir] = true
+			}
+		}
+	}
+
+	walkSourceFiles(repoPath, func(rel, full string) {
+		if path.Ext(rel) != ".go" {
+			return
+		}
+		f, err := parser.ParseFile(fset, full, nil, parser.ImportsOnly)
+		if err != nil {
+			return
+		}
+		for _, spec := range f.Imports {
+			if dir, ok := moduleDir(modulePath, spec.Path.Value); ok && dir == r.Package {
+				r.Importers[path.Dir(rel)] = true
+			}
+		}
+	})
+}
+
+// moduleDir 将模块内的导入路径转换为相对仓库根目录的目录
+func moduleDir(modulePath, quoted string) (string, bool) {
+	importPath, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", false
+	}
+	if importPath == modulePath {
+		return ".", true
+	}
+	if strings.HasPrefix(importPath, modulePath+"/") {
+		return strings.TrimPrefix(importPath, modulePath+"/"), true
+	}
+	return "", false
+}
+
+// findScriptImports 收集脚本文件相对导入的目录，以及相对导入该文件的其他文件所在的目录
+func (r *RelatedFiles) findScriptImports(repoPath string) {
+	target := strings.TrimSuffix(r.File, path.Ext(r.File))
+	walkSourceFiles(repoPath, func(rel, full string) {
+		if !scriptExtensions[path.Ext(rel)] {
+			return
+		}
+		content, err := os.ReadFile(full)
+		if err != nil {
+			return
+		}
+		for _, m := range relativeImportPattern.FindAllStringSubmatch(string(content), -1) {
+			resolved := path.Join(path.Dir(rel), m[1])
+			resolved = strings.TrimSuffix(resolved, path.Ext(resolved))
+			if rel == r.File {
+				r.Imports[path.Dir(resolved)] = true
+			} else if resolved == target || (path.Base(target) == "index" && resolved == path.Dir(target)) {
+				r.Importers[path.Dir(rel)] = true
+			}
+		}
+	})
+}
+
+// walkSourceFiles 遍历仓库中不超过 maxMentionFileSize 的文件，跳过隐藏目录和依赖目录
+func walkSourceFiles(repoPath string, fn func(rel, full string)) {
+	filepath.Walk(repoPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, relErr := filepath.Rel(repoPath, p)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		if info.IsDir() {
+			if skipAPIDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Size() <= maxMentionFileSize {
+			fn(filepath.ToSlash(rel), p)
+		}
+		return nil
+	})
+}
+
+// Weight 返回某个文件与固定文件的相关程度：3 为同一文件，2 为同一个包，1 为导入或被导入的包，0 为无关
+func (r *RelatedFiles) Weight(filePath string) int {
+	filePath = path.Clean(filepath.ToSlash(filePath))
+	dir := path.Dir(filePath)
+	switch {
+	case filePath == r.File:
+		return 3
+	case dir == r.Package:
+		return 2
+	case r.Imports[dir] || r.Importers[dir]:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadFileContext(t *testing.T) {
+	repo := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":             "module example.com/demo\n",
+		"store/store.go":     "package store\n\nimport \"example.com/demo/util\"\n\nfunc Save() { util.Log() }\n",
+		"store/cache.go":     "package store\n",
+		"util/log.go":        "package util\n\nfunc Log() {}\n",
+		"api/handler.go":     "package api\n\nimport \"example.com/demo/store\"\n\nvar _ = store.Save\n",
+		"other/unrelated.go": "package other\n",
+	} {
+		writeTestFile(t, repo, name, content)
+	}
+
+	file, err := ReadFileContext(repo, "./store/store.go", 3, 0, "", 0)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if file.Path != "store/store.go" || file.Label() != "store/store.go:3-5" || len(file.Excerpts) != 1 || !strings.HasPrefix(file.Excerpts[0].Text, "import") {
+		t.Errorf("unexpected file context: %+v", file)
+	}
+	if !file.Covers("store/store.go", 4, 5) || file.Covers("store/store.go", 0, 0) || file.Covers("store/store.go", 1, 2) {
+		t.Error("unexpected coverage")
+	}
+	for _, bad := range []string{"../secret", "/etc/passwd", ".git/config", "store"} {
+		if _, err := ReadFileContext(repo, bad, 0, 0, "", 0); err == nil {
+			t.Errorf("%s should be rejected", bad)
+		}
+	}
+	if _, err := ReadFileContext(repo, "store/store.go", 9, 0, "", 0); err == nil {
+		t.Error("start line beyond the file should be rejected")
+	}
+
+	// 超出预算的文件只保留开头和与问题相关的片段
+	var big strings.Builder
+	for i := 1; i <= 400; i++ {
+		fmt.Fprintf(&big, "line %d\n", i)
+	}
+	writeTestFile(t, repo, "big.txt", strings.Replace(big.String(), "line 250\n", "func retryBackoff() {}\n", 1))
+	file, err = ReadFileContext(repo, "big.txt", 0, 0, "how does retryBackoff work?", 1500)
+	if err != nil {
+		t.Fatalf("read big file: %v", err)
+	}
+	if !file.Partial || len(file.Excerpts) != 2 || file.Excerpts[0].StartLine != 1 || file.Excerpts[1].StartLine != 241 {
+		t.Errorf("unexpected excerpts: %+v", file.Excerpts)
+	}
+
+	related, err := FindRelatedFiles(repo, "store/store.go")
+	if err != nil {
+		t.Fatalf("related: %v", err)
+	}
+	for path, want := range map[string]int{"store/store.go": 3, "store/cache.go": 2, "util/log.go": 1, "api/handler.go": 1, "other/unrelated.go": 0} {
+		if got := related.Weight(path); got != want {
+			t.Errorf("weight of %s = %d, want %d", path, got, want)
+		}
+	}
+}
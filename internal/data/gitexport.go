@@ -0,0 +1,233 @@
+// internal/data/gitexport.go
+package data
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GitCommitOptions 描述如何将一组文件提交到仓库的分支
+type GitCommitOptions struct {
+	Branch      string            // 目标分支
+	Orphan      bool              // 孤立分支：提交树中只包含导出的文件，分支不存在时创建无父提交的分支
+	Dir         string            // 文件写入的目录，提交时替换该目录中原有的内容；孤立分支可以为空
+	Files       map[string][]byte // 相对于 Dir 的路径 -> 文件内容
+	Message     string            // 提交说明
+	AuthorName  string
+	AuthorEmail string
+	PushURL     string // 非空时提交后推送到该地址，地址中包含推送使用的凭据；不会使用 origin 中保存的凭据
+}
+
+// GitCommitResult 表示提交的结果
+type GitCommitResult struct {
+	Branch  string `json:"branch"`
+	Commit  string `json:"commit"`           // 分支的最新提交，没有变化时为原有的提交
+	Parent  string `json:"parent,omitempty"` // 新提交的父提交，新建的孤立分支为空
+	Changed bool   `json:"changed"`          // 文件内容是否有变化
+	Files   int    `json:"files"`
+	Pushed  bool   `json:"pushed"`
+}
+
+// credentialPattern 匹配 URL 中的凭据，用于从 git 的错误输出中隐藏令牌
+var credentialPattern = regexp.MustCompile(`(https?://)[^/@\s]+@`)
+
+// CommitFiles 在不改动工作区的情况下将文件提交到指定分支
+// 使用临时索引构建提交树：普通分支保留原有内容并替换 Dir 目录，孤立分支只包含导出的文件
+// 内容与分支最新提交相同时不创建新提交
+func CommitFiles(repoPath string, opts GitCommitOptions) (*GitCommitResult, error) {
+	if opts.Branch == "" {
+		return nil, fmt.Errorf("未指定目标分支")
+	}
+	if _, err := runGit(repoPath, "check-ref-format", "--branch", opts.Branch); err != nil {
+		return nil, fmt.Errorf("无效的分支名 %s", opts.Branch)
+	}
+	dir := strings.Trim(path.Clean("/"+filepath.ToSlash(opts.Dir)), "/")
+	if dir == "" && !opts.Orphan {
+		return nil, fmt.Errorf("提交到已有分支时必须指定目录")
+	}
+
+	base := branchTip(repoPath, opts.Branch)
+	if base == "" && !opts.Orphan {
+		return nil, fmt.Errorf("分支 %s 不存在", opts.Branch)
+	}
+
+	tree, err := buildTree(repoPath, base, dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GitCommitResult{Branch: opts.Branch, Commit: base, Files: len(opts.Files)}
+	if base != "" {
+		baseTree, err := runGit(repoPath, "rev-parse", base+"^{tree}")
+		if err != nil {
+			return nil, err
+		}
+		result.Changed = strings.TrimSpace(string(baseTree)) != tree
+	} else {
+		result.Changed = true
+	}
+
+	if result.Changed {
+		args := []string{"commit-tree", tree, "-m", opts.Message}
+		if base != "" {
+			args = append(args, "-p", base)
+		}
+		output, err := runGitEnv(repoPath, authorEnv(opts), args...)
+		if err != nil {
+			return nil, err
+		}
+		commit := strings.TrimSpace(string(output))
+
+		if err := advanceBranch(repoPath, opts.Branch, commit, opts.Message); err != nil {
+			return nil, err
+		}
+		result.Parent = base
+		result.Commit = commit
+	}
+
+	if opts.PushURL != "" && result.Commit != "" {
+		if _, err := runGit(repoPath, "push", "--quiet", opts.PushURL, result.Commit+":refs/heads/"+opts.Branch); err != nil {
+			return result, fmt.Errorf("推送分支 %s 失败: %s", opts.Branch, credentialPattern.ReplaceAllString(err.Error(), "${1}***@"))
+		}
+		result.Pushed = true
+	}
+	return result, nil
+}
+
+// advanceBranch 将分支移动到新提交
+// 目标分支正在检出时以快进方式合并，使工作区随分支更新，克隆不会停留在分离头指针状态
+func advanceBranch(repoPath, branch, commit, message string) error {
+	unlock := lockRepo(repoPath)
+	defer unlock()
+
+	if CurrentBranch(repoPath) == branch {
+		_, err := runGit(repoPath, "merge", "--ff-only", "--quiet", commit)
+		return err
+	}
+	_, err := runGit(repoPath, "update-ref", "-m", "deepwiki: "+firstLine(message), "refs/heads/"+branch, commit)
+	return err
+}
+
+// branchTip 返回分支的最新提交，本地分支落后于远程跟踪分支时使用远程的提交，都不存在时返回空字符串
+func branchTip(repoPath, branch string) string {
+	resolve := func(ref string) string {
+		output, err := runGit(repoPath, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(output))
+	}
+	local := resolve("refs/heads/" + branch)
+	remote := resolve("refs/remotes/origin/" + branch)
+	if local == "" {
+		return remote
+	}
+	if remote != "" && remote != local {
+		if _, err := runGit(repoPath, "merge-base", "--is-ancestor", local, remote); err == nil {
+			return remote
+		}
+	}
+	return local
+}
+
+// buildTree 在临时索引中构建提交树并返回树对象的 SHA
+func buildTree(repoPath, base, dir string, opts GitCommitOptions) (string, error) {
+	tmp, err := os.MkdirTemp("", "deepwiki-git-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+
+	if base != "" && !opts.Orphan {
+		if _, err := runGitEnv(repoPath, env, "read-tree", base); err != nil {
+			return "", err
+		}
+		if _, err := runGitEnv(repoPath, env, "rm", "-r", "--cached", "--quiet", "--ignore-unmatch", "--", dir); err != nil {
+			return "", err
+		}
+	}
+
+	names := make([]string, 0, len(opts.Files))
+	for name := range opts.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// 先写入临时文件，再一次性写入对象库
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(tmp, fmt.Sprintf("blob-%d", i))
+		if err := os.WriteFile(paths[i], opts.Files[name], 0644); err != nil {
+			return "", err
+		}
+	}
+	if len(paths) > 0 {
+		output, err := runGit(repoPath, append([]string{"hash-object", "-w", "--"}, paths...)...)
+		if err != nil {
+			return "", err
+		}
+		blobs := strings.Fields(string(output))
+		if len(blobs) != len(names) {
+			return "", fmt.Errorf("写入文件对象失败")
+		}
+		args := []string{"update-index", "--add"}
+		for i, name := range names {
+			target := strings.TrimPrefix(path.Join(dir, path.Clean("/"+filepath.ToSlash(name))), "/")
+			args = append(args, "--cacheinfo", "100644,"+blobs[i]+","+target)
+		}
+		if _, err := runGitEnv(repoPath, env, args...); err != nil {
+			return "", err
+		}
+	}
+
+	output, err := runGitEnv(repoPath, env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// authorEnv 返回提交作者和提交者的环境变量
+func authorEnv(opts GitCommitOptions) []string {
+	name, email := opts.AuthorName, opts.AuthorEmail
+	if name == "" {
+		name = "DeepWiki-Go"
+	}
+	if email == "" {
+		email = "deepwiki@localhost"
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email,
+	}
+}
+
+// firstLine 返回文本的第一行
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// DefaultBranch 返回远程仓库的默认分支，无法确定时返回当前分支或 main
+func DefaultBranch(repoPath string) string {
+	if output, err := runGit(repoPath, "symbolic-ref", "--quiet", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(output)), "refs/remotes/origin/")
+	}
+	if branch := CurrentBranch(repoPath); branch != "" {
+		return branch
+	}
+	return "main"
+}
+
+// IsBranch 判断引用是否是本地或远程分支
+func IsBranch(repoPath, ref string) bool {
+	return ref != "" && branchTip(repoPath, ref) != ""
+}
@@ -0,0 +1,89 @@
+package data
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin.git")
+	repo := filepath.Join(root, "clone")
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		output, err := runGitEnv(dir, authorEnv(GitCommitOptions{}), args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	git(root, "init", "--quiet", "--bare", "--initial-branch=main", origin)
+	git(root, "clone", "--quiet", origin, repo)
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(repo, "add", "main.go")
+	git(repo, "commit", "--quiet", "-m", "init")
+	git(repo, "push", "--quiet", "origin", "main")
+
+	// 写入代码分支的目录，原有文件保留，目录中的旧页面被删除
+	opts := GitCommitOptions{
+		Branch:  "main",
+		Dir:     "docs/wiki",
+		Files:   map[string][]byte{"README.md": []byte("# Wiki\n"), "overview.md": []byte("# Overview\n")},
+		Message: "docs(wiki): update\n\n源码提交: abc",
+		PushURL: origin,
+	}
+	result, err := CommitFiles(repo, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Changed || !result.Pushed || result.Parent == "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if files := git(origin, "ls-tree", "-r", "--name-only", "main"); files != "docs/wiki/README.md\ndocs/wiki/overview.md\nmain.go" {
+		t.Errorf("unexpected tree:\n%s", files)
+	}
+	// 正在检出的目标分支以快进方式更新，工作区中出现导出的文件
+	if CurrentBranch(repo) != "main" || git(repo, "rev-parse", "HEAD") != result.Commit {
+		t.Error("checked-out target branch should be fast-forwarded")
+	}
+	if _, err := os.Stat(filepath.Join(repo, "docs", "wiki", "overview.md")); err != nil {
+		t.Errorf("working tree not updated: %v", err)
+	}
+
+	opts.Files = map[string][]byte{"README.md": []byte("# Wiki\n")}
+	result, err = CommitFiles(repo, opts)
+	if err != nil || !result.Changed {
+		t.Fatalf("unexpected result: %+v, %v", result, err)
+	}
+	if files := git(repo, "ls-tree", "-r", "--name-only", "main"); strings.Contains(files, "overview.md") {
+		t.Errorf("removed page should be deleted:\n%s", files)
+	}
+
+	// 内容相同时不创建新提交
+	again, err := CommitFiles(repo, opts)
+	if err != nil || again.Changed || again.Commit != result.Commit {
+		t.Fatalf("unchanged export should not commit: %+v, %v", again, err)
+	}
+
+	// 孤立分支只包含导出的文件
+	orphan, err := CommitFiles(repo, GitCommitOptions{Branch: "deepwiki", Orphan: true, Files: opts.Files, Message: "wiki"})
+	if err != nil || orphan.Parent != "" {
+		t.Fatalf("unexpected orphan result: %+v, %v", orphan, err)
+	}
+	if files := git(repo, "ls-tree", "-r", "--name-only", "deepwiki"); files != "README.md" {
+		t.Errorf("unexpected orphan tree:\n%s", files)
+	}
+
+	if _, err := CommitFiles(repo, GitCommitOptions{Branch: "missing", Dir: "docs", Message: "x"}); err == nil {
+		t.Error("missing branch should be rejected")
+	}
+}
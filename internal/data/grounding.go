@@ -0,0 +1,393 @@
+// internal/data/grounding.go
+package data
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+// 未验证内容的处理方式
+const (
+	GroundingAnnotate = "annotate" // 在未验证的引用后添加标记
+	GroundingStrip    = "strip"    // 删除未验证的引用，行内其余内容保留
+	GroundingOff      = "off"      // 不做核对
+)
+
+// maxMentionFileSize 是收集标识符时读取的最大文件大小
+const maxMentionFileSize = 512 * 1024
+
+// unverifiedMark 是标注在未验证引用之后的文本
+const unverifiedMark = " *(未验证)*"
+
+var (
+	inlineCodePattern  = regexp.MustCompile("`([^`\n]+)`")
+	linkTargetPattern  = regexp.MustCompile(`\]\([^)]*\)`)
+	urlPattern         = regexp.MustCompile(`https?://\S+`)
+	identifierPattern  = regexp.MustCompile(`^[A-Za-z_]\w*(\.[A-Za-z_]\w*)*$`)
+	wordPattern        = regexp.MustCompile(`[A-Za-z_]\w{2,}`)
+	declarationPattern = regexp.MustCompile(`\b(?:def|class|function|interface|struct|enum|trait|type|fn|func)\s+([A-Za-z_]\w*)`)
+	assignmentPattern  = regexp.MustCompile(`\b(?:const|let|var)\s+([A-Za-z_]\w*)\s*=`)
+	blankLinePattern   = regexp.MustCompile(`^\s*(?:[-*+>]|\d+\.)?[\s.,;:()]*$`)
+	doubleSpacePattern = regexp.MustCompile(`(\S) {2,}`)
+	spacePunctPattern  = regexp.MustCompile(` +([.,;:)])`)
+	prosePathPattern   = regexp.MustCompile(`(?:[\w.-]+/)+[\w.-]+\.[A-Za-z]{1,5}\b|\b[\w-]+\.(?:go|py|js|ts|tsx|jsx|java|rs|rb|php|cs|cpp|swift|kt|yaml|yml|toml|json|mod|proto|sql|sh)\b`)
+)
+
+// knownFileExtensions 是判断引用是否为文件路径时识别的扩展名
+var knownFileExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".ts": true, ".tsx": true, ".jsx": true, ".java": true,
+	".rs": true, ".rb": true, ".php": true, ".cs": true, ".c": true, ".h": true, ".cpp": true,
+	".swift": true, ".kt": true, ".md": true, ".txt": true, ".rst": true, ".yaml": true, ".yml": true,
+	".toml": true, ".json": true, ".mod": true, ".sum": true, ".proto": true, ".sql": true,
+	".sh": true, ".html": true, ".css": true,
+}
+
+// ignoredIdentifiers 是语言关键字和内置标识符，它们不是对仓库内容的断言
+var ignoredIdentifiers = map[string]bool{
+	"nil": true, "null": true, "true": true, "false": true, "None": true, "True": true, "False": true,
+	"string": true, "int": true, "int64": true, "int32": true, "uint": true, "float64": true, "float32": true,
+	"bool": true, "byte": true, "rune": true, "error": true, "any": true, "interface": true, "struct": true,
+	"map": true, "chan": true, "func": true, "go": true, "defer": true, "return": true, "var": true,
+	"const": true, "type": true, "import": true, "package": true, "make": true, "new": true, "len": true,
+	"append": true, "panic": true, "recover": true, "main": true, "init": true, "self": true, "this": true,
+	"void": true, "async": true, "await": true, "class": true, "def": true, "function": true,
+}
+
+// SymbolIndex 记录仓库中的文件、目录和代码符号，用于核对生成内容
+type SymbolIndex struct {
+	files     map[string]bool // 相对路径
+	dirs      map[string]bool
+	baseNames map[string]bool
+	symbols   map[string]bool // 声明的符号，包含 Type.Method 形式
+	packages  map[string]bool // 仓库内的包名和目录名
+	external  map[string]bool // 导入的外部包名
+	mentions  map[string]bool // 源文件中出现过的所有标识符
+}
+
+// BuildSymbolIndex 遍历仓库，建立文件树和符号表
+func BuildSymbolIndex(repoPath string) (*SymbolIndex, error) {
+	index := &SymbolIndex{
+		files:     make(map[string]bool),
+		dirs:      make(map[string]bool),
+		baseNames: make(map[string]bool),
+		symbols:   make(map[string]bool),
+		packages:  make(map[string]bool),
+		external:  make(map[string]bool),
+		mentions:  make(map[string]bool),
+	}
+
+	err := filepath.Walk(repoPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, relErr := filepath.Rel(repoPath, p)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "node_modules" || info.Name() == "vendor" {
+				return filepath.SkipDir
+			}
+			index.dirs[rel] = true
+			index.packages[info.Name()] = true
+			return nil
+		}
+
+		index.files[rel] = true
+		index.baseNames[info.Name()] = true
+		if info.Size() > maxMentionFileSize {
+			return nil
+		}
+
+		if strings.HasSuffix(rel, ".go") {
+			index.addGoFile(p)
+		}
+		index.addMentions(p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// addGoFile 从 Go 源文件中收集包名、导入和顶层声明
+func (idx *SymbolIndex) addGoFile(filePath string) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return
+	}
+	idx.packages[file.Name.Name] = true
+
+	for _, imp := range file.Imports {
+		importPath, _ := strconv.Unquote(imp.Path.Value)
+		name := path.Base(importPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		idx.external[name] = true
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			idx.symbols[d.Name.Name] = true
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				if recv := receiverName(d.Recv.List[0].Type); recv != "" {
+					idx.symbols[recv+"."+d.Name.Name] = true
+				}
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					idx.symbols[s.Name.Name] = true
+					idx.addTypeMembers(s)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						idx.symbols[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// addTypeMembers 记录结构体字段和接口方法，形式为 Type.Member
+func (idx *SymbolIndex) addTypeMembers(spec *ast.TypeSpec) {
+	var fields *ast.FieldList
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	}
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			idx.symbols[spec.Name.Name+"."+name.Name] = true
+		}
+	}
+}
+
+// receiverName 返回方法接收者的类型名
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	}
+	return ""
+}
+
+// addMentions 收集文件中出现的标识符和其他语言的声明
+func (idx *SymbolIndex) addMentions(filePath string) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxMentionFileSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, word := range wordPattern.FindAllString(line, -1) {
+			idx.mentions[word] = true
+		}
+		for _, m := range declarationPattern.FindAllStringSubmatch(line, -1) {
+			idx.symbols[m[1]] = true
+		}
+		for _, m := range assignmentPattern.FindAllStringSubmatch(line, -1) {
+			idx.symbols[m[1]] = true
+		}
+	}
+}
+
+// VerifyGrounding 核对页面中引用的文件路径和代码标识符是否存在于仓库中
+// mode 为 GroundingAnnotate 时标注未验证的引用，为 GroundingStrip 时只删除这些引用，删除后没有剩余内容的行一并去掉
+func VerifyGrounding(content string, index *SymbolIndex, mode string) (string, *models.GroundingReport) {
+	report := &models.GroundingReport{}
+	unverified := make(map[string]bool)
+
+	lines := strings.Split(content, "\n")
+	var out []string
+	inFence := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		var failed []string
+		for _, claim := range extractClaims(line) {
+			ok, checked := index.verify(claim)
+			if !checked {
+				continue
+			}
+			report.Checked++
+			if ok {
+				report.Verified++
+				continue
+			}
+			failed = append(failed, claim)
+			if !unverified[claim] {
+				unverified[claim] = true
+				report.Unverified = append(report.Unverified, claim)
+			}
+		}
+
+		if len(failed) == 0 {
+			out = append(out, line)
+			continue
+		}
+		if mode == GroundingStrip && !strings.HasPrefix(trimmed, "#") {
+			if stripped := stripClaims(line, failed); !blankLinePattern.MatchString(stripped) {
+				out = append(out, stripped)
+			}
+			continue
+		}
+		out = append(out, annotateClaims(line, failed))
+	}
+
+	report.Score = 1
+	if report.Checked > 0 {
+		report.Score = float64(report.Verified) / float64(report.Checked)
+	}
+	return strings.Join(out, "\n"), report
+}
+
+// extractClaims 提取一行 Markdown 中的行内代码和正文中的文件路径
+func extractClaims(line string) []string {
+	var claims []string
+	for _, m := range inlineCodePattern.FindAllStringSubmatch(line, -1) {
+		claims = append(claims, strings.TrimSpace(m[1]))
+	}
+
+	prose := inlineCodePattern.ReplaceAllString(line, " ")
+	prose = linkTargetPattern.ReplaceAllString(prose, "]")
+	prose = urlPattern.ReplaceAllString(prose, " ")
+	claims = append(claims, prosePathPattern.FindAllString(prose, -1)...)
+	return claims
+}
+
+// verify 核对单个引用，checked 为 false 表示该引用不是可核对的路径或标识符
+func (idx *SymbolIndex) verify(claim string) (ok, checked bool) {
+	claim = strings.TrimSuffix(claim, "()")
+	if claim == "" || strings.ContainsAny(claim, " \t=:,;\"'<>{}") || strings.Contains(claim, "://") {
+		return false, false
+	}
+
+	if looksLikePath(claim) {
+		if strings.Contains(claim, "...") || strings.ContainsAny(claim, "*?$") {
+			return false, false
+		}
+		return idx.hasPath(claim), true
+	}
+
+	if !identifierPattern.MatchString(claim) {
+		return false, false
+	}
+	segments := strings.Split(claim, ".")
+	if len(segments) == 1 {
+		if len(claim) < 3 || ignoredIdentifiers[claim] {
+			return false, false
+		}
+		return idx.symbols[claim] || idx.mentions[claim], true
+	}
+
+	// 限定名：外部包的符号不属于仓库，跳过
+	first, last := segments[0], segments[len(segments)-1]
+	if idx.external[first] && !idx.packages[first] && !idx.symbols[first] {
+		return false, false
+	}
+	if idx.symbols[claim] || idx.symbols[strings.Join(segments[len(segments)-2:], ".")] {
+		return true, true
+	}
+	known := func(name string) bool { return idx.symbols[name] || idx.packages[name] || idx.mentions[name] }
+	return known(first) && known(last), true
+}
+
+// hasPath 判断路径是否为仓库中的文件或目录，也接受省略了上级目录的路径
+func (idx *SymbolIndex) hasPath(p string) bool {
+	p = strings.TrimPrefix(strings.TrimSuffix(p, "/"), "./")
+	if idx.files[p] || idx.dirs[p] {
+		return true
+	}
+	if !strings.Contains(p, "/") {
+		return idx.baseNames[p] || idx.packages[p]
+	}
+	for file := range idx.files {
+		if strings.HasSuffix(file, "/"+p) {
+			return true
+		}
+	}
+	for dir := range idx.dirs {
+		if strings.HasSuffix(dir, "/"+p) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikePath 判断引用是否像文件或目录路径
+func looksLikePath(claim string) bool {
+	if strings.Contains(claim, "/") {
+		return true
+	}
+	return knownFileExtensions[strings.ToLower(path.Ext(claim))]
+}
+
+// annotateClaims 在行内每个未验证的引用之后添加标记
+func annotateClaims(line string, failed []string) string {
+	for _, claim := range failed {
+		code := "`" + claim + "`"
+		if strings.Contains(line, code) {
+			line = strings.Replace(line, code, code+unverifiedMark, 1)
+		} else {
+			line = strings.Replace(line, claim, claim+unverifiedMark, 1)
+		}
+	}
+	return line
+}
+
+// stripClaims 删除行内每个未验证的引用，并清理删除后多余的空格
+func stripClaims(line string, failed []string) string {
+	for _, claim := range failed {
+		code := "`" + claim + "`"
+		if strings.Contains(line, code) {
+			line = strings.Replace(line, code, "", 1)
+		} else {
+			line = strings.Replace(line, claim, "", 1)
+		}
+	}
+	line = doubleSpacePattern.ReplaceAllString(line, "$1 ")
+	line = spacePunctPattern.ReplaceAllString(line, "$1")
+	return strings.TrimRight(line, " ")
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyGrounding(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, "internal/store/store.go", "package store\n\ntype Store struct {\n\tpath string\n}\n\nfunc (s *Store) Save() error { return nil }\n")
+	writeTestFile(t, root, "README.md", "# Demo\n")
+	index, err := BuildSymbolIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := strings.Join([]string{
+		"## Storage",
+		"`Store.Save` in internal/store/store.go persists data.",
+		"See `Cache.Flush` and internal/cache/cache.go for caching.",
+		"- `Missing`",
+		"```go",
+		"var x = GhostType{}",
+		"```",
+		"Read the [guide](docs/guide.md) first.",
+	}, "\n")
+
+	tests := []struct {
+		mode       string
+		want       []string
+		unverified string
+	}{
+		{
+			mode: GroundingAnnotate,
+			want: []string{
+				"## Storage",
+				"`Store.Save` in internal/store/store.go persists data.",
+				"See `Cache.Flush`" + unverifiedMark + " and internal/cache/cache.go" + unverifiedMark + " for caching.",
+				"- `Missing`" + unverifiedMark,
+				"```go",
+				"var x = GhostType{}",
+				"```",
+				"Read the [guide](docs/guide.md) first.",
+			},
+			unverified: "Cache.Flush,internal/cache/cache.go,Missing",
+		},
+		{
+			// 只删除未验证的引用，行内其余内容保留，只剩列表标记的行被去掉
+			mode: GroundingStrip,
+			want: []string{
+				"## Storage",
+				"`Store.Save` in internal/store/store.go persists data.",
+				"See and for caching.",
+				"```go",
+				"var x = GhostType{}",
+				"```",
+				"Read the [guide](docs/guide.md) first.",
+			},
+			unverified: "Cache.Flush,internal/cache/cache.go,Missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got, report := VerifyGrounding(content, index, tt.mode)
+			if want := strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("unexpected content:\n%s\nwant:\n%s", got, want)
+			}
+			// 代码块和链接目标不参与核对
+			if strings.Join(report.Unverified, ",") != tt.unverified {
+				t.Errorf("unexpected unverified claims: %v", report.Unverified)
+			}
+			if report.Checked != 5 || report.Verified != 2 || report.Score != 0.4 {
+				t.Errorf("unexpected report: %+v", report)
+			}
+		})
+	}
+}
+
+func TestVerifyGroundingClaims(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, "cmd/server/main.go", "package main\n\nimport \"net/http\"\n\nconst DefaultPort = 8080\n\nfunc main() { http.ListenAndServe(\"\", nil) }\n")
+	writeTestFile(t, root, "web/app.py", "class Handler:\n    def handle(self):\n        pass\n")
+	index, err := BuildSymbolIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line    string
+		checked int
+		ok      bool
+	}{
+		{line: "Entry point: cmd/server/main.go", checked: 1, ok: true},
+		{line: "Entry point: `server/main.go`", checked: 1, ok: true},
+		{line: "Config lives in `config/settings.yaml`", checked: 1},
+		{line: "The port is `DefaultPort`", checked: 1, ok: true},
+		{line: "Requests go to `Handler.handle()`", checked: 1, ok: true},
+		{line: "Calls `http.ListenAndServe`", checked: 0},
+		{line: "Uses `ServerConfig` for settings", checked: 1},
+		{line: "Returns `nil` or `true`", checked: 0},
+		{line: "Run `go build ./...` or `make test`", checked: 0},
+		{line: "Docs at https://example.com/docs/index.html", checked: 0},
+	}
+	for _, tt := range tests {
+		_, report := VerifyGrounding(tt.line, index, GroundingAnnotate)
+		if report.Checked != tt.checked || (tt.checked > 0 && (report.Verified == tt.checked) != tt.ok) {
+			t.Errorf("%q: unexpected report %+v", tt.line, report)
+		}
+	}
+}
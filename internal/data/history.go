@@ -0,0 +1,444 @@
+// internal/data/history.go
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/internal/prompts"
+)
+
+// OwnershipPageID 是所有权与活跃度页面的ID
+const OwnershipPageID = "ownership-activity"
+
+const (
+	defaultHistoryMaxCommits = 5000 // 默认最多分析的提交数
+	historyDirDepth          = 2    // 目录统计的最大层级
+	historyTopContributors   = 3    // 每个目录保留的主要贡献者数量
+	historyHotFiles          = 10   // 热点文件数量
+	historyMaxDirectories    = 30   // 返回的目录数量上限
+)
+
+// 用于分隔 git log 输出中记录和字段的控制字符
+const (
+	logRecordSep = "\x1e"
+	logFieldSep  = "\x1f"
+)
+
+// historyCache 缓存每个仓库目录最近一次的完整历史分析，以分析时的提交区分
+var historyCache = struct {
+	sync.Mutex
+	entries map[string]cachedHistory
+}{entries: make(map[string]cachedHistory)}
+
+type cachedHistory struct {
+	commit  string
+	history *models.RepoHistory
+}
+
+// CachedHistory 返回仓库当前提交的历史分析（使用默认提交上限）
+// 同一提交只分析一次，索引和一次 Wiki 生成中的多个步骤共享同一个结果；返回值不应被修改
+func CachedHistory(repoPath string) (*models.RepoHistory, error) {
+	commit, err := HeadCommit(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	historyCache.Lock()
+	entry, ok := historyCache.entries[repoPath]
+	historyCache.Unlock()
+	if ok && entry.commit == commit {
+		return entry.history, nil
+	}
+
+	history, err := AnalyzeHistory(repoPath, 0)
+	if err != nil {
+		return nil, err
+	}
+	historyCache.Lock()
+	historyCache.entries[repoPath] = cachedHistory{commit: commit, history: history}
+	historyCache.Unlock()
+	return history, nil
+}
+
+// AnalyzeHistory 基于 git log 和 git blame 分析仓库的变更历史
+// maxCommits <= 0 时使用默认上限
+func AnalyzeHistory(repoPath string, maxCommits int) (*models.RepoHistory, error) {
+	if maxCommits <= 0 {
+		maxCommits = defaultHistoryMaxCommits
+	}
+
+	output, err := runGit(repoPath, "log", "--no-merges", "--numstat", "-n", strconv.Itoa(maxCommits),
+		"--format="+logRecordSep+"%H"+logFieldSep+"%an"+logFieldSep+"%ae"+logFieldSep+"%aI")
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.RepoHistory{
+		Files: make(map[string]*models.FileActivity),
+	}
+	contributors := make(map[string]*models.ContributorStat)
+	dirs := make(map[string]*dirAccumulator)
+
+	for _, record := range strings.Split(string(output), logRecordSep) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		lines := strings.Split(record, "\n")
+		fields := strings.Split(lines[0], logFieldSep)
+		if len(fields) < 4 {
+			continue
+		}
+		sha, author, email := fields[0], fields[1], fields[2]
+		date, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			continue
+		}
+
+		// git log 按时间倒序输出，第一条记录即最新提交
+		history.CommitCount++
+		if history.LastCommit.IsZero() {
+			history.LastCommit = date
+		}
+		history.FirstCommit = date
+
+		contributor := contributors[email]
+		if contributor == nil {
+			contributor = &models.ContributorStat{Name: author, Email: email}
+			contributors[email] = contributor
+		}
+		contributor.Commits++
+
+		touchedDirs := make(map[string]bool)
+		for _, line := range lines[1:] {
+			added, deleted, filePath, ok := parseNumstat(line)
+			if !ok {
+				continue
+			}
+			contributor.LinesAdded += added
+			contributor.LinesDeleted += deleted
+
+			file := history.Files[filePath]
+			if file == nil {
+				file = &models.FileActivity{
+					Path:         filePath,
+					LastAuthor:   author,
+					LastCommit:   sha,
+					LastModified: date,
+				}
+				history.Files[filePath] = file
+			}
+			file.Commits++
+			file.Churn += added + deleted
+
+			for _, dir := range parentDirs(filePath, historyDirDepth) {
+				acc := dirs[dir]
+				if acc == nil {
+					acc = &dirAccumulator{
+						activity:     models.DirectoryActivity{Path: dir, LastModified: date},
+						contributors: make(map[string]*models.ContributorStat),
+					}
+					dirs[dir] = acc
+				}
+				acc.activity.Churn += added + deleted
+				stat := acc.contributors[email]
+				if stat == nil {
+					stat = &models.ContributorStat{Name: author, Email: email}
+					acc.contributors[email] = stat
+				}
+				stat.LinesAdded += added
+				stat.LinesDeleted += deleted
+				if !touchedDirs[dir] {
+					touchedDirs[dir] = true
+					acc.activity.Commits++
+					stat.Commits++
+				}
+			}
+		}
+	}
+
+	history.TopContributors = sortContributors(contributors, 10)
+
+	for _, acc := range dirs {
+		acc.activity.TopContributors = sortContributors(acc.contributors, historyTopContributors)
+		history.Directories = append(history.Directories, acc.activity)
+	}
+	sort.Slice(history.Directories, func(i, j int) bool {
+		a, b := history.Directories[i], history.Directories[j]
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.Path < b.Path
+	})
+	if len(history.Directories) > historyMaxDirectories {
+		history.Directories = history.Directories[:historyMaxDirectories]
+	}
+
+	history.HotFiles = hotFiles(repoPath, history.Files, historyHotFiles)
+
+	return history, nil
+}
+
+// dirAccumulator 在遍历提交时累计目录统计
+type dirAccumulator struct {
+	activity     models.DirectoryActivity
+	contributors map[string]*models.ContributorStat
+}
+
+// parseNumstat 解析 git log --numstat 的一行输出
+func parseNumstat(line string) (added, deleted int, filePath string, ok bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", false
+	}
+	// 二进制文件的行数为 "-"
+	added, _ = strconv.Atoi(parts[0])
+	deleted, _ = strconv.Atoi(parts[1])
+	filePath = normalizeRenamePath(parts[2])
+	return added, deleted, filePath, filePath != ""
+}
+
+// normalizeRenamePath 将重命名格式（a => b 或 dir/{a => b}/file）转换为新路径
+func normalizeRenamePath(p string) string {
+	if !strings.Contains(p, " => ") {
+		return p
+	}
+	if open := strings.Index(p, "{"); open >= 0 {
+		if end := strings.Index(p[open:], "}"); end >= 0 {
+			inner := p[open+1 : open+end]
+			parts := strings.SplitN(inner, " => ", 2)
+			if len(parts) == 2 {
+				return path.Clean(p[:open] + parts[1] + p[open+end+1:])
+			}
+		}
+	}
+	parts := strings.SplitN(p, " => ", 2)
+	return parts[1]
+}
+
+// parentDirs 返回文件在指定深度以内的所有父目录，根目录下的文件归入 "."
+func parentDirs(filePath string, depth int) []string {
+	parts := strings.Split(filePath, "/")
+	if len(parts) == 1 {
+		return []string{"."}
+	}
+	var dirs []string
+	for i := 1; i < len(parts) && i <= depth; i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/"))
+	}
+	return dirs
+}
+
+// sortContributors 按提交数和变更行数排序并截取前 limit 个
+func sortContributors(stats map[string]*models.ContributorStat, limit int) []models.ContributorStat {
+	result := make([]models.ContributorStat, 0, len(stats))
+	for _, stat := range stats {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Commits != result[j].Commits {
+			return result[i].Commits > result[j].Commits
+		}
+		ci := result[i].LinesAdded + result[i].LinesDeleted
+		cj := result[j].LinesAdded + result[j].LinesDeleted
+		if ci != cj {
+			return ci > cj
+		}
+		return result[i].Email < result[j].Email
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// hotFiles 选出提交最频繁的文件并计算其 blame 所有权
+func hotFiles(repoPath string, files map[string]*models.FileActivity, limit int) []models.FileActivity {
+	candidates := make([]*models.FileActivity, 0, len(files))
+	for _, file := range files {
+		candidates = append(candidates, file)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		if a.Churn != b.Churn {
+			return a.Churn > b.Churn
+		}
+		return a.Path < b.Path
+	})
+
+	var result []models.FileActivity
+	for _, file := range candidates {
+		if len(result) >= limit {
+			break
+		}
+		owners, err := blameOwnership(repoPath, file.Path)
+		if err != nil {
+			// 文件可能已被删除，不计入热点文件
+			continue
+		}
+		file.Owners = owners
+		result = append(result, *file)
+	}
+	return result
+}
+
+// blameOwnership 使用 git blame 统计文件当前内容中每个作者的行数
+func blameOwnership(repoPath, filePath string) ([]models.OwnershipShare, error) {
+	output, err := runGit(repoPath, "blame", "--line-porcelain", "-w", "HEAD", "--", filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	total := 0
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "author ") {
+			counts[strings.TrimPrefix(line, "author ")]++
+			total++
+		}
+	}
+	if total == 0 {
+		return []models.OwnershipShare{}, nil
+	}
+
+	owners := make([]models.OwnershipShare, 0, len(counts))
+	for author, lines := range counts {
+		owners = append(owners, models.OwnershipShare{
+			Author: author,
+			Lines:  lines,
+			Share:  float64(lines) / float64(total),
+		})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].Lines != owners[j].Lines {
+			return owners[i].Lines > owners[j].Lines
+		}
+		return owners[i].Author < owners[j].Author
+	})
+	if len(owners) > historyTopContributors {
+		owners = owners[:historyTopContributors]
+	}
+	return owners, nil
+}
+
+// BuildOwnershipPage 根据历史分析结果生成"所有权与活跃度"Wiki页面，标题和固定文字通过 text 按输出语言渲染
+func BuildOwnershipPage(history *models.RepoHistory, text PageText) models.WikiPage {
+	title := text("activity_section", prompts.Data{})
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("# %s\n\n", title))
+	content.WriteString(text("activity_intro", prompts.Data{
+		Count: history.CommitCount,
+		From:  formatHistoryDate(history.FirstCommit),
+		To:    formatHistoryDate(history.LastCommit),
+	}) + "\n\n")
+
+	content.WriteString(fmt.Sprintf("## %s\n\n", text("activity_contributors", prompts.Data{})))
+	content.WriteString(text("activity_contributor_columns", prompts.Data{}) + "\n| --- | --- | --- | --- |\n")
+	for _, c := range history.TopContributors {
+		content.WriteString(fmt.Sprintf("| %s | %d | %d | %d |\n",
+			escapeTableCell(c.Name), c.Commits, c.LinesAdded, c.LinesDeleted))
+	}
+
+	content.WriteString(fmt.Sprintf("\n## %s\n\n", text("activity_directories", prompts.Data{})))
+	content.WriteString(text("activity_directory_columns", prompts.Data{}) + "\n| --- | --- | --- | --- | --- |\n")
+	for _, d := range history.Directories {
+		content.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s | %s |\n",
+			d.Path, d.Commits, d.Churn, formatHistoryDate(d.LastModified), contributorNames(d.TopContributors)))
+	}
+
+	content.WriteString(fmt.Sprintf("\n## %s\n\n", text("activity_hot_files", prompts.Data{})))
+	content.WriteString(text("activity_hot_file_columns", prompts.Data{}) + "\n| --- | --- | --- | --- | --- | --- |\n")
+	filePaths := make([]string, 0, len(history.HotFiles))
+	for _, f := range history.HotFiles {
+		filePaths = append(filePaths, f.Path)
+		var owners []string
+		for _, o := range f.Owners {
+			owners = append(owners, fmt.Sprintf("%s (%.0f%%)", o.Author, o.Share*100))
+		}
+		content.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s | %s | %s |\n",
+			f.Path, f.Commits, f.Churn, formatHistoryDate(f.LastModified),
+			escapeTableCell(f.LastAuthor), escapeTableCell(strings.Join(owners, ", "))))
+	}
+
+	return models.WikiPage{
+		ID:           OwnershipPageID,
+		Title:        title,
+		Content:      content.String(),
+		FilePaths:    filePaths,
+		Importance:   "medium",
+		RelatedPages: []string{"overview", "architecture"},
+	}
+}
+
+// contributorNames 将贡献者列表格式化为逗号分隔的名称
+func contributorNames(stats []models.ContributorStat) string {
+	names := make([]string, 0, len(stats))
+	for _, s := range stats {
+		names = append(names, s.Name)
+	}
+	return escapeTableCell(strings.Join(names, ", "))
+}
+
+// formatHistoryDate 格式化日期，零值显示为 "-"
+func formatHistoryDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+// annotateWithHistory 将文件的最后提交信息写入文档元数据
+func annotateWithHistory(documents []models.Document, history *models.RepoHistory) {
+	if history == nil {
+		return
+	}
+	for i := range documents {
+		filePath, _ := documents[i].MetaData["file_path"].(string)
+		file, ok := history.Files[strings.ReplaceAll(filePath, "\\", "/")]
+		if !ok {
+			continue
+		}
+		documents[i].MetaData["last_author"] = file.LastAuthor
+		documents[i].MetaData["last_commit"] = file.LastCommit
+		documents[i].MetaData["last_commit_date"] = file.LastModified.Format(time.RFC3339)
+	}
+}
+
+// runGit 在仓库目录中执行 git 命令并返回标准输出
+func runGit(repoPath string, args ...string) ([]byte, error) {
+	return runGitEnv(repoPath, nil, args...)
+}
+
+// runGitEnv 在仓库目录中执行 git 命令，env 中的环境变量追加到当前进程的环境变量之后
+func runGitEnv(repoPath string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("执行 git %s 失败: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
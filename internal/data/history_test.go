@@ -0,0 +1,98 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+func TestAnalyzeHistory(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.commit("Alice", "2024-01-01T00:00:00Z", "first", map[string]string{
+		"main.go":    "package main\n",
+		"pkg/a/a.go": "package a\n",
+	})
+	repo.commit("Bob", "2024-02-01T00:00:00Z", "second", map[string]string{"pkg/a/a.go": "package a\n\nvar b = 1\n"})
+	last := repo.commit("Alice", "2024-03-01T00:00:00Z", "third", map[string]string{"pkg/a/a.go": "package a\n\nvar b = 2\n"})
+
+	history, err := AnalyzeHistory(repo.path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.CommitCount != 3 || !history.FirstCommit.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+		!history.LastCommit.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected summary: %d %v %v", history.CommitCount, history.FirstCommit, history.LastCommit)
+	}
+	if top := history.TopContributors; len(top) != 2 || top[0].Name != "Alice" || top[0].Commits != 2 ||
+		top[0].LinesAdded != 3 || top[0].LinesDeleted != 1 || top[1].Name != "Bob" {
+		t.Errorf("unexpected contributors: %+v", top)
+	}
+
+	file := history.Files["pkg/a/a.go"]
+	if file == nil || file.Commits != 3 || file.Churn != 5 || file.LastAuthor != "Alice" || file.LastCommit != last {
+		t.Fatalf("unexpected file activity: %+v", file)
+	}
+
+	var dirs []string
+	for _, dir := range history.Directories {
+		dirs = append(dirs, dir.Path)
+	}
+	if len(dirs) != 3 || dirs[0] != "pkg" || dirs[1] != "pkg/a" || dirs[2] != "." {
+		t.Errorf("unexpected directories: %v", dirs)
+	}
+	if pkg := history.Directories[0]; pkg.Commits != 3 || pkg.Churn != 5 || pkg.TopContributors[0].Name != "Alice" {
+		t.Errorf("unexpected pkg activity: %+v", pkg)
+	}
+
+	if len(history.HotFiles) != 2 || history.HotFiles[0].Path != "pkg/a/a.go" {
+		t.Fatalf("unexpected hot files: %+v", history.HotFiles)
+	}
+	if owners := history.HotFiles[0].Owners; len(owners) != 2 || owners[0].Author != "Alice" || owners[0].Lines != 2 || owners[1].Lines != 1 {
+		t.Errorf("unexpected blame ownership: %+v", owners)
+	}
+
+	// 提交上限只统计最新的提交
+	if limited, err := AnalyzeHistory(repo.path, 1); err != nil || limited.CommitCount != 1 || limited.Files["main.go"] != nil {
+		t.Errorf("unexpected limited history: %v %+v", err, limited)
+	}
+}
+
+func TestNormalizeRenamePath(t *testing.T) {
+	cases := map[string]string{
+		"main.go":                   "main.go",
+		"old.go => new.go":          "new.go",
+		"pkg/{old => new}/file.go":  "pkg/new/file.go",
+		"pkg/{ => sub}/file.go":     "pkg/sub/file.go",
+		"{pkg => internal}/file.go": "internal/file.go",
+	}
+	for in, want := range cases {
+		if got := normalizeRenamePath(in); got != want {
+			t.Errorf("normalizeRenamePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAnnotateWithHistory(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	history := &models.RepoHistory{Files: map[string]*models.FileActivity{
+		"pkg/a/a.go": {Path: "pkg/a/a.go", LastAuthor: "Alice", LastCommit: "abc", LastModified: modified},
+	}}
+	documents := []models.Document{
+		{MetaData: map[string]interface{}{"file_path": `pkg\a\a.go`}},
+		{MetaData: map[string]interface{}{"file_path": "missing.go"}},
+		{MetaData: map[string]interface{}{}},
+	}
+	annotateWithHistory(documents, history)
+	if meta := documents[0].MetaData; meta["last_author"] != "Alice" || meta["last_commit"] != "abc" || meta["last_commit_date"] != "2024-03-01T00:00:00Z" {
+		t.Errorf("document not annotated: %+v", meta)
+	}
+	for _, doc := range documents[1:] {
+		if _, ok := doc.MetaData["last_author"]; ok {
+			t.Errorf("unknown file annotated: %+v", doc.MetaData)
+		}
+	}
+
+	// 没有历史时不做任何修改
+	annotateWithHistory(documents[1:], nil)
+}
@@ -4,9 +4,13 @@ package data
 import (
 	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/deepwiki-go/internal/config"
 	"github.com/deepwiki-go/pkg/utils"
@@ -173,3 +177,142 @@ func addToStructure(structure map[string]interface{}, pathParts []string) {
 		addToStructure(subDir, pathParts[1:])
 	}
 }
+
+// HeadCommit 返回仓库当前检出的提交 SHA
+func HeadCommit(repoPath string) (string, error) {
+	output, err := runGit(repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CurrentBranch 返回当前检出的分支名，处于分离头指针状态时返回空字符串
+func CurrentBranch(repoPath string) string {
+	output, err := runGit(repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
+
+// repoLocks 串行化同一个克隆上会修改引用或工作区的 git 操作，键为克隆路径
+var repoLocks sync.Map
+
+// lockRepo 锁定克隆，返回解锁函数
+func lockRepo(repoPath string) func() {
+	value, _ := repoLocks.LoadOrStore(repoPath, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// maxWorktrees 是每个克隆保留的工作树数量，超出时删除最久未使用的工作树
+const maxWorktrees = 8
+
+// CheckoutWorktree 在独立的工作树中检出指定的分支、标签或提交，返回工作树路径和提交 SHA
+// 共享的克隆保持在原来的分支上，因此不同任务可以同时使用同一仓库的不同版本；
+// 工作树按提交存放在克隆旁边的 <克隆目录>.worktrees/<提交> 中，同一提交只创建一次，之后直接复用，
+// 每个克隆只保留最近使用的 maxWorktrees 个工作树。
+// 本地无法解析引用时会先从远程获取
+func CheckoutWorktree(repoPath, ref string) (string, string, error) {
+	unlock := lockRepo(repoPath)
+	defer unlock()
+
+	commit, err := ResolveCommit(repoPath, ref)
+	if err != nil {
+		if fetchErr := FetchRefs(repoPath); fetchErr != nil {
+			return "", "", err
+		}
+		if commit, err = ResolveCommit(repoPath, ref); err != nil {
+			return "", "", err
+		}
+	}
+
+	worktree := filepath.Join(filepath.Clean(repoPath)+".worktrees", commit)
+	if head, err := HeadCommit(worktree); err == nil && head == commit {
+		// 更新修改时间，记录最近一次使用
+		now := time.Now()
+		if err := os.Chtimes(worktree, now, now); err != nil {
+			log.Printf("更新工作树时间失败: %v", err)
+		}
+		return worktree, commit, nil
+	}
+
+	// 清理中断留下的目录和失效的工作树记录后重新创建
+	if err := os.RemoveAll(worktree); err != nil {
+		return "", "", err
+	}
+	if _, err := runGit(repoPath, "worktree", "prune"); err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(worktree), 0755); err != nil {
+		return "", "", err
+	}
+	if _, err := runGit(repoPath, "worktree", "add", "--quiet", "--detach", worktree, commit); err != nil {
+		return "", "", err
+	}
+	if err := pruneWorktrees(repoPath, worktree, maxWorktrees); err != nil {
+		log.Printf("清理工作树失败: %v", err)
+	}
+	return worktree, commit, nil
+}
+
+// pruneWorktrees 按修改时间删除最久未使用的工作树，连同 keep 在内只保留 limit 个，调用方需持有仓库锁
+func pruneWorktrees(repoPath, keep string, limit int) error {
+	entries, err := os.ReadDir(filepath.Clean(repoPath) + ".worktrees")
+	if err != nil {
+		return err
+	}
+	type worktreeEntry struct {
+		path string
+		used time.Time
+	}
+	var worktrees []worktreeEntry
+	for _, entry := range entries {
+		path := filepath.Join(filepath.Clean(repoPath)+".worktrees", entry.Name())
+		if !entry.IsDir() || path == keep {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		worktrees = append(worktrees, worktreeEntry{path: path, used: info.ModTime()})
+	}
+	if len(worktrees) < limit {
+		return nil
+	}
+
+	sort.Slice(worktrees, func(i, j int) bool { return worktrees[i].used.After(worktrees[j].used) })
+	for _, worktree := range worktrees[limit-1:] {
+		if err := os.RemoveAll(worktree.path); err != nil {
+			return err
+		}
+	}
+	_, err = runGit(repoPath, "worktree", "prune")
+	return err
+}
+
+// UpdateRepository 从远程获取最新提交，并以快进方式更新检出的分支和工作区
+func UpdateRepository(repoPath string) error {
+	unlock := lockRepo(repoPath)
+	defer unlock()
+
+	if err := FetchRefs(repoPath); err != nil {
+		return err
+	}
+	// 分离头指针状态（例如旧版本直接在克隆中检出过标签或提交）时切回远程的默认分支
+	if CurrentBranch(repoPath) == "" {
+		branch := DefaultBranch(repoPath)
+		if _, err := runGit(repoPath, "checkout", "--quiet", branch); err != nil {
+			return fmt.Errorf("切换到分支 %s 失败: %v", branch, err)
+		}
+	}
+	_, err := runGit(repoPath, "merge", "--ff-only", "--quiet", "@{upstream}")
+	return err
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRepo 是测试用的本地 git 仓库
+type testRepo struct {
+	t    *testing.T
+	path string
+}
+
+// newTestRepo 在临时目录中创建一个 main 分支上的空仓库
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := &testRepo{t: t, path: filepath.Join(t.TempDir(), "repo")}
+	repo.git(filepath.Dir(repo.path), "init", "--quiet", "--initial-branch=main", repo.path)
+	return repo
+}
+
+// git 在 dir 中执行 git 命令，作者和提交者使用固定的身份
+func (r *testRepo) git(dir string, args ...string) string {
+	r.t.Helper()
+	output, err := runGitEnv(dir, authorEnv(GitCommitOptions{}), args...)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// commit 写入文件并以指定作者和日期提交，返回提交 SHA；内容为空字符串时删除文件
+func (r *testRepo) commit(author, date, message string, files map[string]string) string {
+	r.t.Helper()
+	for name, content := range files {
+		if content == "" {
+			r.git(r.path, "rm", "--quiet", name)
+			continue
+		}
+		writeTestFile(r.t, r.path, name, content)
+		r.git(r.path, "add", name)
+	}
+	args := []string{"-c", "user.name=" + author, "-c", "user.email=" + strings.ToLower(author) + "@example.com",
+		"commit", "--quiet", "-m", message}
+	var env []string
+	if date != "" {
+		args = append(args, "--date", date)
+		env = []string{"GIT_COMMITTER_DATE=" + date}
+	}
+	if _, err := runGitEnv(r.path, env, args...); err != nil {
+		r.t.Fatal(err)
+	}
+	return r.git(r.path, "rev-parse", "HEAD")
+}
+
+func TestCheckoutWorktree(t *testing.T) {
+	repo := newTestRepo(t)
+	first := repo.commit("Alice", "", "first", map[string]string{"main.go": "package main\n"})
+	repo.git(repo.path, "tag", "v1")
+	second := repo.commit("Alice", "", "second", map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	// 并发检出不同版本，共享克隆保持在原来的分支上
+	var wg sync.WaitGroup
+	paths := make([]string, 2)
+	commits := make([]string, 2)
+	errs := make([]error, 2)
+	for i, ref := range []string{"v1", second} {
+		wg.Add(1)
+		go func(i int, ref string) {
+			defer wg.Done()
+			paths[i], commits[i], errs[i] = CheckoutWorktree(repo.path, ref)
+		}(i, ref)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if commits[0] != first || commits[1] != second || paths[0] == paths[1] {
+		t.Fatalf("unexpected worktrees: %v %v", paths, commits)
+	}
+	if head, _ := HeadCommit(paths[0]); head != first {
+		t.Errorf("worktree for v1 is at %s", head)
+	}
+	if CurrentBranch(repo.path) != "main" {
+		t.Errorf("shared clone should stay on main")
+	}
+	if head, _ := HeadCommit(repo.path); head != second {
+		t.Errorf("shared clone moved to %s", head)
+	}
+
+	// 同一提交的工作树被复用
+	again, _, err := CheckoutWorktree(repo.path, first)
+	if err != nil || again != paths[0] {
+		t.Errorf("expected to reuse %s, got %s (%v)", paths[0], again, err)
+	}
+	if _, _, err := CheckoutWorktree(repo.path, "missing"); err == nil {
+		t.Error("unknown refs should be rejected")
+	}
+}
+
+func TestPruneWorktrees(t *testing.T) {
+	repo := newTestRepo(t)
+	var worktrees []string
+	for i, name := range []string{"a.go", "b.go", "c.go"} {
+		commit := repo.commit("Alice", "", name, map[string]string{name: "package main\n"})
+		worktree, _, err := CheckoutWorktree(repo.path, commit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 显式设置使用时间，避免依赖文件系统的时间精度
+		used := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(worktree, used, used)
+		worktrees = append(worktrees, worktree)
+	}
+
+	// 复用最早的工作树后它成为最近使用的，随后清理删除第二个
+	if _, _, err := CheckoutWorktree(repo.path, filepath.Base(worktrees[0])); err != nil {
+		t.Fatal(err)
+	}
+	if err := pruneWorktrees(repo.path, worktrees[2], 2); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false, true} {
+		if _, err := os.Stat(worktrees[i]); (err == nil) != want {
+			t.Errorf("worktree %d exists = %v, want %v", i, err == nil, want)
+		}
+	}
+	if list := repo.git(repo.path, "worktree", "list"); strings.Contains(list, filepath.Base(worktrees[1])) {
+		t.Errorf("removed worktree still registered:\n%s", list)
+	}
+}
+
+func TestUpdateRepositoryRestoresBranch(t *testing.T) {
+	origin := newTestRepo(t)
+	first := origin.commit("Alice", "", "first", map[string]string{"main.go": "package main\n"})
+	clone := filepath.Join(t.TempDir(), "clone")
+	origin.git(filepath.Dir(clone), "clone", "--quiet", origin.path, clone)
+
+	// 克隆处于分离头指针状态时，更新后回到默认分支并快进到远程的最新提交
+	origin.git(clone, "checkout", "--quiet", "--detach", first)
+	second := origin.commit("Alice", "", "second", map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	if err := UpdateRepository(clone); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentBranch(clone) != "main" {
+		t.Errorf("expected the clone to be back on main")
+	}
+	if head, _ := HeadCommit(clone); head != second {
+		t.Errorf("clone not fast-forwarded: %s", head)
+	}
+}
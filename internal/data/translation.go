@@ -0,0 +1,137 @@
+// internal/data/translation.go
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 翻译时需要原样保留的链接和图片、裸 URL 与引用编号，行内代码使用 inlineCodePattern
+var (
+	markdownLinkRegexp = regexp.MustCompile(`!?\[(?:[^\[\]\n]|\[[^\[\]\n]*\])*\]\([^()\s]*\)`)
+	bareURLPattern     = regexp.MustCompile(`https?://[^\s)>\]]+`)
+	citationMarker     = regexp.MustCompile(`\[\d{1,3}\]`)
+	placeholderPattern = regexp.MustCompile(`⟦(\d+)⟧`)
+)
+
+// MaskMarkdown 将 Markdown 中不应翻译的内容替换为 ⟦n⟧ 形式的占位符
+// 代码块（包括 Mermaid 图表）、行内代码、链接、URL 和引用编号会被保留，返回替换后的文本和被保留的原文
+func MaskMarkdown(content string) (string, []string) {
+	var kept []string
+	keep := func(s string) string {
+		kept = append(kept, s)
+		return fmt.Sprintf("⟦%d⟧", len(kept)-1)
+	}
+
+	var masked strings.Builder
+	lines := strings.SplitAfter(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		if !isFenceLine(lines[i]) {
+			line := lines[i]
+			for _, pattern := range []*regexp.Regexp{inlineCodePattern, markdownLinkRegexp, bareURLPattern, citationMarker} {
+				line = pattern.ReplaceAllStringFunc(line, keep)
+			}
+			masked.WriteString(line)
+			continue
+		}
+
+		// 整个代码块作为一个占位符，未闭合的代码块保留到文末
+		end := i + 1
+		for end < len(lines) && !isFenceLine(lines[end]) {
+			end++
+		}
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		block := strings.Join(lines[i:end+1], "")
+		trailing := ""
+		if strings.HasSuffix(block, "\n") {
+			block, trailing = block[:len(block)-1], "\n"
+		}
+		masked.WriteString(keep(block) + trailing)
+		i = end
+	}
+	return masked.String(), kept
+}
+
+// UnmaskMarkdown 将占位符还原为原文，占位符缺失、重复或编号无效时返回错误
+func UnmaskMarkdown(text string, kept []string) (string, error) {
+	counts := make(map[int]int)
+	var unknown []string
+	restored := placeholderPattern.ReplaceAllStringFunc(text, func(m string) string {
+		n, _ := strconv.Atoi(placeholderPattern.FindStringSubmatch(m)[1])
+		if n >= len(kept) {
+			unknown = append(unknown, m)
+			return m
+		}
+		counts[n]++
+		return kept[n]
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("译文中有未知的占位符: %s", strings.Join(unknown, ", "))
+	}
+
+	var missing, repeated []string
+	for n := range kept {
+		switch {
+		case counts[n] == 0:
+			missing = append(missing, fmt.Sprintf("⟦%d⟧", n))
+		case counts[n] > 1:
+			repeated = append(repeated, fmt.Sprintf("⟦%d⟧", n))
+		}
+	}
+	if len(missing) > 0 || len(repeated) > 0 {
+		return "", fmt.Errorf("译文中的占位符不匹配，缺失: [%s]，重复: [%s]", strings.Join(missing, ", "), strings.Join(repeated, ", "))
+	}
+	return restored, nil
+}
+
+// SamePlaceholders 检查两段文本包含的占位符是否完全相同（不计顺序）
+func SamePlaceholders(a, b string) bool {
+	pa := placeholderPattern.FindAllString(a, -1)
+	pb := placeholderPattern.FindAllString(b, -1)
+	if len(pa) != len(pb) {
+		return false
+	}
+	sort.Strings(pa)
+	sort.Strings(pb)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasTranslatableText 判断文本在去掉占位符后是否还有需要翻译的内容
+func HasTranslatableText(text string) bool {
+	return strings.TrimSpace(placeholderPattern.ReplaceAllString(text, "")) != ""
+}
+
+// SplitMarkdown 按空行将文本切分为不超过 maxChars 的片段，单个段落超长时单独成为一段
+func SplitMarkdown(text string, maxChars int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(para)+2 > maxChars {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(para)
+	}
+	if current.Len() > 0 || len(chunks) == 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// isFenceLine 判断一行是否是代码块的起止标记
+func isFenceLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
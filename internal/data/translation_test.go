@@ -0,0 +1,42 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskAndUnmaskMarkdown(t *testing.T) {
+	content := "# 概述\n\n入口是 `main.go`，参见 [架构](#architecture) 和 [[1]](https://example.com/a#L1-L3)。未链接引用 [2]。\n\n```mermaid\ngraph TD\n  A[\"x\"] --> B\n```\n\n访问 https://example.com/docs 获取更多信息。\n"
+	masked, kept := MaskMarkdown(content)
+
+	for _, verbatim := range []string{"`main.go`", "[架构](#architecture)", "[[1]](https://example.com/a#L1-L3)", "[2]", "https://example.com/docs"} {
+		if strings.Contains(masked, verbatim) {
+			t.Errorf("%q should be masked:\n%s", verbatim, masked)
+		}
+	}
+	if strings.Contains(masked, "graph TD") || len(kept) != 6 {
+		t.Fatalf("unexpected masking (%d kept):\n%s", len(kept), masked)
+	}
+
+	// 模拟翻译：正文改变，占位符原样保留
+	translated := strings.NewReplacer("概述", "Overview", "入口是", "The entry point is", "访问", "Visit").Replace(masked)
+	restored, err := UnmaskMarkdown(translated, kept)
+	if err != nil {
+		t.Fatalf("unmask: %v", err)
+	}
+	if !strings.Contains(restored, "The entry point is `main.go`") || !strings.Contains(restored, "```mermaid\ngraph TD\n  A[\"x\"] --> B\n```") {
+		t.Errorf("unexpected restored text:\n%s", restored)
+	}
+
+	if _, err := UnmaskMarkdown(strings.Replace(translated, "⟦0⟧", "", 1), kept); err == nil {
+		t.Error("missing placeholder should be rejected")
+	}
+	if !SamePlaceholders("a ⟦1⟧ b ⟦0⟧", "⟦0⟧ c ⟦1⟧") || SamePlaceholders("⟦0⟧", "⟦0⟧ ⟦0⟧") {
+		t.Error("unexpected placeholder comparison")
+	}
+
+	chunks := SplitMarkdown("aaaa\n\nbbbb\n\ncccc", 10)
+	if len(chunks) != 2 || chunks[0] != "aaaa\n\nbbbb" {
+		t.Errorf("unexpected chunks: %q", chunks)
+	}
+}
@@ -0,0 +1,251 @@
+// internal/data/wikistore.go
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/utils"
+)
+
+// ErrWikiNotFound 表示请求的 Wiki 或页面不存在
+var ErrWikiNotFound = errors.New("Wiki 不存在")
+
+// WikiStore 将生成的 Wiki 持久化到本地磁盘
+// 目录结构为 <basePath>/<仓库键>/<引用>-<短提交>[-<语言>].json，同一仓库、引用、提交和语言只保留一份
+type WikiStore struct {
+	mu       sync.RWMutex
+	basePath string
+}
+
+// NewWikiStore 创建 Wiki 存储，未配置路径时使用默认根目录下的 wikis 目录
+func NewWikiStore(cfg *config.Config) *WikiStore {
+	basePath := cfg.Wiki.StorePath
+	if basePath == "" {
+		basePath = filepath.Join(utils.GetDefaultRootPath(), "wikis")
+	}
+	return &WikiStore{basePath: basePath}
+}
+
+// RepoKey 返回仓库URL在存储中使用的键
+func RepoKey(repoURL string) string {
+	return createRepoDirName(repoURL)
+}
+
+// Save 保存 Wiki，缺少的 ID、仓库键和生成时间会被自动填充
+func (s *WikiStore) Save(wiki *models.Wiki) error {
+	if wiki.RepoURL == "" || wiki.Commit == "" {
+		return errors.New("保存 Wiki 需要仓库URL和提交")
+	}
+	if wiki.RepoKey == "" {
+		wiki.RepoKey = RepoKey(wiki.RepoURL)
+	}
+	if wiki.ID == "" {
+		wiki.ID = WikiID(wiki.Ref, wiki.Commit, wiki.Language)
+	}
+	if wiki.GeneratedAt.IsZero() {
+		wiki.GeneratedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(wiki)
+}
+
+// Update 在写锁内读取、修改并保存一个 Wiki，fn 返回错误时不会保存
+func (s *WikiStore) Update(repoKey, id string, fn func(wiki *models.Wiki) error) (*models.Wiki, error) {
+	if !validStoreName(repoKey) || !validStoreName(id) {
+		return nil, ErrWikiNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wiki, err := s.load(filepath.Join(s.basePath, repoKey, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(wiki); err != nil {
+		return nil, err
+	}
+	if err := s.write(wiki); err != nil {
+		return nil, err
+	}
+	return wiki, nil
+}
+
+// write 将 Wiki 写入磁盘，调用方需持有写锁
+func (s *WikiStore) write(wiki *models.Wiki) error {
+	data, err := json.MarshalIndent(wiki, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 Wiki 失败: %v", err)
+	}
+
+	dir := filepath.Join(s.basePath, wiki.RepoKey)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建 Wiki 目录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免读取到写了一半的文件
+	path := filepath.Join(dir, wiki.ID+".json")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入 Wiki 失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入 Wiki 失败: %v", err)
+	}
+	return nil
+}
+
+// Get 读取指定仓库下的某个 Wiki
+func (s *WikiStore) Get(repoKey, id string) (*models.Wiki, error) {
+	if !validStoreName(repoKey) || !validStoreName(id) {
+		return nil, ErrWikiNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.load(filepath.Join(s.basePath, repoKey, id+".json"))
+}
+
+// Latest 返回仓库最近生成的 Wiki，ref、commit 或 language 非空时只在匹配的 Wiki 中查找
+func (s *WikiStore) Latest(repoKey, ref, commit, language string) (*models.Wiki, error) {
+	wikis, err := s.loadRepo(repoKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *models.Wiki
+	for _, wiki := range wikis {
+		if ref != "" && wiki.Ref != ref {
+			continue
+		}
+		if commit != "" && !strings.HasPrefix(wiki.Commit, commit) {
+			continue
+		}
+		if language != "" && wiki.Language != language {
+			continue
+		}
+		if latest == nil || wiki.GeneratedAt.After(latest.GeneratedAt) {
+			latest = wiki
+		}
+	}
+	if latest == nil {
+		return nil, ErrWikiNotFound
+	}
+	return latest, nil
+}
+
+// List 列出所有已保存 Wiki 的元数据，repoKey 非空时只列出该仓库，按生成时间倒序排列
+func (s *WikiStore) List(repoKey string) ([]models.WikiSummary, error) {
+	var repoKeys []string
+	if repoKey != "" {
+		repoKeys = []string{repoKey}
+	} else {
+		s.mu.RLock()
+		entries, err := os.ReadDir(s.basePath)
+		s.mu.RUnlock()
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("读取 Wiki 目录失败: %v", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				repoKeys = append(repoKeys, entry.Name())
+			}
+		}
+	}
+
+	summaries := []models.WikiSummary{}
+	for _, key := range repoKeys {
+		wikis, err := s.loadRepo(key)
+		if err != nil && !errors.Is(err, ErrWikiNotFound) {
+			return nil, err
+		}
+		for _, wiki := range wikis {
+			summaries = append(summaries, wiki.Summary())
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].GeneratedAt.After(summaries[j].GeneratedAt)
+	})
+	return summaries, nil
+}
+
+// loadRepo 读取某个仓库下的所有 Wiki
+func (s *WikiStore) loadRepo(repoKey string) ([]*models.Wiki, error) {
+	if !validStoreName(repoKey) {
+		return nil, ErrWikiNotFound
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dir := filepath.Join(s.basePath, repoKey)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrWikiNotFound
+		}
+		return nil, fmt.Errorf("读取 Wiki 目录失败: %v", err)
+	}
+
+	var wikis []*models.Wiki
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		wiki, err := s.load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		wikis = append(wikis, wiki)
+	}
+	return wikis, nil
+}
+
+// load 读取并解析单个 Wiki 文件
+func (s *WikiStore) load(path string) (*models.Wiki, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrWikiNotFound
+		}
+		return nil, fmt.Errorf("读取 Wiki 失败: %v", err)
+	}
+
+	var wiki models.Wiki
+	if err := json.Unmarshal(data, &wiki); err != nil {
+		return nil, fmt.Errorf("解析 Wiki %s 失败: %v", filepath.Base(path), err)
+	}
+	return &wiki, nil
+}
+
+// WikiID 由引用、提交和语言生成 Wiki ID，例如 main-1a2b3c4-en
+func WikiID(ref, commit, language string) string {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	replacer := strings.NewReplacer("/", "_", "\\", "_", ":", "_", " ", "_")
+	id := replacer.Replace(ref) + "-" + shortSHA(commit)
+	if language != "" {
+		id += "-" + language
+	}
+	return id
+}
+
+// validStoreName 检查名称能否安全地作为存储中的文件或目录名
+func validStoreName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
@@ -0,0 +1,99 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deepwiki-go/internal/config"
+	"github.com/deepwiki-go/internal/models"
+)
+
+func TestWikiStore(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Wiki.StorePath = t.TempDir()
+	store := NewWikiStore(cfg)
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	wikis := []*models.Wiki{
+		{RepoURL: "https://github.com/acme/demo", Ref: "main", Commit: "aaaaaaa1111111", GeneratedAt: base},
+		{RepoURL: "https://github.com/acme/demo", Ref: "main", Commit: "bbbbbbb2222222", GeneratedAt: base.Add(2 * time.Hour)},
+		{RepoURL: "https://github.com/acme/demo", Ref: "main", Commit: "bbbbbbb2222222", Language: "en", GeneratedAt: base.Add(3 * time.Hour)},
+		{RepoURL: "https://github.com/acme/demo", Ref: "release/v1", Commit: "ccccccc3333333", GeneratedAt: base.Add(time.Hour)},
+	}
+	for _, wiki := range wikis {
+		wiki.Pages = []models.WikiPage{{ID: "overview", Title: "Overview", Content: "x"}}
+		if err := store.Save(wiki); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	if wikis[0].ID != "main-aaaaaaa" || wikis[2].ID != "main-bbbbbbb-en" || wikis[3].ID != "release_v1-ccccccc" {
+		t.Errorf("unexpected ids: %s %s %s", wikis[0].ID, wikis[2].ID, wikis[3].ID)
+	}
+	if err := store.Save(&models.Wiki{RepoURL: "https://github.com/acme/demo"}); err == nil {
+		t.Error("wikis without a commit should be rejected")
+	}
+
+	// 重新打开存储后内容仍然存在
+	store = NewWikiStore(cfg)
+	stored, err := store.Get("github.com_acme_demo", "main-aaaaaaa")
+	if err != nil || stored.Commit != "aaaaaaa1111111" || len(stored.Pages) != 1 {
+		t.Fatalf("get: %v %+v", err, stored)
+	}
+	for _, id := range []string{"missing", "../github.com_acme_demo", "."} {
+		if _, err := store.Get("github.com_acme_demo", id); !errors.Is(err, ErrWikiNotFound) {
+			t.Errorf("%s: expected ErrWikiNotFound, got %v", id, err)
+		}
+	}
+
+	cases := []struct {
+		ref, commit, language string
+		want                  string
+	}{
+		{"", "", "", "main-bbbbbbb-en"},
+		{"main", "", "", "main-bbbbbbb-en"},
+		{"main", "aaaaaaa", "", "main-aaaaaaa"},
+		{"", "bbbbbbb", "", "main-bbbbbbb-en"},
+		{"release/v1", "", "", "release_v1-ccccccc"},
+		{"", "", "en", "main-bbbbbbb-en"},
+	}
+	for _, tc := range cases {
+		latest, err := store.Latest("github.com_acme_demo", tc.ref, tc.commit, tc.language)
+		if err != nil || latest.ID != tc.want {
+			t.Errorf("Latest(%q, %q, %q) = %v %v, want %s", tc.ref, tc.commit, tc.language, latest, err, tc.want)
+		}
+	}
+	if _, err := store.Latest("github.com_acme_demo", "dev", "", ""); !errors.Is(err, ErrWikiNotFound) {
+		t.Errorf("expected ErrWikiNotFound for an unknown ref, got %v", err)
+	}
+	if _, err := store.Latest("github.com_acme_other", "", "", ""); !errors.Is(err, ErrWikiNotFound) {
+		t.Errorf("expected ErrWikiNotFound for an unknown repo, got %v", err)
+	}
+
+	// 更新在锁内完成，回调返回错误时不保存
+	if _, err := store.Update("github.com_acme_demo", "main-aaaaaaa", func(wiki *models.Wiki) error {
+		wiki.Pages[0].Content = "discarded"
+		return errors.New("abort")
+	}); err == nil {
+		t.Error("expected the update error to be returned")
+	}
+	if _, err := store.Update("github.com_acme_demo", "main-aaaaaaa", func(wiki *models.Wiki) error {
+		wiki.Pages[0].Content = "updated"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.Get("github.com_acme_demo", "main-aaaaaaa"); stored.Pages[0].Content != "updated" {
+		t.Errorf("update not saved: %q", stored.Pages[0].Content)
+	}
+
+	summaries, err := store.List("")
+	if err != nil || len(summaries) != 4 {
+		t.Fatalf("list: %v %+v", err, summaries)
+	}
+	for i := 1; i < len(summaries); i++ {
+		if summaries[i].GeneratedAt.After(summaries[i-1].GeneratedAt) {
+			t.Errorf("summaries not sorted by generation time: %+v", summaries)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// internal/export/confluence.go
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/markdown"
+)
+
+// Mermaid 图表在 Confluence 中的处理方式
+const (
+	MermaidAsCode       = "code"       // 作为代码宏，保留图表源码
+	MermaidAsAttachment = "attachment" // 作为 .mmd 附件上传，正文中引用附件
+)
+
+// confluenceLanguages 将代码块语言映射为 Confluence 代码宏支持的语言名
+var confluenceLanguages = map[string]string{
+	"js": "js", "javascript": "js", "jsx": "js", "ts": "js", "typescript": "js", "tsx": "js", "json": "js",
+	"py": "py", "python": "py",
+	"sh": "bash", "bash": "bash", "shell": "bash", "zsh": "bash",
+	"yml": "yaml", "yaml": "yaml",
+	"c": "cpp", "h": "cpp", "cpp": "cpp", "c++": "cpp",
+	"cs": "c#", "csharp": "c#",
+	"html": "html", "xml": "xml", "sql": "sql", "java": "java", "go": "go", "kotlin": "kotlin", "rust": "rust",
+	"ruby": "ruby", "rb": "ruby", "php": "php", "css": "css", "diff": "diff", "scala": "scala",
+}
+
+// ConfluenceOptions 控制 Confluence 导出
+type ConfluenceOptions struct {
+	Mermaid string // MermaidAsCode（默认）或 MermaidAsAttachment
+}
+
+// ConfluencePage 是转换为 Confluence 格式的一个页面
+type ConfluencePage struct {
+	ID          string            `json:"id,omitempty"` // Wiki 页面ID，章节和根页面为空
+	Title       string            `json:"title"`
+	Parent      string            `json:"parent,omitempty"` // 父页面标题，为空表示空间或配置的父页面下
+	File        string            `json:"file"`             // 存储格式 XHTML 文件
+	WikiFile    string            `json:"wiki_file"`        // Wiki 标记文件
+	Attachments []string          `json:"attachments,omitempty"`
+	Storage     string            `json:"-"` // 存储格式 XHTML
+	WikiMarkup  string            `json:"-"` // 旧版 Wiki 标记
+	Files       map[string][]byte `json:"-"` // 附件文件名 -> 内容
+}
+
+// ConfluencePages 将 Wiki 转换为 Confluence 页面，按父页面在前的顺序排列
+// 根页面以 Wiki 标题命名，每个有标题的章节是根页面下的一个页面，Wiki 页面挂在所属章节下
+func ConfluencePages(w *Wiki, opts ConfluenceOptions) []ConfluencePage {
+	names := w.FileNames()
+	root := w.DisplayTitle()
+	rootBody := ""
+	if w.Description != "" {
+		rootBody = "<p>" + html.EscapeString(w.Description) + "</p>\n"
+	}
+	if w.RepoURL != "" {
+		rootBody += `<p>仓库: <a href="` + html.EscapeString(w.RepoURL) + `">` + html.EscapeString(w.RepoURL) + "</a>"
+		if w.Commit != "" {
+			rootBody += "，提交 <code>" + html.EscapeString(w.Commit) + "</code>"
+		}
+		rootBody += "</p>\n"
+	}
+	pages := []ConfluencePage{{
+		Title:      root,
+		File:       "pages/index.xml",
+		WikiFile:   "pages/index.wiki",
+		Storage:    rootBody + childrenMacro,
+		WikiMarkup: strings.TrimSpace(w.Description+"\n\n{children}") + "\n",
+	}}
+
+	for _, section := range w.Navigation() {
+		parent := root
+		if section.Title != "" {
+			name := "section-" + strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(section.ID), "-"), "-")
+			pages = append(pages, ConfluencePage{
+				Title:      section.Title,
+				Parent:     root,
+				File:       "pages/" + name + ".xml",
+				WikiFile:   "pages/" + name + ".wiki",
+				Storage:    childrenMacro,
+				WikiMarkup: "{children}\n",
+			})
+			parent = section.Title
+		}
+		for _, page := range section.Pages {
+			converted := w.confluencePage(page, opts)
+			converted.Parent = parent
+			converted.File = "pages/" + names[page.ID] + ".xml"
+			converted.WikiFile = "pages/" + names[page.ID] + ".wiki"
+			pages = append(pages, converted)
+		}
+	}
+	return pages
+}
+
+// childrenMacro 列出子页面的宏
+const childrenMacro = `<ac:structured-macro ac:name="children" />` + "\n"
+
+// confluencePage 将单个 Wiki 页面转换为存储格式和 Wiki 标记
+func (w *Wiki) confluencePage(page models.WikiPage, opts ConfluenceOptions) ConfluencePage {
+	result := ConfluencePage{ID: page.ID, Title: page.Title, Files: make(map[string][]byte)}
+	blocks := markdown.Parse(page.Content)
+	// Confluence 页面标题单独显示，去掉与标题重复的一级标题
+	if len(blocks) > 0 && blocks[0].Kind == markdown.Heading && blocks[0].Level == 1 {
+		blocks = blocks[1:]
+	}
+
+	diagram := 0
+	attach := func(code string) string {
+		diagram++
+		name := fmt.Sprintf("diagram-%d.mmd", diagram)
+		result.Files[name] = []byte(code)
+		result.Attachments = append(result.Attachments, name)
+		return name
+	}
+
+	result.Storage = markdown.RenderHTML(blocks, markdown.HTMLOptions{
+		XHTML: true,
+		CodeBlock: func(lang, code string) (string, bool) {
+			if lang == "mermaid" && opts.Mermaid == MermaidAsAttachment {
+				return `<p><ac:link><ri:attachment ri:filename="` + html.EscapeString(attach(code)) + `" /><ac:plain-text-link-body>` +
+					cdata("Mermaid 图表") + "</ac:plain-text-link-body></ac:link></p>", true
+			}
+			return codeMacro(lang, code), true
+		},
+		Link: func(dest, body string) (string, bool) {
+			id, anchor, ok := w.PageLink(dest)
+			if !ok {
+				return "", false
+			}
+			return w.confluenceLink(id, anchor, body), true
+		},
+		Image: func(src, alt string) (string, bool) {
+			return `<ac:image ac:alt="` + html.EscapeString(alt) + `"><ri:url ri:value="` + html.EscapeString(src) + `" /></ac:image>`, true
+		},
+	})
+
+	// Wiki 标记中的附件按相同顺序编号
+	diagram = 0
+	result.WikiMarkup = w.wikiMarkup(blocks, opts, func(string) string {
+		diagram++
+		return fmt.Sprintf("diagram-%d.mmd", diagram)
+	})
+	if len(result.Files) == 0 {
+		result.Files = nil
+	}
+	return result
+}
+
+// confluenceLink 生成指向其他页面的链接，锚点转换为目标页面中对应的标题文本
+func (w *Wiki) confluenceLink(id, anchor, body string) string {
+	target, _ := w.Page(id)
+	var link strings.Builder
+	link.WriteString("<ac:link")
+	if heading := headingForAnchor(target, anchor); heading != "" {
+		link.WriteString(` ac:anchor="` + html.EscapeString(heading) + `"`)
+	}
+	link.WriteString(`><ri:page ri:content-title="` + html.EscapeString(target.Title) + `" /><ac:link-body>` + body + "</ac:link-body></ac:link>")
+	return link.String()
+}
+
+// headingForAnchor 返回页面中锚点对应的标题文本
+func headingForAnchor(page models.WikiPage, anchor string) string {
+	if anchor == "" {
+		return ""
+	}
+	for _, heading := range markdown.Headings(markdown.Parse(page.Content)) {
+		if heading.ID == anchor {
+			return heading.Text
+		}
+	}
+	return ""
+}
+
+// codeMacro 生成 Confluence 代码宏
+func codeMacro(lang, code string) string {
+	var macro strings.Builder
+	macro.WriteString(`<ac:structured-macro ac:name="code">`)
+	if name, ok := confluenceLanguages[lang]; ok {
+		macro.WriteString(`<ac:parameter ac:name="language">` + name + "</ac:parameter>")
+	} else if lang != "" {
+		macro.WriteString(`<ac:parameter ac:name="title">` + html.EscapeString(lang) + "</ac:parameter>")
+	}
+	macro.WriteString("<ac:plain-text-body>" + cdata(code) + "</ac:plain-text-body></ac:structured-macro>")
+	return macro.String()
+}
+
+// cdata 将文本包裹为 CDATA，文本中的 ]]> 被拆分
+func cdata(text string) string {
+	return "<![CDATA[" + strings.ReplaceAll(text, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
+
+// wikiMarkup 将块转换为 Confluence 旧版 Wiki 标记
+func (w *Wiki) wikiMarkup(blocks []markdown.Block, opts ConfluenceOptions, attachment func(code string) string) string {
+	var out strings.Builder
+	var write func(blocks []markdown.Block, listPrefix string)
+	write = func(blocks []markdown.Block, listPrefix string) {
+		for _, block := range blocks {
+			switch block.Kind {
+			case markdown.Heading:
+				fmt.Fprintf(&out, "h%d. %s\n\n", block.Level, w.wikiInline(block.Text))
+			case markdown.Paragraph:
+				out.WriteString(w.wikiInline(block.Text) + "\n\n")
+			case markdown.CodeBlock:
+				if block.Lang == "mermaid" && opts.Mermaid == MermaidAsAttachment {
+					out.WriteString("[^" + attachment(block.Text) + "]\n\n")
+					continue
+				}
+				out.WriteString("{code")
+				if name, ok := confluenceLanguages[block.Lang]; ok {
+					out.WriteString(":language=" + name)
+				} else if block.Lang != "" {
+					out.WriteString(":title=" + block.Lang)
+				}
+				out.WriteString("}\n" + block.Text + "\n{code}\n\n")
+			case markdown.Quote:
+				out.WriteString("{quote}\n")
+				write(block.Children, "")
+				out.WriteString("{quote}\n\n")
+			case markdown.List:
+				marker := "*"
+				if block.Ordered {
+					marker = "#"
+				}
+				for _, item := range block.Items {
+					prefix := listPrefix + marker
+					text := ""
+					rest := item
+					if len(item) > 0 && item[0].Kind == markdown.Paragraph {
+						text, rest = w.wikiInline(item[0].Text), item[1:]
+					}
+					out.WriteString(prefix + " " + text + "\n")
+					for _, child := range rest {
+						if child.Kind == markdown.List {
+							write([]markdown.Block{child}, prefix)
+						} else {
+							write([]markdown.Block{child}, "")
+						}
+					}
+				}
+				if listPrefix == "" {
+					out.WriteString("\n")
+				}
+			case markdown.Table:
+				out.WriteString("||")
+				for _, cell := range block.Header {
+					out.WriteString(" " + w.wikiInline(cell) + " ||")
+				}
+				out.WriteString("\n")
+				for _, row := range block.Rows {
+					out.WriteString("|")
+					for _, cell := range row {
+						out.WriteString(" " + w.wikiInline(cell) + " |")
+					}
+					out.WriteString("\n")
+				}
+				out.WriteString("\n")
+			case markdown.Rule:
+				out.WriteString("----\n\n")
+			}
+		}
+	}
+	write(blocks, "")
+	return strings.TrimSpace(out.String()) + "\n"
+}
+
+// wikiTagPattern 匹配行内渲染结果中的标签
+var wikiTagPattern = regexp.MustCompile(`<(/?)([a-z]+)((?:\s+[a-z]+="[^"]*")*)\s*/?>`)
+
+// wikiAttrPattern 匹配标签属性
+var wikiAttrPattern = regexp.MustCompile(`([a-z]+)="([^"]*)"`)
+
+// wikiSpecial 是 Wiki 标记中需要转义的字符
+var wikiSpecial = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`, "|", `\|`, "!", `\!`, "^", `\^`, "~", `\~`)
+
+// wikiInline 将行内 Markdown 转换为 Wiki 标记：先渲染为 HTML，再将标签替换为对应的标记
+func (w *Wiki) wikiInline(text string) string {
+	rendered := markdown.InlineHTML(text, markdown.HTMLOptions{})
+
+	var out strings.Builder
+	var links []string
+	last := 0
+	for _, m := range wikiTagPattern.FindAllStringSubmatchIndex(rendered, -1) {
+		out.WriteString(wikiSpecial.Replace(html.UnescapeString(rendered[last:m[0]])))
+		last = m[1]
+		closing, tag := rendered[m[2]:m[3]] == "/", rendered[m[4]:m[5]]
+		attrs := make(map[string]string)
+		for _, a := range wikiAttrPattern.FindAllStringSubmatch(rendered[m[6]:m[7]], -1) {
+			attrs[a[1]] = html.UnescapeString(a[2])
+		}
+		switch tag {
+		case "strong":
+			out.WriteString("*")
+		case "em":
+			out.WriteString("_")
+		case "del":
+			out.WriteString("-")
+		case "code":
+			if closing {
+				out.WriteString("}}")
+			} else {
+				out.WriteString("{{")
+			}
+		case "br":
+			out.WriteString("\\\\ ")
+		case "img":
+			out.WriteString("!" + attrs["src"] + "!")
+		case "a":
+			if !closing {
+				out.WriteString("[")
+				target := attrs["href"]
+				if id, anchor, ok := w.PageLink(target); ok {
+					page, _ := w.Page(id)
+					target = page.Title
+					if heading := headingForAnchor(page, anchor); heading != "" {
+						target += "#" + heading
+					}
+				}
+				links = append(links, target)
+			} else if len(links) > 0 {
+				out.WriteString("|" + links[len(links)-1] + "]")
+				links = links[:len(links)-1]
+			}
+		}
+	}
+	out.WriteString(wikiSpecial.Replace(html.UnescapeString(rendered[last:])))
+	return strings.ReplaceAll(out.String(), "\n", " ")
+}
+
+// ConfluenceBundle 将 Wiki 打包为空间导入包，返回 zip 包
+// 包含 manifest.json（页面标题、父页面、文件和附件，按创建顺序排列）、pages/ 下每个页面的存储格式 XHTML 和 Wiki 标记以及 attachments/ 下的附件
+func ConfluenceBundle(w *Wiki, opts ConfluenceOptions) ([]byte, error) {
+	pages := ConfluencePages(w, opts)
+	root := projectName(w) + "-confluence/"
+
+	out := newArchive()
+	for i := range pages {
+		page := &pages[i]
+		if err := out.add(root+page.File, []byte(page.Storage)); err != nil {
+			return nil, err
+		}
+		if err := out.add(root+page.WikiFile, []byte(page.WikiMarkup)); err != nil {
+			return nil, err
+		}
+		for j, name := range page.Attachments {
+			path := "attachments/" + strings.TrimSuffix(strings.TrimPrefix(page.File, "pages/"), ".xml") + "/" + name
+			if err := out.add(root+path, page.Files[name]); err != nil {
+				return nil, err
+			}
+			page.Attachments[j] = path
+		}
+	}
+
+	manifest, err := json.MarshalIndent(map[string]interface{}{
+		"title":    w.DisplayTitle(),
+		"repo_url": w.RepoURL,
+		"commit":   w.Commit,
+		"format":   "storage",
+		"pages":    pages,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成导入清单失败: %v", err)
+	}
+	if err := out.add(root+"manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	return out.bytes()
+}
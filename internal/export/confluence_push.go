@@ -0,0 +1,255 @@
+// internal/export/confluence_push.go
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ConfluenceClient 通过 REST API 将页面推送到 Confluence 空间
+type ConfluenceClient struct {
+	BaseURL  string // 例如 https://example.atlassian.net/wiki 或 https://confluence.example.com
+	SpaceKey string
+	Username string // 设置时使用 Basic 认证（用户名/邮箱 + API 令牌），否则使用 Bearer 个人访问令牌
+	Token    string
+	ParentID string // 可选，根页面挂在该页面下
+	HTTP     *http.Client
+}
+
+// PushResult 表示一个页面的推送结果
+type PushResult struct {
+	Title       string   `json:"title"`
+	ContentID   string   `json:"content_id"`
+	Action      string   `json:"action"` // "created" 或 "updated"
+	Version     int      `json:"version"`
+	URL         string   `json:"url,omitempty"`
+	Attachments []string `json:"attachments,omitempty"`
+}
+
+// confluenceContent 是 Confluence 内容 API 的请求和响应
+type confluenceContent struct {
+	ID        string             `json:"id,omitempty"`
+	Type      string             `json:"type"`
+	Title     string             `json:"title"`
+	Space     *confluenceSpace   `json:"space,omitempty"`
+	Ancestors []confluenceID     `json:"ancestors,omitempty"`
+	Version   *confluenceVersion `json:"version,omitempty"`
+	Body      *confluenceBody    `json:"body,omitempty"`
+	Links     map[string]string  `json:"_links,omitempty"`
+}
+
+type confluenceSpace struct {
+	Key string `json:"key"`
+}
+
+type confluenceID struct {
+	ID string `json:"id"`
+}
+
+type confluenceVersion struct {
+	Number  int    `json:"number"`
+	Message string `json:"message,omitempty"`
+}
+
+type confluenceBody struct {
+	Storage confluenceStorage `json:"storage"`
+}
+
+type confluenceStorage struct {
+	Value          string `json:"value"`
+	Representation string `json:"representation"`
+}
+
+// Push 按顺序创建或更新页面（父页面必须在子页面之前），并上传页面的附件
+// 位于同一父页面下的同名页面会被更新为新版本，版本说明中包含源码提交；
+// 同名页面位于空间中的其他位置时拒绝覆盖并返回错误
+func (c *ConfluenceClient) Push(ctx context.Context, pages []ConfluencePage, commit string) ([]PushResult, error) {
+	if c.BaseURL == "" || c.SpaceKey == "" {
+		return nil, fmt.Errorf("未配置 Confluence 地址或空间")
+	}
+	ids := make(map[string]string)
+	results := make([]PushResult, 0, len(pages))
+	message := "DeepWiki-Go 导出"
+	if commit != "" {
+		message += "，源码提交 " + commit
+	}
+
+	for _, page := range pages {
+		content := confluenceContent{
+			Type:  "page",
+			Title: page.Title,
+			Space: &confluenceSpace{Key: c.SpaceKey},
+			Body:  &confluenceBody{Storage: confluenceStorage{Value: page.Storage, Representation: "storage"}},
+		}
+		parent := c.ParentID
+		if page.Parent != "" {
+			parent = ids[page.Parent]
+		}
+		if parent != "" {
+			content.Ancestors = []confluenceID{{ID: parent}}
+		}
+
+		existing, err := c.find(ctx, page.Title)
+		if err != nil {
+			return results, err
+		}
+		if existing != nil && !childOf(existing, parent) {
+			return results, fmt.Errorf("空间中已有不属于该 Wiki 的同名页面 %s (ID %s)，拒绝覆盖", page.Title, existing.ID)
+		}
+		result := PushResult{Title: page.Title}
+		var saved confluenceContent
+		if existing == nil {
+			content.Version = &confluenceVersion{Number: 1, Message: message}
+			err = c.do(ctx, http.MethodPost, "/rest/api/content", content, &saved)
+			result.Action = "created"
+		} else {
+			content.Version = &confluenceVersion{Number: existing.Version.Number + 1, Message: message}
+			err = c.do(ctx, http.MethodPut, "/rest/api/content/"+url.PathEscape(existing.ID), content, &saved)
+			result.Action = "updated"
+		}
+		if err != nil {
+			return results, fmt.Errorf("推送页面 %s 失败: %v", page.Title, err)
+		}
+
+		ids[page.Title] = saved.ID
+		result.ContentID = saved.ID
+		result.Version = content.Version.Number
+		if saved.Version != nil {
+			result.Version = saved.Version.Number
+		}
+		if webui := saved.Links["webui"]; webui != "" {
+			result.URL = strings.TrimRight(c.BaseURL, "/") + webui
+		}
+
+		for _, name := range page.Attachments {
+			if err := c.attach(ctx, saved.ID, name, page.Files[name]); err != nil {
+				return results, fmt.Errorf("上传页面 %s 的附件 %s 失败: %v", page.Title, name, err)
+			}
+			result.Attachments = append(result.Attachments, name)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// find 按标题查找空间中的页面及其祖先页面，不存在时返回 nil
+func (c *ConfluenceClient) find(ctx context.Context, title string) (*confluenceContent, error) {
+	query := url.Values{"spaceKey": {c.SpaceKey}, "title": {title}, "type": {"page"}, "expand": {"version,ancestors"}}
+	var found struct {
+		Results []confluenceContent `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/content?"+query.Encode(), nil, &found); err != nil {
+		return nil, fmt.Errorf("查找页面 %s 失败: %v", title, err)
+	}
+	if len(found.Results) == 0 {
+		return nil, nil
+	}
+	page := found.Results[0]
+	if page.Version == nil {
+		page.Version = &confluenceVersion{Number: 1}
+	}
+	return &page, nil
+}
+
+// childOf 判断页面是否直接位于指定父页面下，parent 为空表示位于空间的顶层
+// 祖先页面按从空间顶层到直接父页面的顺序排列
+func childOf(page *confluenceContent, parent string) bool {
+	if len(page.Ancestors) == 0 {
+		return parent == ""
+	}
+	return page.Ancestors[len(page.Ancestors)-1].ID == parent
+}
+
+// attach 创建或更新页面附件
+func (c *ConfluenceClient) attach(ctx context.Context, contentID, name string, data []byte) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	req, err := c.request(ctx, http.MethodPut, "/rest/api/content/"+url.PathEscape(contentID)+"/child/attachment", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-Atlassian-Token", "no-check")
+	return c.send(req, nil)
+}
+
+// do 发送 JSON 请求并解析 JSON 响应
+func (c *ConfluenceClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+// request 创建带认证信息的请求
+func (c *ConfluenceClient) request(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Token)
+	} else if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// send 发送请求，非 2xx 响应作为错误返回
+func (c *ConfluenceClient) send(req *http.Request, out interface{}) error {
+	client := c.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Confluence 返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析 Confluence 响应失败: %v", err)
+		}
+	}
+	return nil
+}
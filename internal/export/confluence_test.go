@@ -0,0 +1,188 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConfluencePages(t *testing.T) {
+	w := testWiki()
+	w.Pages[0].Content = "# Overview\n\nRead [storage](#architecture) and [docs](https://example.com/x).  \nNext line with `code` and **bold**.\n\n" +
+		"| A | B |\n|---|---|\n| 1 | [[2]](https://example.com) |\n\n```go\nfmt.Println(\"]]>\")\n```\n\n---\n"
+	w.Pages[1].Content = "# Architecture\n\n## Storage Layer\n\n```mermaid\ngraph TD\n  A --> B\n```\n"
+	w.Pages[0].Content = strings.Replace(w.Pages[0].Content, "#architecture", "./architecture.md#storage-layer", 1)
+
+	pages := ConfluencePages(w, ConfluenceOptions{Mermaid: MermaidAsAttachment})
+	titles := make([]string, 0, len(pages))
+	for _, page := range pages {
+		titles = append(titles, page.Title+"<"+page.Parent)
+	}
+	if strings.Join(titles, ",") != "demo<,Introduction<demo,Overview<Introduction,Architecture<Introduction,Extra<demo" {
+		t.Fatalf("unexpected page tree: %v", titles)
+	}
+
+	overview := pages[2].Storage
+	for _, want := range []string{
+		`<ac:link ac:anchor="Storage Layer"><ri:page ri:content-title="Architecture" /><ac:link-body>storage</ac:link-body></ac:link>`,
+		`<a href="https://example.com/x">docs</a>.<br />`,
+		`<td><a href="https://example.com">[2]</a></td>`,
+		`<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">go</ac:parameter><ac:plain-text-body><![CDATA[fmt.Println("]]]]><![CDATA[>")]]></ac:plain-text-body></ac:structured-macro>`,
+		"<hr />",
+	} {
+		if !strings.Contains(overview, want) {
+			t.Errorf("storage format missing %q:\n%s", want, overview)
+		}
+	}
+	if strings.Contains(overview, "<h1>") {
+		t.Error("the page title heading should be dropped")
+	}
+
+	wiki := pages[2].WikiMarkup
+	for _, want := range []string{"[storage|Architecture#Storage Layer]", "[docs|https://example.com/x].\\\\ ", "{{code}} and *bold*", "|| A || B ||", "| 1 | [\\[2\\]|https://example.com] |", "{code:language=go}", "----"} {
+		if !strings.Contains(wiki, want) {
+			t.Errorf("wiki markup missing %q:\n%s", want, wiki)
+		}
+	}
+
+	arch := pages[3]
+	if len(arch.Attachments) != 1 || string(arch.Files["diagram-1.mmd"]) != "graph TD\n  A --> B" || !strings.Contains(arch.Storage, `ri:filename="diagram-1.mmd"`) || !strings.Contains(arch.WikiMarkup, "[^diagram-1.mmd]") {
+		t.Errorf("mermaid diagram should become an attachment: %+v", arch)
+	}
+	if code := ConfluencePages(w, ConfluenceOptions{})[3]; len(code.Attachments) != 0 || !strings.Contains(code.Storage, `<ac:parameter ac:name="title">mermaid</ac:parameter>`) {
+		t.Errorf("mermaid diagram should be a code macro by default:\n%s", code.Storage)
+	}
+
+	data, err := ConfluenceBundle(w, ConfluenceOptions{Mermaid: MermaidAsAttachment})
+	if err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+	files := readZip(t, data)
+	var manifest struct {
+		Pages []ConfluencePage `json:"pages"`
+	}
+	if err := json.Unmarshal([]byte(files["demo-confluence/manifest.json"]), &manifest); err != nil || len(manifest.Pages) != 5 {
+		t.Fatalf("unexpected manifest: %v\n%s", err, files["demo-confluence/manifest.json"])
+	}
+	if files["demo-confluence/attachments/architecture/diagram-1.mmd"] == "" || files["demo-confluence/pages/overview.xml"] == "" || files["demo-confluence/pages/overview.wiki"] == "" {
+		t.Errorf("bundle is missing files: %v", manifest.Pages)
+	}
+}
+
+// confluenceStub 是模拟 Confluence REST API 的测试服务器
+type confluenceStub struct {
+	mu          sync.Mutex
+	pages       map[string]map[string]interface{} // 标题 -> 页面
+	attachments []string
+	auth        []string
+}
+
+func (s *confluenceStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, token, _ := r.BasicAuth()
+	s.auth = append(s.auth, user+":"+token)
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/wiki/rest/api/content":
+		var results []interface{}
+		if page, ok := s.pages[r.URL.Query().Get("title")]; ok && r.URL.Query().Get("spaceKey") == "DOCS" {
+			results = append(results, page)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+
+	case (r.Method == http.MethodPost && r.URL.Path == "/wiki/rest/api/content") || (r.Method == http.MethodPut && !strings.HasSuffix(r.URL.Path, "/attachment")):
+		var page map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&page)
+		title := page["title"].(string)
+		if existing, ok := s.pages[title]; ok {
+			page["id"] = existing["id"]
+		} else {
+			page["id"] = string(rune('a' + len(s.pages)))
+		}
+		page["_links"] = map[string]string{"webui": "/spaces/DOCS/pages/" + page["id"].(string)}
+		s.pages[title] = page
+		json.NewEncoder(w).Encode(page)
+
+	case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/child/attachment"):
+		file, header, err := r.FormFile("file")
+		if err != nil || r.Header.Get("X-Atlassian-Token") != "no-check" {
+			http.Error(w, "bad attachment", http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		s.attachments = append(s.attachments, r.URL.Path+":"+header.Filename+":"+string(content))
+		w.Write([]byte(`{"results":[]}`))
+
+	default:
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+func TestConfluencePush(t *testing.T) {
+	stub := &confluenceStub{pages: make(map[string]map[string]interface{})}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	client := &ConfluenceClient{BaseURL: server.URL + "/wiki/", SpaceKey: "DOCS", Username: "bot", Token: "secret", ParentID: "root"}
+	pages := ConfluencePages(testWiki(), ConfluenceOptions{Mermaid: MermaidAsAttachment})
+
+	results, err := client.Push(context.Background(), pages, "abc123")
+	if err != nil {
+		t.Fatalf("first push: %v", err)
+	}
+	if len(results) != 5 || results[0].Action != "created" || results[0].URL != server.URL+"/wiki/spaces/DOCS/pages/a" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	ancestors := stub.pages["Overview"]["ancestors"].([]interface{})
+	if ancestors[0].(map[string]interface{})["id"] != stub.pages["Introduction"]["id"] {
+		t.Errorf("pages should be created under their section: %v", ancestors)
+	}
+	if root := stub.pages["demo"]["ancestors"].([]interface{}); root[0].(map[string]interface{})["id"] != "root" {
+		t.Errorf("root page should be created under the configured parent: %v", root)
+	}
+	if len(stub.attachments) != 1 || !strings.Contains(stub.attachments[0], "diagram-1.mmd:graph TD") {
+		t.Errorf("unexpected attachments: %v", stub.attachments)
+	}
+	if stub.auth[0] != "bot:secret" {
+		t.Errorf("basic auth not sent: %v", stub.auth[0])
+	}
+
+	// 再次推送时更新已有页面并递增版本号
+	results, err = client.Push(context.Background(), pages, "def456")
+	if err != nil {
+		t.Fatalf("second push: %v", err)
+	}
+	if results[2].Action != "updated" || results[2].Version != 2 {
+		t.Errorf("existing pages should be updated: %+v", results[2])
+	}
+	version := stub.pages["Overview"]["version"].(map[string]interface{})
+	if !strings.Contains(version["message"].(string), "def456") {
+		t.Errorf("version message should mention the source commit: %v", version)
+	}
+
+	// 空间中其他位置的同名页面不会被覆盖
+	stub.pages["Architecture"] = map[string]interface{}{
+		"id": "other", "title": "Architecture", "ancestors": []interface{}{map[string]interface{}{"id": "team-notes"}},
+		"version": map[string]interface{}{"number": 7},
+	}
+	if _, err := client.Push(context.Background(), pages, "def456"); err == nil || !strings.Contains(err.Error(), "other") {
+		t.Errorf("pages outside the wiki should not be overwritten: %v", err)
+	}
+	if version := stub.pages["Architecture"]["version"].(map[string]interface{}); version["number"] != 7 {
+		t.Errorf("foreign page was modified: %v", stub.pages["Architecture"])
+	}
+	moved := &ConfluenceClient{BaseURL: server.URL + "/wiki", SpaceKey: "DOCS", Username: "bot", Token: "secret", ParentID: "elsewhere"}
+	if _, err := moved.Push(context.Background(), pages[:1], ""); err == nil {
+		t.Error("the root page should only be updated under the configured parent")
+	}
+
+	if _, err := (&ConfluenceClient{BaseURL: server.URL + "/missing", SpaceKey: "DOCS"}).Push(context.Background(), pages, ""); err == nil {
+		t.Error("errors from the server should be reported")
+	}
+}
@@ -0,0 +1,359 @@
+// internal/export/docsite.go
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/markdown"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter 是导出的 Markdown 文件开头的 YAML 头信息
+type frontMatter struct {
+	Title           string   `yaml:"title"`
+	ID              string   `yaml:"id,omitempty"`
+	Slug            string   `yaml:"slug,omitempty"`
+	SidebarPosition int      `yaml:"sidebar_position,omitempty"`
+	Importance      string   `yaml:"importance,omitempty"`
+	FilePaths       []string `yaml:"file_paths,omitempty"`
+	RelatedPages    []string `yaml:"related_pages,omitempty"`
+	Commit          string   `yaml:"commit,omitempty"`
+	RepoURL         string   `yaml:"repo_url,omitempty"`
+}
+
+// withFrontMatter 在 Markdown 内容前加上 YAML 头信息
+func withFrontMatter(meta frontMatter, content string) (string, error) {
+	header, err := yaml.Marshal(meta)
+	if err != nil {
+		return "", fmt.Errorf("生成页面头信息失败: %v", err)
+	}
+	return "---\n" + string(header) + "---\n\n" + strings.TrimLeft(content, "\n"), nil
+}
+
+// pageFrontMatter 返回页面的头信息
+func (w *Wiki) pageFrontMatter(page models.WikiPage) frontMatter {
+	return frontMatter{
+		Title:        page.Title,
+		ID:           page.ID,
+		Importance:   page.Importance,
+		FilePaths:    page.FilePaths,
+		RelatedPages: page.RelatedPages,
+		Commit:       w.Commit,
+	}
+}
+
+// importanceRank 返回重要性的排序权重，越重要越小
+func importanceRank(importance string) int {
+	switch strings.ToLower(importance) {
+	case "high":
+		return 0
+	case "medium", "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// docNavigation 返回文档站点使用的导航：章节内按重要性排序，同等重要性保持原有顺序
+func (w *Wiki) docNavigation() []NavSection {
+	nav := w.Navigation()
+	for _, section := range nav {
+		pages := section.Pages
+		sort.SliceStable(pages, func(i, j int) bool {
+			return importanceRank(pages[i].Importance) < importanceRank(pages[j].Importance)
+		})
+	}
+	return nav
+}
+
+// markdownFiles 改写页面间的链接，返回每个页面的 Markdown 内容
+// 指向其他页面的链接改为相对的 <文件名>.md，不安全的链接目标（例如 javascript:）替换为 #
+func (w *Wiki) markdownFiles(names map[string]string) map[string]string {
+	files := make(map[string]string, len(w.Pages))
+	for _, page := range w.Pages {
+		files[page.ID] = markdown.RewriteLinks(page.Content, func(dest string) string {
+			if !markdown.SafeURL(dest) {
+				return "#"
+			}
+			id, anchor, ok := w.PageLink(dest)
+			if !ok {
+				return dest
+			}
+			link := names[id] + ".md"
+			if anchor != "" {
+				link += "#" + anchor
+			}
+			return link
+		})
+	}
+	return files
+}
+
+// pageHeading 在没有一级标题的页面开头补充页面标题
+func pageHeading(page models.WikiPage, content string) string {
+	for _, block := range markdown.Parse(content) {
+		if block.Kind == markdown.Heading && block.Level == 1 {
+			return content
+		}
+		break
+	}
+	return "# " + page.Title + "\n\n" + content
+}
+
+// MkDocs 将 Wiki 导出为 MkDocs 项目，返回 zip 包
+// 包含 mkdocs.yml（导航按章节、页面顺序和重要性生成）、requirements.txt、docs/index.md 以及每个页面一个 Markdown 文件
+func MkDocs(w *Wiki) ([]byte, error) {
+	names := w.FileNames()
+	contents := w.markdownFiles(names)
+	nav := w.docNavigation()
+	root := slugDir(w)
+
+	out := newArchive()
+	var config strings.Builder
+	fmt.Fprintf(&config, "site_name: %s\n", yamlString(w.DisplayTitle()))
+	if w.Description != "" {
+		fmt.Fprintf(&config, "site_description: %s\n", yamlString(w.Description))
+	}
+	if w.RepoURL != "" {
+		fmt.Fprintf(&config, "repo_url: %s\n", yamlString(w.RepoURL))
+	}
+	config.WriteString("theme:\n  name: material\n  features:\n    - navigation.sections\n    - search.highlight\n")
+	if w.Language != "" {
+		fmt.Fprintf(&config, "  language: %s\n", yamlString(w.Language))
+	}
+	config.WriteString("plugins:\n  - search\n")
+	config.WriteString("markdown_extensions:\n  - tables\n  - admonition\n  - toc:\n      permalink: true\n")
+	config.WriteString("  - pymdownx.highlight\n  - pymdownx.superfences:\n      custom_fences:\n")
+	config.WriteString("        - name: mermaid\n          class: mermaid\n          format: !!python/name:pymdownx.superfences.fence_code_format\n")
+	config.WriteString("nav:\n  - " + yamlString(homeTitle(w)) + ": index.md\n")
+	for _, section := range nav {
+		indent := "  "
+		if section.Title != "" {
+			fmt.Fprintf(&config, "  - %s:\n", yamlString(section.Title))
+			indent = "      "
+		}
+		for _, page := range section.Pages {
+			fmt.Fprintf(&config, "%s- %s: %s.md\n", indent, yamlString(page.Title), names[page.ID])
+		}
+	}
+	if err := out.add(root+"mkdocs.yml", []byte(config.String())); err != nil {
+		return nil, err
+	}
+	if err := out.add(root+"requirements.txt", []byte("mkdocs>=1.5\nmkdocs-material>=9.0\npymdown-extensions>=10.0\n")); err != nil {
+		return nil, err
+	}
+	if err := out.add(root+"docs/index.md", []byte(landingPage(w, nav, names))); err != nil {
+		return nil, err
+	}
+
+	for _, page := range w.Ordered() {
+		content, err := withFrontMatter(w.pageFrontMatter(page), pageHeading(page, contents[page.ID]))
+		if err != nil {
+			return nil, err
+		}
+		if err := out.add(root+"docs/"+names[page.ID]+".md", []byte(content)); err != nil {
+			return nil, err
+		}
+	}
+	return out.bytes()
+}
+
+// Docusaurus 将 Wiki 导出为 Docusaurus 项目，返回 zip 包
+// 包含 package.json、docusaurus.config.js、sidebars.js（按章节、页面顺序和重要性生成）以及每个页面一个 Markdown 文件
+func Docusaurus(w *Wiki) ([]byte, error) {
+	names := w.FileNames()
+	contents := w.markdownFiles(names)
+	nav := w.docNavigation()
+	root := slugDir(w)
+
+	// 侧边栏：有标题的章节作为分类，其余页面直接列出
+	var items []interface{}
+	for _, section := range nav {
+		ids := make([]string, 0, len(section.Pages))
+		for _, page := range section.Pages {
+			ids = append(ids, names[page.ID])
+		}
+		if section.Title == "" {
+			for _, id := range ids {
+				items = append(items, id)
+			}
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"type":      "category",
+			"label":     section.Title,
+			"collapsed": false,
+			"items":     ids,
+		})
+	}
+	sidebar, err := json.MarshalIndent(map[string]interface{}{"wikiSidebar": items}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成侧边栏失败: %v", err)
+	}
+
+	title, _ := json.Marshal(w.DisplayTitle())
+	tagline, _ := json.Marshal(w.Description)
+	locale := w.Language
+	if locale == "" {
+		locale = "zh"
+	}
+	localeJSON, _ := json.Marshal(locale)
+	packageJSON, _ := json.MarshalIndent(map[string]interface{}{
+		"name":    docusaurusPackageName(w),
+		"version": "0.0.0",
+		"private": true,
+		"scripts": map[string]string{
+			"start": "docusaurus start",
+			"build": "docusaurus build",
+			"serve": "docusaurus serve",
+		},
+		"dependencies": map[string]string{
+			"@docusaurus/core":           "^3.5.0",
+			"@docusaurus/preset-classic": "^3.5.0",
+			"@docusaurus/theme-mermaid":  "^3.5.0",
+			"@mdx-js/react":              "^3.0.0",
+			"react":                      "^18.2.0",
+			"react-dom":                  "^18.2.0",
+		},
+	}, "", "  ")
+
+	config := fmt.Sprintf(`// 由 DeepWiki-Go 生成
+module.exports = {
+  title: %s,
+  tagline: %s,
+  url: "http://localhost",
+  baseUrl: "/",
+  onBrokenLinks: "warn",
+  i18n: { defaultLocale: %s, locales: [%s] },
+  markdown: { format: "detect", mermaid: true },
+  themes: ["@docusaurus/theme-mermaid"],
+  presets: [
+    [
+      "classic",
+      {
+        docs: { routeBasePath: "/", sidebarPath: require.resolve("./sidebars.js") },
+        blog: false,
+      },
+    ],
+  ],
+  themeConfig: {
+    navbar: { title: %s },
+  },
+};
+`, title, tagline, localeJSON, localeJSON, title)
+
+	out := newArchive()
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"package.json", packageJSON},
+		{"docusaurus.config.js", []byte(config)},
+		{"sidebars.js", []byte("// 由 DeepWiki-Go 生成\nmodule.exports = " + string(sidebar) + ";\n")},
+	}
+	for _, f := range files {
+		if err := out.add(root+f.name, f.content); err != nil {
+			return nil, err
+		}
+	}
+
+	position := 0
+	for _, section := range nav {
+		for _, page := range section.Pages {
+			position++
+			meta := frontMatter{
+				Title:           page.Title,
+				ID:              names[page.ID],
+				SidebarPosition: position,
+				Importance:      page.Importance,
+				FilePaths:       page.FilePaths,
+				RelatedPages:    page.RelatedPages,
+				Commit:          w.Commit,
+			}
+			// 第一个页面作为站点首页
+			if position == 1 {
+				meta.Slug = "/"
+			}
+			content, err := withFrontMatter(meta, pageHeading(page, contents[page.ID]))
+			if err != nil {
+				return nil, err
+			}
+			if err := out.add(root+"docs/"+names[page.ID]+".md", []byte(content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out.bytes()
+}
+
+// landingPage 生成 MkDocs 首页，列出所有章节和页面
+func landingPage(w *Wiki, nav []NavSection, names map[string]string) string {
+	var b strings.Builder
+	b.WriteString("# " + w.DisplayTitle() + "\n\n")
+	if w.Description != "" {
+		b.WriteString(w.Description + "\n\n")
+	}
+	if w.RepoURL != "" {
+		b.WriteString("仓库: <" + w.RepoURL + ">")
+		if w.Commit != "" {
+			b.WriteString("，提交 `" + w.Commit + "`")
+		}
+		b.WriteString("\n\n")
+	}
+	return b.String() + pageList(nav, names)
+}
+
+// pageList 按章节列出指向各页面 Markdown 文件的链接
+func pageList(nav []NavSection, names map[string]string) string {
+	var b strings.Builder
+	for i, section := range nav {
+		if section.Title != "" {
+			b.WriteString("## " + section.Title + "\n\n")
+		} else if i > 0 {
+			// 不属于任何章节的页面与上一章节的列表分开
+			b.WriteString("---\n\n")
+		}
+		for _, page := range section.Pages {
+			b.WriteString(fmt.Sprintf("- [%s](%s.md)\n", page.Title, names[page.ID]))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// homeTitle 返回首页在导航中的标题
+func homeTitle(w *Wiki) string {
+	if w.Language == "" || strings.HasPrefix(w.Language, "zh") {
+		return "首页"
+	}
+	return "Home"
+}
+
+// projectName 返回由仓库名生成的、可用作目录名的项目名
+func projectName(w *Wiki) string {
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(w.RepoName), "-"), "-")
+	if name == "" {
+		name = "wiki"
+	}
+	return name
+}
+
+// slugDir 返回压缩包中文档项目的根目录
+func slugDir(w *Wiki) string {
+	return projectName(w) + "-docs/"
+}
+
+// docusaurusPackageName 返回 package.json 中的包名
+func docusaurusPackageName(w *Wiki) string {
+	return strings.TrimSuffix(slugDir(w), "/")
+}
+
+// yamlString 将字符串转为 YAML 双引号字符串
+func yamlString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMkDocs(t *testing.T) {
+	w := testWiki()
+	w.Pages[1].Importance = "high"
+	w.Pages[2].Content += "\n\n[run](javascript:alert(1))"
+	data, err := MkDocs(w)
+	if err != nil {
+		t.Fatalf("mkdocs: %v", err)
+	}
+	files := readZip(t, data)
+
+	config := files["demo-docs/mkdocs.yml"]
+	nav := config[strings.Index(config, "nav:"):]
+	want := "nav:\n  - \"首页\": index.md\n  - \"Introduction\":\n      - \"Overview\": overview.md\n      - \"Architecture\": architecture.md\n  - \"Extra\": extra.md\n"
+	if nav != want {
+		t.Errorf("unexpected nav:\n%s", nav)
+	}
+
+	overview := files["demo-docs/docs/overview.md"]
+	for _, want := range []string{"---\ntitle: Overview\nid: overview\nimportance: high\nrelated_pages:\n", "commit: abc123\n---\n\n# Overview", "[architecture](architecture.md)", "[storage](architecture.md#storage)"} {
+		if !strings.Contains(overview, want) {
+			t.Errorf("overview.md missing %q:\n%s", want, overview)
+		}
+	}
+	if !strings.Contains(files["demo-docs/docs/extra.md"], "# Extra\n\nNo heading here.") {
+		t.Error("pages without a heading should get the page title")
+	}
+	if extra := files["demo-docs/docs/extra.md"]; strings.Contains(extra, "javascript:") || !strings.Contains(extra, "[run](#)") {
+		t.Errorf("unsafe link not neutralized:\n%s", extra)
+	}
+}
+
+func TestDocusaurus(t *testing.T) {
+	w := testWiki()
+	w.Pages[0].Importance = "low"
+	data, err := Docusaurus(w)
+	if err != nil {
+		t.Fatalf("docusaurus: %v", err)
+	}
+	files := readZip(t, data)
+
+	sidebars := strings.Join(strings.Fields(files["demo-docs/sidebars.js"]), " ")
+	if !strings.Contains(sidebars, `"items": [ "architecture", "overview" ]`) || !strings.Contains(sidebars, `"extra" ]`) {
+		t.Errorf("sidebar should order pages by importance within sections:\n%s", sidebars)
+	}
+	if !strings.Contains(files["demo-docs/docs/architecture.md"], "slug: /\n") {
+		t.Error("first page in the sidebar should be the home page")
+	}
+	if !strings.Contains(files["demo-docs/docs/overview.md"], "sidebar_position: 2\n") {
+		t.Errorf("unexpected overview front matter:\n%s", files["demo-docs/docs/overview.md"])
+	}
+	for _, name := range []string{"demo-docs/package.json", "demo-docs/docusaurus.config.js"} {
+		if files[name] == "" {
+			t.Errorf("missing %s", name)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// internal/export/epub.go
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/markdown"
+	"github.com/google/uuid"
+)
+
+// epubStyle 是电子书的样式表，代码块使用等宽字体并保留与 HTML 导出相同的高亮配色
+const epubStyle = `body { font-family: serif; line-height: 1.5; margin: 0 4%; }
+h1, h2, h3, h4 { font-family: sans-serif; line-height: 1.25; page-break-after: avoid; }
+code { font-family: monospace; font-size: 0.9em; background: #f3f4f6; padding: 0 0.2em; }
+pre { font-family: monospace; font-size: 0.8em; background: #f6f8fa; border: 1px solid #d0d7de; padding: 0.6em; white-space: pre-wrap; word-wrap: break-word; page-break-inside: avoid; }
+pre code { background: none; padding: 0; font-size: 1em; }
+pre .k { color: #cf222e; font-weight: bold; }
+pre .s { color: #0a3069; }
+pre .c { color: #6e7781; font-style: italic; }
+pre .n { color: #0550ae; }
+pre.mermaid { color: #57606a; }
+table { border-collapse: collapse; margin: 1em 0; font-size: 0.9em; }
+th, td { border: 1px solid #d0d7de; padding: 0.3em 0.6em; }
+th { background: #f6f8fa; }
+blockquote { margin: 0 0 0 1em; padding-left: 0.8em; border-left: 3px solid #d0d7de; color: #57606a; }
+.meta { color: #57606a; font-size: 0.85em; }
+nav ol { list-style: none; padding-left: 1em; }
+`
+
+// EPUB 将 Wiki 导出为 EPUB 3 电子书
+// 目录按导航顺序生成，元数据中包含仓库名、仓库地址和源码提交；Mermaid 图表以源码显示
+func EPUB(w *Wiki) ([]byte, error) {
+	names := w.FileNames()
+	chapter := func(id string) string { return "text/" + names[id] + ".xhtml" }
+	language := w.Language
+	if language == "" {
+		language = "zh"
+	}
+	title := w.DisplayTitle()
+	nav := w.Navigation()
+	pages := w.Ordered()
+
+	renderOpts := markdown.HTMLOptions{
+		XHTML:      true,
+		HeadingIDs: true,
+		LinkURL: func(dest string) string {
+			id, anchor, ok := w.PageLink(dest)
+			if !ok {
+				return dest
+			}
+			// 章节文件位于同一目录
+			link := names[id] + ".xhtml"
+			if anchor != "" {
+				link += "#" + anchor
+			}
+			return link
+		},
+		// 电子书中不引用远程图片，改为链接
+		Image: func(src, alt string) (string, bool) {
+			if alt == "" {
+				alt = src
+			}
+			return `<a href="` + html.EscapeString(src) + `">` + html.EscapeString(alt) + "</a>", true
+		},
+		CodeBlock: func(lang, code string) (string, bool) {
+			if lang != "mermaid" {
+				return "", false
+			}
+			return `<pre class="mermaid"><code>` + html.EscapeString(code) + "</code></pre>", true
+		},
+	}
+
+	out := newArchive()
+	// mimetype 必须是第一个文件且不压缩
+	if err := out.store("mimetype", []byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+	container := `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+	if err := out.add("META-INF/container.xml", []byte(container)); err != nil {
+		return nil, err
+	}
+	if err := out.add("OEBPS/style.css", []byte(epubStyle)); err != nil {
+		return nil, err
+	}
+
+	// 标题页
+	var cover strings.Builder
+	cover.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+	if w.Description != "" {
+		cover.WriteString("<p>" + html.EscapeString(w.Description) + "</p>\n")
+	}
+	if w.RepoURL != "" {
+		cover.WriteString(`<p class="meta">仓库: <a href="` + html.EscapeString(w.RepoURL) + `">` + html.EscapeString(w.RepoURL) + "</a></p>\n")
+	}
+	if w.Commit != "" {
+		cover.WriteString(`<p class="meta">提交: <code>` + html.EscapeString(w.Commit) + "</code></p>\n")
+	}
+	if err := out.add("OEBPS/text/title.xhtml", []byte(xhtmlDocument(title, language, "../", cover.String()))); err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		blocks := markdown.Parse(page.Content)
+		body := markdown.RenderHTML(blocks, renderOpts)
+		if len(blocks) == 0 || blocks[0].Kind != markdown.Heading || blocks[0].Level != 1 {
+			body = "<h1>" + html.EscapeString(page.Title) + "</h1>\n" + body
+		}
+		if err := out.add("OEBPS/"+chapter(page.ID), []byte(xhtmlDocument(page.Title, language, "../", body))); err != nil {
+			return nil, err
+		}
+	}
+
+	// EPUB 3 导航文档
+	var toc strings.Builder
+	toc.WriteString(`<nav epub:type="toc" id="toc">` + "\n<h1>目录</h1>\n<ol>\n")
+	toc.WriteString(`<li><a href="text/title.xhtml">` + html.EscapeString(title) + "</a></li>\n")
+	for _, section := range nav {
+		if section.Title != "" {
+			toc.WriteString("<li><span>" + html.EscapeString(section.Title) + "</span>\n<ol>\n")
+		}
+		for _, page := range section.Pages {
+			toc.WriteString(`<li><a href="` + chapter(page.ID) + `">` + html.EscapeString(page.Title) + "</a></li>\n")
+		}
+		if section.Title != "" {
+			toc.WriteString("</ol>\n</li>\n")
+		}
+	}
+	toc.WriteString("</ol>\n</nav>\n")
+	if err := out.add("OEBPS/nav.xhtml", []byte(xhtmlDocument("目录", language, "", toc.String()))); err != nil {
+		return nil, err
+	}
+
+	if err := out.add("OEBPS/content.opf", []byte(epubPackage(w, title, language, pages, chapter))); err != nil {
+		return nil, err
+	}
+	return out.bytes()
+}
+
+// epubPackage 生成 OPF 包文档：元数据、清单和阅读顺序
+func epubPackage(w *Wiki, title, language string, pages []models.WikiPage, chapter func(id string) string) string {
+	// 同一仓库和提交生成相同的标识符
+	identifier := uuid.NewSHA1(uuid.NameSpaceURL, []byte(w.RepoURL+"@"+w.Commit+"#"+language)).String()
+
+	var opf strings.Builder
+	opf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="` + html.EscapeString(language) + `">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&opf, "    <dc:identifier id=\"book-id\">urn:uuid:%s</dc:identifier>\n", identifier)
+	fmt.Fprintf(&opf, "    <dc:title>%s</dc:title>\n", html.EscapeString(title))
+	fmt.Fprintf(&opf, "    <dc:language>%s</dc:language>\n", html.EscapeString(language))
+	opf.WriteString("    <dc:creator>DeepWiki-Go</dc:creator>\n")
+	if w.RepoName != "" {
+		fmt.Fprintf(&opf, "    <dc:subject>%s</dc:subject>\n", html.EscapeString(w.RepoName))
+	}
+	if w.RepoURL != "" {
+		fmt.Fprintf(&opf, "    <dc:source>%s</dc:source>\n", html.EscapeString(w.RepoURL))
+	}
+	description := w.Description
+	if w.Commit != "" {
+		description = strings.TrimSpace(description + "\n" + w.RepoName + " @ " + w.Commit)
+		fmt.Fprintf(&opf, "    <meta property=\"dcterms:hasVersion\">%s</meta>\n", html.EscapeString(w.Commit))
+	}
+	if description != "" {
+		fmt.Fprintf(&opf, "    <dc:description>%s</dc:description>\n", html.EscapeString(description))
+	}
+	fmt.Fprintf(&opf, "    <meta property=\"dcterms:modified\">%s</meta>\n", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	opf.WriteString("  </metadata>\n  <manifest>\n")
+	opf.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	opf.WriteString(`    <item id="style" href="style.css" media-type="text/css"/>` + "\n")
+	opf.WriteString(`    <item id="title" href="text/title.xhtml" media-type="application/xhtml+xml"/>` + "\n")
+	for i, page := range pages {
+		fmt.Fprintf(&opf, "    <item id=\"page-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapter(page.ID))
+	}
+	opf.WriteString("  </manifest>\n  <spine>\n    <itemref idref=\"title\"/>\n    <itemref idref=\"nav\"/>\n")
+	for i := range pages {
+		fmt.Fprintf(&opf, "    <itemref idref=\"page-%d\"/>\n", i+1)
+	}
+	opf.WriteString("  </spine>\n</package>\n")
+	return opf.String()
+}
+
+// xhtmlDocument 生成 XHTML 内容文档，root 是样式表相对于文档的路径前缀
+func xhtmlDocument(title, language, root, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + html.EscapeString(language) + `" lang="` + html.EscapeString(language) + `">
+<head>
+<meta charset="utf-8"/>
+<title>` + html.EscapeString(title) + `</title>
+<link rel="stylesheet" type="text/css" href="` + root + `style.css"/>
+</head>
+<body>
+` + body + `</body>
+</html>
+`
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEPUB(t *testing.T) {
+	w := testWiki()
+	w.Pages[2].Content = "Text with a line break  \nand an image ![logo](https://example.com/logo.png).\n\n---\n\n| A | B |\n|---|---|\n| `x` | <y> |\n"
+	data, err := EPUB(w)
+	if err != nil {
+		t.Fatalf("epub: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Fatalf("mimetype must be the first, uncompressed entry: %s %d", first.Name, first.Method)
+	}
+
+	files := readZip(t, data)
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".xhtml") && !strings.HasSuffix(name, ".opf") && !strings.HasSuffix(name, ".xml") {
+			continue
+		}
+		// 所有内容文档都必须是格式良好的 XML
+		decoder := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s is not well-formed: %v\n%s", name, err, content)
+				break
+			}
+		}
+	}
+
+	opf := files["OEBPS/content.opf"]
+	for _, want := range []string{"<dc:title>demo</dc:title>", "<dc:source>https://github.com/acme/demo</dc:source>", `<meta property="dcterms:hasVersion">abc123</meta>`, "demo @ abc123", `properties="nav"`} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("content.opf missing %q:\n%s", want, opf)
+		}
+	}
+	spine := opf[strings.Index(opf, "<spine>"):]
+	if strings.Index(spine, "page-1") > strings.Index(spine, "page-2") || !strings.Contains(opf, `id="page-1" href="text/overview.xhtml"`) {
+		t.Errorf("spine should follow the navigation order:\n%s", opf)
+	}
+
+	nav := files["OEBPS/nav.xhtml"]
+	if !strings.Contains(nav, "<li><span>Introduction</span>\n<ol>\n<li><a href=\"text/overview.xhtml\">Overview</a></li>") {
+		t.Errorf("unexpected table of contents:\n%s", nav)
+	}
+	if !strings.Contains(files["OEBPS/text/overview.xhtml"], `href="architecture.xhtml#storage"`) {
+		t.Error("links between pages should point to chapter files")
+	}
+	if !strings.Contains(files["OEBPS/text/extra.xhtml"], `<a href="https://example.com/logo.png">logo</a>`) {
+		t.Error("remote images should become links")
+	}
+}
@@ -0,0 +1,210 @@
+// internal/export/export.go
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+// Wiki 描述要导出的 Wiki 页面和元数据
+type Wiki struct {
+	Title       string
+	Description string
+	RepoName    string
+	RepoURL     string
+	Ref         string // 生成时的分支或标签，仅导入时使用
+	Commit      string // 生成时的源码提交 SHA
+	Language    string
+	Pages       []models.WikiPage
+	Sections    []models.WikiSection // 大纲中的章节，为空时按页面的 Section 字段分组
+}
+
+// NavSection 表示导航中的一个章节及其页面
+type NavSection struct {
+	ID    string
+	Title string
+	Pages []models.WikiPage
+}
+
+// DisplayTitle 返回 Wiki 的标题，未设置时使用仓库名
+func (w *Wiki) DisplayTitle() string {
+	if w.Title != "" {
+		return w.Title
+	}
+	if w.RepoName != "" {
+		return w.RepoName
+	}
+	return "Wiki"
+}
+
+// Page 按ID查找页面
+func (w *Wiki) Page(id string) (models.WikiPage, bool) {
+	for _, page := range w.Pages {
+		if page.ID == id {
+			return page, true
+		}
+	}
+	return models.WikiPage{}, false
+}
+
+// Navigation 返回按章节组织的页面
+// 有大纲章节时按章节顺序排列，不属于任何章节的页面放在最后；否则按页面的 Section 字段分组，保持页面顺序
+func (w *Wiki) Navigation() []NavSection {
+	placed := make(map[string]bool)
+	var nav []NavSection
+
+	if len(w.Sections) > 0 {
+		for _, section := range w.Sections {
+			ns := NavSection{ID: section.ID, Title: section.Title}
+			for _, id := range section.Pages {
+				if page, ok := w.Page(id); ok && !placed[id] {
+					ns.Pages = append(ns.Pages, page)
+					placed[id] = true
+				}
+			}
+			if len(ns.Pages) > 0 {
+				nav = append(nav, ns)
+			}
+		}
+	} else {
+		index := make(map[string]int)
+		for _, page := range w.Pages {
+			if page.Section == "" {
+				continue
+			}
+			i, ok := index[page.Section]
+			if !ok {
+				i = len(nav)
+				index[page.Section] = i
+				nav = append(nav, NavSection{ID: page.Section, Title: page.Section})
+			}
+			nav[i].Pages = append(nav[i].Pages, page)
+			placed[page.ID] = true
+		}
+	}
+
+	var rest []models.WikiPage
+	for _, page := range w.Pages {
+		if !placed[page.ID] {
+			rest = append(rest, page)
+		}
+	}
+	if len(rest) > 0 {
+		// 没有章节信息时只有一个不带标题的分组
+		nav = append(nav, NavSection{Pages: rest})
+	}
+	return nav
+}
+
+// Ordered 返回按导航顺序排列的页面
+func (w *Wiki) Ordered() []models.WikiPage {
+	var pages []models.WikiPage
+	for _, section := range w.Navigation() {
+		pages = append(pages, section.Pages...)
+	}
+	return pages
+}
+
+// unsafeNameChars 匹配不适合出现在文件名中的字符
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// FileNames 为每个页面生成不重复、不含扩展名的文件名
+func (w *Wiki) FileNames() map[string]string {
+	names := make(map[string]string, len(w.Pages))
+	used := make(map[string]bool, len(w.Pages))
+	for i, page := range w.Pages {
+		base := strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(page.ID), "-"), "-")
+		if base == "" || base == "index" {
+			base = fmt.Sprintf("page-%d", i+1)
+		}
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		names[page.ID] = name
+	}
+	return names
+}
+
+// PageLink 判断链接是否指向 Wiki 中的其他页面，是则返回页面ID和页面内锚点
+// 支持 #page-id、page-id、page-id.md 和 ./page-id.md#anchor 等形式
+func (w *Wiki) PageLink(dest string) (id, anchor string, ok bool) {
+	if dest == "" || strings.Contains(dest, "://") || strings.HasPrefix(dest, "mailto:") {
+		return "", "", false
+	}
+	target := dest
+	if i := strings.Index(target, "#"); i >= 0 {
+		target, anchor = target[:i], target[i+1:]
+	}
+	if target == "" {
+		// 只有锚点时，锚点是页面ID才视为页面链接
+		if _, exists := w.Page(anchor); exists {
+			return anchor, "", true
+		}
+		return "", "", false
+	}
+
+	target = strings.TrimPrefix(path.Clean("/"+target), "/")
+	for _, ext := range []string{".md", ".html", ".xhtml"} {
+		target = strings.TrimSuffix(target, ext)
+	}
+	if _, exists := w.Page(target); exists {
+		return target, anchor, true
+	}
+	if _, exists := w.Page(path.Base(target)); exists {
+		return path.Base(target), anchor, true
+	}
+	return "", "", false
+}
+
+// archive 是写入 zip 包的辅助类型
+type archive struct {
+	buf bytes.Buffer
+	zw  *zip.Writer
+	mod time.Time
+}
+
+// newArchive 创建新的 zip 包，所有文件使用相同的修改时间
+func newArchive() *archive {
+	a := &archive{mod: time.Now().UTC()}
+	a.zw = zip.NewWriter(&a.buf)
+	return a
+}
+
+// add 向包中写入一个压缩文件
+func (a *archive) add(name string, data []byte) error {
+	return a.write(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.mod}, data)
+}
+
+// store 向包中写入一个不压缩的文件
+func (a *archive) store(name string, data []byte) error {
+	return a.write(&zip.FileHeader{Name: name, Method: zip.Store, Modified: a.mod}, data)
+}
+
+// write 按给定的文件头写入文件
+func (a *archive) write(header *zip.FileHeader, data []byte) error {
+	w, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", header.Name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", header.Name, err)
+	}
+	return nil
+}
+
+// bytes 关闭 zip 包并返回其内容
+func (a *archive) bytes() ([]byte, error) {
+	if err := a.zw.Close(); err != nil {
+		return nil, fmt.Errorf("生成压缩包失败: %v", err)
+	}
+	return a.buf.Bytes(), nil
+}
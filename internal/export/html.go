@@ -0,0 +1,231 @@
+// internal/export/html.go
+package export
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/markdown"
+)
+
+//go:embed site
+var siteFiles embed.FS
+
+// siteTemplates 是静态站点的页面模板
+var siteTemplates = template.Must(template.ParseFS(siteFiles, "site/layout.html"))
+
+// maxSearchTextChars 是搜索索引中每个页面保留的最大文本长度
+const maxSearchTextChars = 20000
+
+// SiteOptions 控制静态站点导出
+type SiteOptions struct {
+	// MermaidScript 是 mermaid.min.js 的内容，会打包到站点中在浏览器端渲染图表；为空时图表以源码显示
+	MermaidScript []byte
+}
+
+// searchEntry 是搜索索引中的一个页面
+type searchEntry struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Section  string   `json:"section,omitempty"`
+	URL      string   `json:"url"`
+	Headings []string `json:"headings,omitempty"`
+	Text     string   `json:"text"`
+}
+
+// siteInfo 是所有页面共用的模板数据
+type siteInfo struct {
+	Title       string
+	Description string
+	RepoURL     string
+	Commit      string
+	Language    string
+	Generated   string
+	Mermaid     bool
+	Nav         []navSectionView
+}
+
+// navSectionView 和 navPageView 是导航的模板数据
+type navSectionView struct {
+	Title string
+	Pages []navPageView
+}
+
+type navPageView struct {
+	Title  string
+	URL    string
+	Active bool
+	High   bool
+}
+
+// pageView 是单个页面的模板数据
+type pageView struct {
+	Site       siteInfo
+	Title      string
+	Importance string
+	Files      []string
+	Body       template.HTML
+	Related    []navPageView
+}
+
+// HTMLSite 将 Wiki 渲染为可直接部署到静态服务器的站点，返回 zip 包
+// 包含 index.html、每个页面的 HTML 文件、样式和搜索脚本以及 search_index.json，不引用任何外部资源
+func HTMLSite(w *Wiki, opts SiteOptions) ([]byte, error) {
+	names := w.FileNames()
+	pageURL := func(id, anchor string) string {
+		url := names[id] + ".html"
+		if anchor != "" {
+			url += "#" + anchor
+		}
+		return url
+	}
+
+	info := siteInfo{
+		Title:       w.DisplayTitle(),
+		Description: w.Description,
+		RepoURL:     w.RepoURL,
+		Commit:      w.Commit,
+		Language:    w.Language,
+		Generated:   time.Now().UTC().Format("2006-01-02"),
+		Mermaid:     len(opts.MermaidScript) > 0,
+	}
+	if info.Language == "" {
+		info.Language = "zh"
+	}
+	nav := w.Navigation()
+	navFor := func(active string) []navSectionView {
+		views := make([]navSectionView, 0, len(nav))
+		for _, section := range nav {
+			view := navSectionView{Title: section.Title}
+			for _, page := range section.Pages {
+				view.Pages = append(view.Pages, navPageView{
+					Title:  page.Title,
+					URL:    pageURL(page.ID, ""),
+					Active: page.ID == active,
+					High:   page.Importance == "high",
+				})
+			}
+			views = append(views, view)
+		}
+		return views
+	}
+
+	renderOpts := markdown.HTMLOptions{
+		HeadingIDs: true,
+		LinkURL: func(dest string) string {
+			if id, anchor, ok := w.PageLink(dest); ok {
+				return pageURL(id, anchor)
+			}
+			return dest
+		},
+		CodeBlock: func(lang, code string) (string, bool) {
+			if lang != "mermaid" {
+				return "", false
+			}
+			return `<pre class="mermaid">` + html.EscapeString(code) + "</pre>", true
+		},
+	}
+
+	out := newArchive()
+	sections := make(map[string]string)
+	for _, section := range nav {
+		for _, page := range section.Pages {
+			sections[page.ID] = section.Title
+		}
+	}
+
+	var index []searchEntry
+	for _, page := range w.Ordered() {
+		blocks := markdown.Parse(page.Content)
+		site := info
+		site.Nav = navFor(page.ID)
+		view := pageView{
+			Site:       site,
+			Title:      page.Title + " - " + info.Title,
+			Importance: page.Importance,
+			Files:      page.FilePaths,
+			Related:    relatedViews(w, page, pageURL),
+		}
+
+		// 页面正文没有一级标题时补充页面标题
+		body := markdown.RenderHTML(blocks, renderOpts)
+		if len(blocks) == 0 || blocks[0].Kind != markdown.Heading || blocks[0].Level != 1 {
+			body = "<h1>" + html.EscapeString(page.Title) + "</h1>\n" + body
+		}
+		view.Body = template.HTML(body)
+
+		if err := renderTemplate(out, names[page.ID]+".html", "page", view); err != nil {
+			return nil, err
+		}
+
+		entry := searchEntry{
+			ID:      page.ID,
+			Title:   page.Title,
+			Section: sections[page.ID],
+			URL:     pageURL(page.ID, ""),
+			Text:    markdown.DocumentText(blocks),
+		}
+		for _, heading := range markdown.Headings(blocks) {
+			entry.Headings = append(entry.Headings, heading.Text)
+		}
+		if len(entry.Text) > maxSearchTextChars {
+			entry.Text = strings.ToValidUTF8(entry.Text[:maxSearchTextChars], "")
+		}
+		index = append(index, entry)
+	}
+
+	site := info
+	site.Nav = navFor("")
+	if err := renderTemplate(out, "index.html", "index", pageView{Site: site, Title: info.Title}); err != nil {
+		return nil, err
+	}
+
+	indexJSON, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化搜索索引失败: %v", err)
+	}
+	if err := out.add("search_index.json", indexJSON); err != nil {
+		return nil, err
+	}
+	for _, asset := range []string{"style.css", "search.js"} {
+		content, err := siteFiles.ReadFile("site/" + asset)
+		if err != nil {
+			return nil, err
+		}
+		if err := out.add("assets/"+asset, content); err != nil {
+			return nil, err
+		}
+	}
+	if info.Mermaid {
+		if err := out.add("assets/mermaid.min.js", opts.MermaidScript); err != nil {
+			return nil, err
+		}
+	}
+	return out.bytes()
+}
+
+// relatedViews 返回页面的相关页面链接，忽略不存在的页面
+func relatedViews(w *Wiki, page models.WikiPage, pageURL func(id, anchor string) string) []navPageView {
+	var related []navPageView
+	for _, id := range page.RelatedPages {
+		if other, ok := w.Page(id); ok && id != page.ID {
+			related = append(related, navPageView{Title: other.Title, URL: pageURL(id, "")})
+		}
+	}
+	return related
+}
+
+// renderTemplate 渲染模板并写入压缩包
+func renderTemplate(out *archive, name, tmpl string, data interface{}) error {
+	var buf strings.Builder
+	if err := siteTemplates.ExecuteTemplate(&buf, tmpl, data); err != nil {
+		return fmt.Errorf("渲染 %s 失败: %v", name, err)
+	}
+	return out.add(name, []byte(buf.String()))
+}
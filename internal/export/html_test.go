@@ -0,0 +1,86 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+// testWiki 返回包含章节、页面间链接和 Mermaid 图表的 Wiki
+func testWiki() *Wiki {
+	return &Wiki{
+		RepoName: "demo",
+		RepoURL:  "https://github.com/acme/demo",
+		Commit:   "abc123",
+		Pages: []models.WikiPage{
+			{ID: "overview", Title: "Overview", Importance: "high", RelatedPages: []string{"architecture", "missing"},
+				Content: "# Overview\n\nSee [architecture](#architecture) and [storage](./architecture.md#storage).\n"},
+			{ID: "architecture", Title: "Architecture", Importance: "medium", FilePaths: []string{"main.go"},
+				Content: "# Architecture\n\n## Storage\n\n```mermaid\ngraph TD\n  A --> B\n```\n"},
+			{ID: "extra", Title: "Extra", Content: "No heading here."},
+		},
+		Sections: []models.WikiSection{{ID: "intro", Title: "Introduction", Pages: []string{"overview", "architecture"}}},
+	}
+}
+
+// readZip 读取 zip 包中的所有文件
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestHTMLSite(t *testing.T) {
+	data, err := HTMLSite(testWiki(), SiteOptions{MermaidScript: []byte("/* mermaid */")})
+	if err != nil {
+		t.Fatalf("html site: %v", err)
+	}
+	files := readZip(t, data)
+	for _, name := range []string{"index.html", "overview.html", "architecture.html", "extra.html", "search_index.json", "assets/style.css", "assets/search.js", "assets/mermaid.min.js"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	overview := files["overview.html"]
+	for _, want := range []string{`href="architecture.html">architecture</a>`, `href="architecture.html#storage"`, `class="active importance-high"`, "<h2>相关页面</h2>", "Introduction"} {
+		if !strings.Contains(overview, want) {
+			t.Errorf("overview.html missing %q", want)
+		}
+	}
+	if strings.Contains(overview, "missing.html") || strings.Contains(overview, `src="http`) || strings.Contains(overview, `href="http`) {
+		t.Error("overview.html should not reference unknown pages or external resources")
+	}
+	if !strings.Contains(files["architecture.html"], "<pre class=\"mermaid\">graph TD\n  A --&gt; B</pre>") {
+		t.Errorf("mermaid block not rendered for the client:\n%s", files["architecture.html"])
+	}
+	if !strings.Contains(files["extra.html"], "<h1>Extra</h1>") {
+		t.Error("pages without a heading should get the page title")
+	}
+
+	var index []searchEntry
+	if err := json.Unmarshal([]byte(files["search_index.json"]), &index); err != nil || len(index) != 3 {
+		t.Fatalf("unexpected search index: %v %v", index, err)
+	}
+	if index[1].URL != "architecture.html" || index[1].Section != "Introduction" || len(index[1].Headings) != 2 {
+		t.Errorf("unexpected search entry: %+v", index[1])
+	}
+}
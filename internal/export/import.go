@@ -0,0 +1,415 @@
+// internal/export/import.go
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/markdown"
+	"gopkg.in/yaml.v3"
+)
+
+// maxImportFileSize 是 zip 包中单个文件解压后的最大字节数
+const maxImportFileSize = 16 << 20
+
+// maxImportTotalSize 是 zip 包中所有 Markdown 文件解压后的总字节数上限
+const maxImportTotalSize = 64 << 20
+
+// jsonDocument 是可导入的 JSON 对象，兼容导出请求和已保存的 Wiki
+type jsonDocument struct {
+	RepoURL     string               `json:"repo_url"`
+	Ref         string               `json:"ref"`
+	Commit      string               `json:"commit"`
+	Language    string               `json:"language"`
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Sections    []models.WikiSection `json:"sections"`
+	Plan        *models.WikiPlan     `json:"plan"`
+	Pages       []models.WikiPage    `json:"pages"`
+
+	// 以下字段出现在已保存的 Wiki 或导出请求中，导入时忽略；列出它们是为了拒绝其他未知字段
+	ID             json.RawMessage `json:"id"`
+	RepoKey        json.RawMessage `json:"repo_key"`
+	Provider       json.RawMessage `json:"provider"`
+	Model          json.RawMessage `json:"model"`
+	Mode           json.RawMessage `json:"mode"`
+	GeneratedAt    json.RawMessage `json:"generated_at"`
+	PageStatus     json.RawMessage `json:"page_status"`
+	Revisions      json.RawMessage `json:"revisions"`
+	PromptVersions json.RawMessage `json:"prompt_versions"`
+	TranslatedFrom json.RawMessage `json:"translated_from"`
+	Format         json.RawMessage `json:"format"`
+	Push           json.RawMessage `json:"push"`
+	WikiID         json.RawMessage `json:"wiki_id"`
+	PerPage        json.RawMessage `json:"per_page"`
+	FrontMatter    json.RawMessage `json:"front_matter"`
+	Header         json.RawMessage `json:"header"`
+	Footer         json.RawMessage `json:"footer"`
+	Branch         json.RawMessage `json:"branch"`
+	Orphan         json.RawMessage `json:"orphan"`
+	Dir            json.RawMessage `json:"dir"`
+	GitHubToken    json.RawMessage `json:"github_token"`
+	GitLabToken    json.RawMessage `json:"gitlab_token"`
+}
+
+// jsonExport 是 json 格式导出的文档，字段与 jsonDocument 一致，导出结果可以直接重新导入
+type jsonExport struct {
+	RepoURL     string               `json:"repo_url,omitempty"`
+	Ref         string               `json:"ref,omitempty"`
+	Commit      string               `json:"commit,omitempty"`
+	Language    string               `json:"language,omitempty"`
+	Title       string               `json:"title,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Sections    []models.WikiSection `json:"sections,omitempty"`
+	Pages       []models.WikiPage    `json:"pages"`
+}
+
+// JSON 将 Wiki 导出为包含元数据和页面的 JSON 文档，ParseJSON 可以解析该文档
+func JSON(w *Wiki) ([]byte, error) {
+	pages := w.Pages
+	if pages == nil {
+		pages = []models.WikiPage{}
+	}
+	return json.MarshalIndent(jsonExport{
+		RepoURL:     w.RepoURL,
+		Ref:         w.Ref,
+		Commit:      w.Commit,
+		Language:    w.Language,
+		Title:       w.Title,
+		Description: w.Description,
+		Sections:    w.Sections,
+		Pages:       pages,
+	}, "", "  ")
+}
+
+// IsZip 判断数据是否是 zip 包
+func IsZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// ParseJSON 解析 JSON 导出
+// 支持 json 格式导出的文档、旧版导出的页面数组，以及包含 pages 字段的对象（导出请求或已保存的 Wiki）
+func ParseJSON(data []byte) (*Wiki, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("内容为空")
+	}
+
+	if data[0] == '[' {
+		var pages []models.WikiPage
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&pages); err != nil {
+			return nil, fmt.Errorf("解析页面数组失败: %v", err)
+		}
+		return &Wiki{Pages: pages}, nil
+	}
+
+	var doc jsonDocument
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析 JSON 失败: %v", err)
+	}
+	w := &Wiki{
+		Title:       doc.Title,
+		Description: doc.Description,
+		RepoURL:     doc.RepoURL,
+		Ref:         doc.Ref,
+		Commit:      doc.Commit,
+		Language:    doc.Language,
+		Pages:       doc.Pages,
+		Sections:    doc.Sections,
+	}
+	if doc.Plan != nil {
+		if w.Title == "" {
+			w.Title = doc.Plan.Title
+		}
+		if w.Description == "" {
+			w.Description = doc.Plan.Description
+		}
+		if len(w.Sections) == 0 {
+			w.Sections = doc.Plan.Sections
+		}
+	}
+	return w, nil
+}
+
+// ParseMarkdownZip 解析逐页 Markdown 导出的 zip 包
+// 页面元数据来自 YAML 头信息，章节和页面顺序来自 README.md 索引；指向其他页面文件的链接改回页面ID，
+// 与 opts 中页脚模板匹配的页脚会被去掉
+func ParseMarkdownZip(data []byte, opts MarkdownOptions) (*Wiki, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("读取 zip 包失败: %v", err)
+	}
+
+	files := make(map[string]string)
+	var names []string
+	var total int64 // 已解压的总字节数，按实际读取的内容计算，不依赖 zip 头中声明的大小
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), ".md") {
+			continue
+		}
+		if f.UncompressedSize64 > maxImportFileSize {
+			return nil, fmt.Errorf("文件 %s 过大", f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxImportFileSize+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %v", f.Name, err)
+		}
+		if len(content) > maxImportFileSize {
+			return nil, fmt.Errorf("文件 %s 过大", f.Name)
+		}
+		if total += int64(len(content)); total > maxImportTotalSize {
+			return nil, fmt.Errorf("zip 包解压后超过 %d MB", maxImportTotalSize>>20)
+		}
+		name := path.Base(f.Name)
+		if _, dup := files[name]; dup {
+			return nil, fmt.Errorf("zip 包中有重名文件 %s", name)
+		}
+		files[name] = strings.ReplaceAll(string(content), "\r\n", "\n")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	footer := footerPattern(opts.Footer)
+	w := &Wiki{}
+	ids := make(map[string]string) // 文件名（不含扩展名） -> 页面ID
+	index := ""
+	for _, name := range names {
+		content := files[name]
+		if footer != nil {
+			content = footer.ReplaceAllString(content, "\n")
+		}
+		base := strings.TrimSuffix(name, path.Ext(name))
+		if strings.EqualFold(base, "readme") || strings.EqualFold(base, "index") {
+			index = content
+			continue
+		}
+
+		meta, body, err := splitFrontMatter(content)
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 的头信息失败: %v", name, err)
+		}
+		page := models.WikiPage{
+			ID:           meta.ID,
+			Title:        meta.Title,
+			Content:      strings.TrimRight(body, "\n") + "\n",
+			FilePaths:    meta.FilePaths,
+			Importance:   meta.Importance,
+			RelatedPages: meta.RelatedPages,
+		}
+		if page.ID == "" {
+			page.ID = base
+		}
+		if page.Title == "" {
+			page.Title = firstHeading(body)
+		}
+		if page.Title == "" {
+			page.Title = page.ID
+		}
+		if w.Commit == "" {
+			w.Commit = meta.Commit
+		}
+		ids[base] = page.ID
+		w.Pages = append(w.Pages, page)
+	}
+
+	// 导出时页面间的链接改成了 <文件名>.md，改回页面ID
+	for i := range w.Pages {
+		w.Pages[i].Content = markdown.RewriteLinks(w.Pages[i].Content, func(dest string) string {
+			target, anchor := dest, ""
+			if j := strings.Index(target, "#"); j >= 0 {
+				target, anchor = target[:j], target[j+1:]
+			}
+			if strings.Contains(target, "://") || !strings.HasSuffix(target, ".md") {
+				return dest
+			}
+			id, ok := ids[strings.TrimSuffix(path.Base(target), ".md")]
+			if !ok {
+				return dest
+			}
+			if anchor == "" {
+				return "#" + id
+			}
+			return id + ".md#" + anchor
+		})
+	}
+
+	if index != "" {
+		w.readIndex(index, ids)
+	}
+	return w, nil
+}
+
+// readIndex 从 README.md 索引中读取标题、章节和页面顺序
+func (w *Wiki) readIndex(index string, ids map[string]string) {
+	order := make(map[string]int)
+	current := -1 // 当前章节在 w.Sections 中的位置
+	for _, block := range markdown.Parse(index) {
+		switch {
+		case block.Kind == markdown.Heading && block.Level == 1 && w.Title == "":
+			w.Title = markdown.PlainText(block.Text)
+		case block.Kind == markdown.Heading && block.Level == 2:
+			title := markdown.PlainText(block.Text)
+			id := markdown.Slugify(title)
+			if id == "" {
+				id = fmt.Sprintf("section-%d", len(w.Sections)+1)
+			}
+			w.Sections = append(w.Sections, models.WikiSection{ID: id, Title: title})
+			current = len(w.Sections) - 1
+		case block.Kind == markdown.Rule:
+			current = -1
+		case block.Kind == markdown.List:
+			for _, item := range block.Items {
+				if len(item) == 0 {
+					continue
+				}
+				for _, m := range indexLinkPattern.FindAllStringSubmatch(item[0].Text, -1) {
+					id, ok := ids[strings.TrimSuffix(path.Base(m[1]), ".md")]
+					if !ok {
+						continue
+					}
+					if _, seen := order[id]; !seen {
+						order[id] = len(order)
+					}
+					if current >= 0 {
+						w.Sections[current].Pages = append(w.Sections[current].Pages, id)
+					}
+				}
+			}
+		}
+	}
+
+	// 没有章节的索引只决定页面顺序，未列出的页面排在最后
+	sort.SliceStable(w.Pages, func(i, j int) bool {
+		oi, iok := order[w.Pages[i].ID]
+		oj, jok := order[w.Pages[j].ID]
+		if iok != jok {
+			return iok
+		}
+		return oi < oj
+	})
+	var sections []models.WikiSection
+	for _, s := range w.Sections {
+		if len(s.Pages) > 0 {
+			sections = append(sections, s)
+		}
+	}
+	w.Sections = sections
+}
+
+// indexLinkPattern 匹配索引中指向页面文件的链接
+var indexLinkPattern = regexp.MustCompile(`\]\(([^)#\s]+\.md)\)`)
+
+// splitFrontMatter 分离 Markdown 开头的 YAML 头信息和正文
+func splitFrontMatter(content string) (frontMatter, string, error) {
+	var meta frontMatter
+	if !strings.HasPrefix(content, "---\n") {
+		return meta, content, nil
+	}
+	end := strings.Index(content[4:], "\n---\n")
+	if end < 0 {
+		return meta, content, nil
+	}
+	if err := yaml.Unmarshal([]byte(content[4:4+end]), &meta); err != nil {
+		return meta, "", err
+	}
+	return meta, strings.TrimLeft(content[4+end+5:], "\n"), nil
+}
+
+// firstHeading 返回文档第一个一级标题的纯文本
+func firstHeading(content string) string {
+	for _, block := range markdown.Parse(content) {
+		if block.Kind == markdown.Heading && block.Level == 1 {
+			return markdown.PlainText(block.Text)
+		}
+	}
+	return ""
+}
+
+// footerPattern 将页脚模板转换为匹配文件末尾页脚的正则表达式，占位符匹配任意文本
+func footerPattern(footer string) *regexp.Regexp {
+	footer = strings.TrimRight(footer, "\n")
+	if footer == "" {
+		return nil
+	}
+	quoted := regexp.QuoteMeta(footer)
+	quoted = regexp.MustCompile(`\\\{[a-z_]+\\\}`).ReplaceAllString(quoted, ".*?")
+	return regexp.MustCompile(`\n+---\n\n` + quoted + `\n*$`)
+}
+
+// Validate 检查导入的 Wiki，problems 中的问题会导致导入失败，warnings 只作提示
+func (w *Wiki) Validate() (problems, warnings []string) {
+	if len(w.Pages) == 0 {
+		return []string{"没有页面"}, nil
+	}
+
+	ids := make(map[string]bool, len(w.Pages))
+	for i, page := range w.Pages {
+		label := fmt.Sprintf("第 %d 个页面", i+1)
+		if page.ID != "" {
+			label = "页面 " + page.ID
+		}
+		switch {
+		case strings.TrimSpace(page.ID) == "":
+			problems = append(problems, label+" 缺少 id")
+		case page.ID != strings.TrimSpace(page.ID) || strings.ContainsAny(page.ID, "/\\#?\n"):
+			problems = append(problems, label+" 的 id 包含不允许的字符")
+		case ids[page.ID]:
+			problems = append(problems, label+" 的 id 重复")
+		}
+		ids[page.ID] = true
+		if strings.TrimSpace(page.Title) == "" {
+			problems = append(problems, label+" 缺少 title")
+		}
+		switch strings.ToLower(page.Importance) {
+		case "", "high", "medium", "low":
+		default:
+			problems = append(problems, fmt.Sprintf("%s 的 importance 无效: %s", label, page.Importance))
+		}
+		if strings.TrimSpace(page.Content) == "" {
+			warnings = append(warnings, label+" 没有内容")
+		}
+	}
+
+	for _, page := range w.Pages {
+		for _, related := range page.RelatedPages {
+			if !ids[related] {
+				warnings = append(warnings, fmt.Sprintf("页面 %s 的相关页面 %s 不存在", page.ID, related))
+			}
+		}
+	}
+	sectionIDs := make(map[string]bool, len(w.Sections))
+	for _, section := range w.Sections {
+		if section.ID == "" || sectionIDs[section.ID] {
+			problems = append(problems, fmt.Sprintf("章节 %q 的 id 缺失或重复", section.Title))
+		}
+		sectionIDs[section.ID] = true
+		for _, id := range section.Pages {
+			if !ids[id] {
+				warnings = append(warnings, fmt.Sprintf("章节 %s 中的页面 %s 不存在", section.ID, id))
+			}
+		}
+	}
+	for _, page := range w.Pages {
+		if page.Section != "" && len(w.Sections) > 0 && !sectionIDs[page.Section] {
+			warnings = append(warnings, fmt.Sprintf("页面 %s 的章节 %s 不存在", page.ID, page.Section))
+		}
+	}
+	return problems, warnings
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+func TestParseMarkdownZip(t *testing.T) {
+	original := testWiki()
+	opts := MarkdownOptions{Footer: "Generated from {repo} @ {commit}", FrontMatter: true}
+	data, err := MarkdownPages(original, opts)
+	if err != nil {
+		t.Fatalf("markdown pages: %v", err)
+	}
+	if !IsZip(data) {
+		t.Fatal("export should be a zip")
+	}
+
+	w, err := ParseMarkdownZip(data, opts)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if w.Title != "demo" || w.Commit != "abc123" {
+		t.Errorf("unexpected metadata: %q %q", w.Title, w.Commit)
+	}
+	var ids []string
+	for _, page := range w.Pages {
+		ids = append(ids, page.ID)
+	}
+	if strings.Join(ids, " ") != "overview architecture extra" {
+		t.Errorf("unexpected page order: %v", ids)
+	}
+	if len(w.Sections) != 1 || w.Sections[0].Title != "Introduction" || strings.Join(w.Sections[0].Pages, " ") != "overview architecture" {
+		t.Errorf("unexpected sections: %+v", w.Sections)
+	}
+
+	overview := w.Pages[0]
+	if overview.Importance != "high" || strings.Join(overview.RelatedPages, " ") != "architecture missing" {
+		t.Errorf("front matter not restored: %+v", overview)
+	}
+	if !strings.Contains(overview.Content, "[architecture](#architecture) and [storage](architecture.md#storage)") {
+		t.Errorf("page links not restored:\n%s", overview.Content)
+	}
+	if strings.Contains(overview.Content, "Generated from") || strings.HasPrefix(overview.Content, "---") {
+		t.Errorf("footer or front matter left in content:\n%s", overview.Content)
+	}
+
+	problems, warnings := w.Validate()
+	if len(problems) != 0 || len(warnings) != 1 || !strings.Contains(warnings[0], "missing") {
+		t.Errorf("unexpected validation: %v %v", problems, warnings)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	pages, _ := json.Marshal(testWiki().Pages)
+	w, err := ParseJSON(pages)
+	if err != nil || len(w.Pages) != 3 {
+		t.Fatalf("parse page array: %v", err)
+	}
+	if _, err := ParseJSON([]byte(`[{"id": "a", "title": "A", "bogus": 1}]`)); err == nil {
+		t.Error("unknown page fields should be rejected")
+	}
+
+	w, err = ParseJSON([]byte(`{"repo_url": "https://github.com/acme/demo", "commit": "abc", "plan": {"title": "Demo", "sections": [{"id": "s", "title": "S", "pages": ["a"]}]},
+		"pages": [{"id": "a", "title": "A", "content": "x"}, {"id": "a", "title": "", "content": "y", "importance": "urgent"}]}`))
+	if err != nil {
+		t.Fatalf("parse stored wiki: %v", err)
+	}
+	if w.Title != "Demo" || len(w.Sections) != 1 || w.RepoURL == "" {
+		t.Errorf("unexpected wiki: %+v", w)
+	}
+	if _, err := ParseJSON([]byte(`{"commit": "abc", "pages": [{"id": "a", "title": "A"}], "bogus": 1}`)); err == nil {
+		t.Error("unknown wiki fields should be rejected")
+	}
+	// 已保存的 Wiki 中的其他字段可以原样导入
+	stored, _ := json.Marshal(models.Wiki{ID: "main", RepoKey: "github.com_acme_demo", Provider: "openai", Pages: testWiki().Pages})
+	if _, err := ParseJSON(stored); err != nil {
+		t.Errorf("stored wiki rejected: %v", err)
+	}
+	problems, _ := w.Validate()
+	if len(problems) != 3 {
+		t.Errorf("expected duplicate id, missing title and bad importance, got %v", problems)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	wiki := testWiki()
+	wiki.Ref, wiki.Language, wiki.Title, wiki.Description = "main", "en", "Demo", "A demo wiki"
+	data, err := JSON(wiki)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := ParseJSON(data)
+	if err != nil {
+		t.Fatalf("parse json export: %v", err)
+	}
+	if w.RepoURL != wiki.RepoURL || w.Ref != "main" || w.Commit != wiki.Commit || w.Language != "en" ||
+		w.Title != "Demo" || w.Description != "A demo wiki" || len(w.Sections) != 1 || len(w.Pages) != 3 {
+		t.Errorf("metadata lost in round trip: %+v", w)
+	}
+	if w.Pages[1].FilePaths[0] != "main.go" || w.Pages[0].RelatedPages[0] != "architecture" {
+		t.Errorf("page fields lost in round trip: %+v", w.Pages)
+	}
+}
+
+func TestParseMarkdownZipSizeLimit(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	page := strings.Repeat("a", 8<<20)
+	for i := 0; i < maxImportTotalSize/len(page)+1; i++ {
+		f, _ := zw.Create(fmt.Sprintf("page-%d.md", i))
+		f.Write([]byte(page))
+	}
+	zw.Close()
+	if _, err := ParseMarkdownZip(buf.Bytes(), MarkdownOptions{}); err == nil || !strings.Contains(err.Error(), "MB") {
+		t.Errorf("expected the total size limit to be enforced, got %v", err)
+	}
+}
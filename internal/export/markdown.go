@@ -0,0 +1,233 @@
+// internal/export/markdown.go
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deepwiki-go/pkg/markdown"
+)
+
+// MarkdownOptions 控制 Markdown 导出
+// Header 和 Footer 支持占位符 {title}、{description}、{repo}、{repo_url}、{commit} 和 {language}
+type MarkdownOptions struct {
+	Header      string // 文档开头的内容，为空时使用 Wiki 标题和简介
+	Footer      string // 文档末尾的内容，为空时不添加
+	TOCTitle    string // 目录标题，为空时按语言使用“目录”或“Contents”
+	FrontMatter bool   // 添加 YAML 头信息：单个文档为 Wiki 信息，逐页文件为页面信息
+}
+
+// expand 替换页眉页脚中的占位符
+func (o MarkdownOptions) expand(w *Wiki, text string) string {
+	return strings.NewReplacer(
+		"{title}", w.DisplayTitle(),
+		"{description}", w.Description,
+		"{repo}", w.RepoName,
+		"{repo_url}", w.RepoURL,
+		"{commit}", w.Commit,
+		"{language}", w.Language,
+	).Replace(text)
+}
+
+// header 返回展开后的页眉，未配置时为 Wiki 标题和简介
+func (o MarkdownOptions) header(w *Wiki) string {
+	if o.Header != "" {
+		return strings.TrimRight(o.expand(w, o.Header), "\n") + "\n\n"
+	}
+	header := "# " + w.DisplayTitle() + "\n\n"
+	if w.Description != "" {
+		header += w.Description + "\n\n"
+	}
+	return header
+}
+
+// footer 返回展开后的页脚，前面以分隔线与正文隔开
+func (o MarkdownOptions) footer(w *Wiki) string {
+	if o.Footer == "" {
+		return ""
+	}
+	return "\n---\n\n" + strings.TrimRight(o.expand(w, o.Footer), "\n") + "\n"
+}
+
+// tocTitle 返回目录标题
+func (o MarkdownOptions) tocTitle(w *Wiki) string {
+	if o.TOCTitle != "" {
+		return o.TOCTitle
+	}
+	if w.Language == "" || strings.HasPrefix(w.Language, "zh") {
+		return "目录"
+	}
+	return "Contents"
+}
+
+// pageSection 是单个文档中一个页面的内容和锚点
+type pageSection struct {
+	body    string
+	anchor  string            // 页面标题的锚点
+	anchors map[string]string // 页面内原有锚点 -> 文档中的锚点
+}
+
+// Markdown 将 Wiki 导出为单个 Markdown 文档
+// 每个页面的标题降为二级标题，目录和页面间链接使用与 GitHub 一致的标题锚点（包括重复标题的 -1、-2 后缀）
+func Markdown(w *Wiki, opts MarkdownOptions) (string, error) {
+	nav := w.Navigation()
+	header := opts.header(w)
+	tocTitle := opts.tocTitle(w)
+
+	// 按文档中出现的顺序为所有标题生成锚点
+	slugs := markdown.NewSlugger()
+	for _, heading := range markdown.Headings(markdown.Parse(header)) {
+		slugs.Slug(heading.Text)
+	}
+	slugs.Slug(tocTitle)
+
+	pages := w.Ordered()
+	sections := make(map[string]*pageSection, len(pages))
+	for _, page := range pages {
+		body := demoteHeadings(pageHeading(page, page.Content))
+		local := markdown.Headings(markdown.Parse(page.Content))
+		global := markdown.Headings(markdown.Parse(body))
+		// 补充的页面标题位于页面原有标题之前
+		offset := len(global) - len(local)
+
+		section := &pageSection{body: body, anchors: make(map[string]string, len(local))}
+		for i, heading := range global {
+			anchor := slugs.Slug(heading.Text)
+			if i == 0 {
+				section.anchor = anchor
+			}
+			if j := i - offset; j >= 0 && j < len(local) {
+				if _, seen := section.anchors[local[j].ID]; !seen {
+					section.anchors[local[j].ID] = anchor
+				}
+			}
+		}
+		sections[page.ID] = section
+	}
+
+	var doc strings.Builder
+	if opts.FrontMatter {
+		meta, err := withFrontMatter(frontMatter{Title: w.DisplayTitle(), Commit: w.Commit, RepoURL: w.RepoURL}, "")
+		if err != nil {
+			return "", err
+		}
+		doc.WriteString(meta)
+	}
+	doc.WriteString(header)
+	doc.WriteString("## " + tocTitle + "\n\n")
+	for _, group := range nav {
+		indent := ""
+		if group.Title != "" {
+			doc.WriteString("- " + group.Title + "\n")
+			indent = "  "
+		}
+		for _, page := range group.Pages {
+			fmt.Fprintf(&doc, "%s- [%s](#%s)\n", indent, page.Title, sections[page.ID].anchor)
+		}
+	}
+
+	for _, page := range pages {
+		section := sections[page.ID]
+		body := markdown.RewriteLinks(section.body, func(dest string) string {
+			// 页面内的锚点
+			if strings.HasPrefix(dest, "#") {
+				if anchor, ok := section.anchors[dest[1:]]; ok {
+					return "#" + anchor
+				}
+			}
+			id, anchor, ok := w.PageLink(dest)
+			if !ok {
+				return dest
+			}
+			target := sections[id]
+			if anchor != "" {
+				if mapped, ok := target.anchors[anchor]; ok {
+					return "#" + mapped
+				}
+			}
+			return "#" + target.anchor
+		})
+		doc.WriteString("\n---\n\n" + strings.TrimRight(body, "\n") + "\n")
+	}
+	doc.WriteString(opts.footer(w))
+	return doc.String(), nil
+}
+
+// MarkdownFolder 将 Wiki 导出为 Markdown 目录，返回相对路径 -> 文件内容
+// 包含列出所有页面的 README.md 和每个页面一个 Markdown 文件，页面间链接改为相对的 .md 路径
+// 内容不含时间戳，便于在版本历史中比较
+func MarkdownFolder(w *Wiki, opts MarkdownOptions) (map[string][]byte, error) {
+	names := w.FileNames()
+	contents := w.markdownFiles(names)
+	nav := w.docNavigation()
+	footer := opts.footer(w)
+
+	index := landingPage(w, nav, names)
+	if opts.Header != "" {
+		index = opts.header(w) + pageList(nav, names)
+	}
+	files := make(map[string][]byte, len(w.Pages)+1)
+	files["README.md"] = []byte(strings.TrimRight(index, "\n") + "\n" + footer)
+	for _, page := range w.Ordered() {
+		content := pageHeading(page, contents[page.ID])
+		if opts.FrontMatter {
+			var err error
+			if content, err = withFrontMatter(w.pageFrontMatter(page), content); err != nil {
+				return nil, err
+			}
+		}
+		files[names[page.ID]+".md"] = []byte(strings.TrimRight(content, "\n") + "\n" + footer)
+	}
+	return files, nil
+}
+
+// MarkdownPages 将逐页的 Markdown 文件打包为 zip
+func MarkdownPages(w *Wiki, opts MarkdownOptions) ([]byte, error) {
+	files, err := MarkdownFolder(w, opts)
+	if err != nil {
+		return nil, err
+	}
+	names := w.FileNames()
+	root := projectName(w) + "-wiki/"
+	out := newArchive()
+	if err := out.add(root+"README.md", files["README.md"]); err != nil {
+		return nil, err
+	}
+	for _, page := range w.Ordered() {
+		name := names[page.ID] + ".md"
+		if err := out.add(root+name, files[name]); err != nil {
+			return nil, err
+		}
+	}
+	return out.bytes()
+}
+
+// demoteHeadings 将 ATX 标题降低一级（六级标题保持不变），跳过代码块
+func demoteHeadings(content string) string {
+	lines := strings.Split(content, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > 3 {
+			continue
+		}
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		if level == 0 || level >= 6 {
+			continue
+		}
+		if rest := trimmed[level:]; rest == "" || rest[0] == ' ' || rest[0] == '\t' {
+			lines[i] = "#" + trimmed
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/markdown"
+)
+
+func TestMarkdown(t *testing.T) {
+	w := testWiki()
+	// 与其他页面重名的标题和中文标题
+	w.Pages = append(w.Pages,
+		models.WikiPage{ID: "storage-notes", Title: "Storage", Content: "See [back](#storage) and [arch](architecture.md#storage).\n\n## Storage\n"},
+		models.WikiPage{ID: "zh", Title: "项目概述 (API)", Content: "```md\n# not a heading\n```\n"},
+	)
+	footer := "Generated from {repo} @ {commit}"
+	doc, err := Markdown(w, MarkdownOptions{Footer: footer, FrontMatter: true})
+	if err != nil {
+		t.Fatalf("markdown: %v", err)
+	}
+
+	// 所有指向文档内部的链接都必须对应实际的标题锚点
+	ids := make(map[string]bool)
+	for _, heading := range markdown.Headings(markdown.Parse(doc)) {
+		ids[heading.ID] = true
+	}
+	for _, m := range regexp.MustCompile(`\]\(#([^)]*)\)`).FindAllStringSubmatch(doc, -1) {
+		if !ids[m[1]] {
+			t.Errorf("broken anchor #%s", m[1])
+		}
+	}
+
+	for _, want := range []string{
+		"---\ntitle: demo\ncommit: abc123\nrepo_url: https://github.com/acme/demo\n---\n\n# demo\n",
+		"## 目录\n\n- Introduction\n  - [Overview](#overview)\n  - [Architecture](#architecture)\n- [Extra](#extra)\n- [Storage](#storage-1)\n- [项目概述 (API)](#项目概述-api)\n",
+		"## Overview\n\nSee [architecture](#architecture) and [storage](#storage).",
+		"## Architecture\n\n### Storage\n",
+		"## Extra\n\nNo heading here.",
+		"## Storage\n\nSee [back](#storage-2) and [arch](#storage).\n\n### Storage\n",
+		"```md\n# not a heading\n```",
+		"---\n\nGenerated from demo @ abc123\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document missing %q:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "DeepWiki 导出") {
+		t.Error("header should not be hard-coded")
+	}
+
+	data, err := MarkdownPages(w, MarkdownOptions{Header: "# {title} wiki", FrontMatter: true})
+	if err != nil {
+		t.Fatalf("markdown pages: %v", err)
+	}
+	files := readZip(t, data)
+	if !strings.HasPrefix(files["demo-wiki/README.md"], "# demo wiki\n\n## Introduction\n\n- [Overview](overview.md)") {
+		t.Errorf("unexpected index:\n%s", files["demo-wiki/README.md"])
+	}
+	architecture := files["demo-wiki/architecture.md"]
+	if !strings.Contains(architecture, "id: architecture\nimportance: medium\nfile_paths:\n    - main.go\ncommit: abc123\n---\n\n# Architecture") {
+		t.Errorf("unexpected front matter:\n%s", architecture)
+	}
+	if !strings.Contains(files["demo-wiki/storage-notes.md"], "[arch](architecture.md#storage)") {
+		t.Errorf("page links should point to files:\n%s", files["demo-wiki/storage-notes.md"])
+	}
+}
@@ -0,0 +1,80 @@
+// 在本地搜索索引中按关键字查找页面，不依赖任何外部资源
+(function () {
+  var input = document.getElementById("search-input");
+  var results = document.getElementById("search-results");
+  if (!input || !results) {
+    return;
+  }
+  var index = null;
+
+  function load() {
+    if (index !== null) {
+      return Promise.resolve(index);
+    }
+    return fetch("search_index.json")
+      .then(function (resp) { return resp.json(); })
+      .then(function (data) { index = data; return index; })
+      .catch(function () { index = []; return index; });
+  }
+
+  function score(entry, terms) {
+    var title = entry.title.toLowerCase();
+    var headings = (entry.headings || []).join(" ").toLowerCase();
+    var text = entry.text.toLowerCase();
+    var total = 0;
+    for (var i = 0; i < terms.length; i++) {
+      var term = terms[i];
+      var hit = 0;
+      if (title.indexOf(term) >= 0) { hit += 10; }
+      if (headings.indexOf(term) >= 0) { hit += 4; }
+      if (text.indexOf(term) >= 0) { hit += 1; }
+      if (hit === 0) {
+        return 0;
+      }
+      total += hit;
+    }
+    return total;
+  }
+
+  function snippet(text, term) {
+    var pos = text.toLowerCase().indexOf(term);
+    if (pos < 0) {
+      return text.slice(0, 120);
+    }
+    var start = Math.max(0, pos - 40);
+    return (start > 0 ? "…" : "") + text.slice(start, pos + 80) + "…";
+  }
+
+  function render(query) {
+    var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+    results.textContent = "";
+    if (terms.length === 0) {
+      return;
+    }
+    load().then(function (entries) {
+      var matches = entries
+        .map(function (entry) { return { entry: entry, score: score(entry, terms) }; })
+        .filter(function (m) { return m.score > 0; })
+        .sort(function (a, b) { return b.score - a.score; })
+        .slice(0, 20);
+      results.textContent = "";
+      matches.forEach(function (m) {
+        var link = document.createElement("a");
+        link.href = m.entry.url;
+        link.textContent = m.entry.title;
+        var small = document.createElement("small");
+        small.textContent = snippet(m.entry.text, terms[0]);
+        link.appendChild(small);
+        results.appendChild(link);
+      });
+    });
+  }
+
+  input.addEventListener("input", function () { render(input.value); });
+  input.addEventListener("keydown", function (e) {
+    if (e.key === "Escape") {
+      input.value = "";
+      results.textContent = "";
+    }
+  });
+})();
@@ -1,17 +1,24 @@
 // internal/models/models.go
 package models
 
+import "time"
+
 // ChatMessage 表示聊天消息
 type ChatMessage struct {
-	Role    string `json:"role"`    // 'user' 或 'assistant'
-	Content string `json:"content"` // 消息内容
+	Role      string     `json:"role"`                 // 'user' 或 'assistant'
+	Content   string     `json:"content"`              // 消息内容
+	Citations []Citation `json:"citations,omitempty"`  // 助手回答引用的来源
+	CreatedAt *time.Time `json:"created_at,omitempty"` // 会话中的消息记录的时间
 }
 
 // ChatCompletionRequest 表示聊天完成请求
 type ChatCompletionRequest struct {
 	RepoURL     string        `json:"repo_url"`               // 仓库 URL
 	Messages    []ChatMessage `json:"messages"`               // 聊天消息列表
-	FilePath    string        `json:"filePath,omitempty"`     // 可选的文件路径
+	FilePath    string        `json:"filePath,omitempty"`     // 可选的文件路径，其内容固定在检索结果之前
+	StartLine   int           `json:"startLine,omitempty"`    // 文件的起始行，从 1 开始，0 表示整个文件
+	EndLine     int           `json:"endLine,omitempty"`      // 文件的结束行，0 表示到文件末尾
+	Language    string        `json:"language,omitempty"`     // 回答语言，例如 en、zh
 	GitHubToken string        `json:"github_token,omitempty"` // GitHub 访问令牌
 	GitLabToken string        `json:"gitlab_token,omitempty"` // GitLab 访问令牌
 }
@@ -29,19 +36,148 @@ type Document struct {
 
 // WikiPage 表示一个Wiki页面
 type WikiPage struct {
+	ID            string     `json:"id"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	FilePaths     []string   `json:"file_paths,omitempty"`
+	Importance    string     `json:"importance"`
+	RelatedPages  []string   `json:"related_pages,omitempty"`
+	Section       string     `json:"section,omitempty"`        // 所属章节ID
+	Revision      int        `json:"revision,omitempty"`       // 当前版本号，从 1 开始
+	HumanAuthored bool       `json:"human_authored,omitempty"` // 当前版本是否由人工编辑
+	Citations     []Citation `json:"citations,omitempty"`      // 正文中引用的源码位置
+}
+
+// Citation 表示生成内容中引用的一个源码位置
+type Citation struct {
+	Index     int    `json:"index"` // 提示词中来源的编号，正文中以 [n] 引用
+	FilePath  string `json:"file_path"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Commit    string `json:"commit,omitempty"` // 建立索引时的提交
+	URL       string `json:"url,omitempty"`    // 代码托管平台上带行锚点的链接
+}
+
+// WikiSection 表示 Wiki 大纲中的一个章节
+type WikiSection struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Pages []string `json:"pages"` // 章节包含的页面ID，按展示顺序排列
+}
+
+// PlannedPage 表示 Wiki 大纲中规划的一个页面
+type PlannedPage struct {
 	ID           string   `json:"id"`
 	Title        string   `json:"title"`
-	Content      string   `json:"content"`
-	FilePaths    []string `json:"file_paths,omitempty"`
+	Description  string   `json:"description"`
+	FilePaths    []string `json:"file_paths"`
 	Importance   string   `json:"importance"`
-	RelatedPages []string `json:"related_pages,omitempty"`
+	RelatedPages []string `json:"related_pages"`
+	Section      string   `json:"section,omitempty"`
+}
+
+// WikiPlan 表示由模型规划的 Wiki 结构
+type WikiPlan struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Mode        string        `json:"mode"` // "concise" 或 "comprehensive"
+	Sections    []WikiSection `json:"sections"`
+	Pages       []PlannedPage `json:"pages"`
+}
+
+// PageStatus 表示单个 Wiki 页面的生成结果
+type PageStatus struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Status     string           `json:"status"` // "ok" 或 "failed"
+	Error      string           `json:"error,omitempty"`
+	Attempts   int              `json:"attempts"`
+	DurationMs int64            `json:"duration_ms"`
+	Grounding  *GroundingReport `json:"grounding,omitempty"`
+	Diagrams   *DiagramReport   `json:"diagrams,omitempty"`
+}
+
+// DiagramReport 表示页面中 Mermaid 图表的校验和修复结果
+type DiagramReport struct {
+	Total    int      `json:"total"`
+	Repaired int      `json:"repaired"`         // 经模型修复后通过校验的图表数
+	Invalid  int      `json:"invalid"`          // 修复后仍无法通过校验的图表数
+	Errors   []string `json:"errors,omitempty"` // 仍无效的图表的解析错误
+}
+
+// GroundingReport 表示页面中引用的文件和代码符号与仓库的核对结果
+type GroundingReport struct {
+	Score      float64  `json:"score"`   // 已验证引用的比例，没有可核对的引用时为 1
+	Checked    int      `json:"checked"` // 可核对的引用次数
+	Verified   int      `json:"verified"`
+	Unverified []string `json:"unverified,omitempty"` // 未能在仓库中找到的路径或标识符
 }
 
 // WikiExportRequest 表示 wiki 导出请求
 type WikiExportRequest struct {
-	RepoURL string     `json:"repo_url"`
-	Pages   []WikiPage `json:"pages"`
-	Format  string     `json:"format"` // "markdown" 或 "json"
+	RepoURL     string        `json:"repo_url"`
+	Pages       []WikiPage    `json:"pages"`
+	Format      string        `json:"format"`                // "markdown"、"json"、"html"、"mkdocs"、"docusaurus"、"confluence"、"epub" 或 "git"
+	Title       string        `json:"title,omitempty"`       // Wiki 标题，默认使用仓库名
+	Description string        `json:"description,omitempty"` // Wiki 简介
+	Commit      string        `json:"commit,omitempty"`      // 生成时的源码提交 SHA
+	Language    string        `json:"language,omitempty"`    // 页面语言
+	Sections    []WikiSection `json:"sections,omitempty"`    // 大纲章节，用于生成导航
+	Push        bool          `json:"push,omitempty"`        // confluence 格式：推送到配置的 Confluence 空间而不是返回导入包；git 格式：提交后推送到远程仓库
+	WikiID      string        `json:"wiki_id,omitempty"`     // git 格式和推送到 Confluence 时导出的已保存 Wiki，页面内容不从请求中读取
+
+	// 以下字段仅用于 markdown 格式
+	PerPage     bool    `json:"per_page,omitempty"`     // 每个页面一个文件，打包为 zip
+	FrontMatter bool    `json:"front_matter,omitempty"` // 添加 YAML 头信息（页面ID、重要性、相关文件、相关页面和源码提交）
+	Header      *string `json:"header,omitempty"`       // 覆盖配置的页眉，空字符串表示使用默认标题
+	Footer      *string `json:"footer,omitempty"`       // 覆盖配置的页脚，空字符串表示不添加页脚
+
+	// 以下字段仅用于 git 格式
+	Branch      string `json:"branch,omitempty"`       // 目标分支，默认为仓库的默认分支，孤立分支默认为配置的分支名
+	Orphan      bool   `json:"orphan,omitempty"`       // 写入只包含 Wiki 的孤立分支，而不是代码分支中的目录
+	Dir         string `json:"dir,omitempty"`          // 写入的目录，默认为配置的目录（docs/wiki），孤立分支默认为根目录
+	GitHubToken string `json:"github_token,omitempty"` // GitHub 访问令牌
+	GitLabToken string `json:"gitlab_token,omitempty"` // GitLab 访问令牌
+}
+
+// ChatSession 表示一个持久化的聊天会话，绑定到仓库和引用，属于创建它的用户
+type ChatSession struct {
+	ID        string        `json:"id"`
+	Owner     string        `json:"owner"` // 创建会话的用户ID
+	RepoURL   string        `json:"repo_url"`
+	Ref       string        `json:"ref,omitempty"`    // 分支、标签或提交，为空时使用仓库当前检出的版本
+	Commit    string        `json:"commit,omitempty"` // 第一条消息时由 ref 解析出的提交 SHA，之后的消息都基于该版本回答
+	Title     string        `json:"title,omitempty"`
+	Language  string        `json:"language,omitempty"`
+	Messages  []ChatMessage `json:"messages"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ChatSessionSummary 表示会话列表中的一项，不包含消息内容
+type ChatSessionSummary struct {
+	ID           string    `json:"id"`
+	RepoURL      string    `json:"repo_url"`
+	Ref          string    `json:"ref,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Language     string    `json:"language,omitempty"`
+	MessageCount int       `json:"message_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Summary 返回会话的元数据摘要
+func (s *ChatSession) Summary() ChatSessionSummary {
+	return ChatSessionSummary{
+		ID:           s.ID,
+		RepoURL:      s.RepoURL,
+		Ref:          s.Ref,
+		Title:        s.Title,
+		Language:     s.Language,
+		MessageCount: len(s.Messages),
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+	}
 }
 
 // DialogTurn 表示对话轮次
@@ -50,3 +186,224 @@ type DialogTurn struct {
 	UserQuery         string `json:"user_query"`
 	AssistantResponse string `json:"assistant_response"`
 }
+
+// ContributorStat 表示一个贡献者在某个范围内的提交统计
+type ContributorStat struct {
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Commits      int    `json:"commits"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesDeleted int    `json:"lines_deleted"`
+}
+
+// OwnershipShare 表示某个作者在文件当前内容中所占的行数比例（基于 git blame）
+type OwnershipShare struct {
+	Author string  `json:"author"`
+	Lines  int     `json:"lines"`
+	Share  float64 `json:"share"`
+}
+
+// FileActivity 表示单个文件的变更活跃度
+type FileActivity struct {
+	Path         string           `json:"path"`
+	Commits      int              `json:"commits"`
+	Churn        int              `json:"churn"` // 新增行数与删除行数之和
+	LastAuthor   string           `json:"last_author"`
+	LastCommit   string           `json:"last_commit"`
+	LastModified time.Time        `json:"last_modified"`
+	Owners       []OwnershipShare `json:"owners,omitempty"`
+}
+
+// DirectoryActivity 表示一个目录的变更活跃度
+type DirectoryActivity struct {
+	Path            string            `json:"path"`
+	Commits         int               `json:"commits"`
+	Churn           int               `json:"churn"`
+	LastModified    time.Time         `json:"last_modified"`
+	TopContributors []ContributorStat `json:"top_contributors"`
+}
+
+// RepoHistory 表示基于 git 历史的仓库活跃度分析结果
+type RepoHistory struct {
+	CommitCount     int                      `json:"commit_count"`
+	FirstCommit     time.Time                `json:"first_commit"`
+	LastCommit      time.Time                `json:"last_commit"`
+	TopContributors []ContributorStat        `json:"top_contributors"`
+	Directories     []DirectoryActivity      `json:"directories"`
+	HotFiles        []FileActivity           `json:"hot_files"`
+	Files           map[string]*FileActivity `json:"-"` // 按相对路径索引的全部文件
+}
+
+// ChangelogCommit 表示变更日志中的一个提交
+type ChangelogCommit struct {
+	SHA      string    `json:"sha"`
+	ShortSHA string    `json:"short_sha"`
+	Author   string    `json:"author"`
+	Date     time.Time `json:"date"`
+	Subject  string    `json:"subject"`
+	Type     string    `json:"type"` // 约定式提交类型，如 feat、fix；无法识别时为 "other"
+	Scope    string    `json:"scope,omitempty"`
+	Breaking bool      `json:"breaking,omitempty"`
+	Module   string    `json:"module"` // 提交主要涉及的顶级目录
+	Files    []string  `json:"files,omitempty"`
+}
+
+// Changelog 表示两个引用之间的变更汇总
+type Changelog struct {
+	FromRef      string                       `json:"from_ref"`
+	ToRef        string                       `json:"to_ref"`
+	FromCommit   string                       `json:"from_commit"`
+	ToCommit     string                       `json:"to_commit"`
+	Commits      []ChangelogCommit            `json:"commits"`
+	FilesChanged int                          `json:"files_changed"`
+	Insertions   int                          `json:"insertions"`
+	Deletions    int                          `json:"deletions"`
+	ByType       map[string][]ChangelogCommit `json:"by_type"`
+	ByModule     map[string][]string          `json:"by_module"` // 模块 -> 提交 SHA 列表
+}
+
+// Wiki 表示一次持久化的 Wiki 生成结果，按仓库、引用和提交存储
+type Wiki struct {
+	ID          string       `json:"id"`
+	RepoURL     string       `json:"repo_url"`
+	RepoKey     string       `json:"repo_key"` // 仓库在存储中的键，例如 github.com_owner_repo
+	Ref         string       `json:"ref"`
+	Commit      string       `json:"commit"` // 生成时的源码提交 SHA
+	Provider    string       `json:"provider"`
+	Model       string       `json:"model,omitempty"`
+	Mode        string       `json:"mode,omitempty"`
+	Language    string       `json:"language,omitempty"` // 输出语言代码，例如 zh、en
+	GeneratedAt time.Time    `json:"generated_at"`
+	Plan        *WikiPlan    `json:"plan,omitempty"`
+	Pages       []WikiPage   `json:"pages"`
+	PageStatus  []PageStatus `json:"page_status,omitempty"`
+
+	Revisions      map[string][]PageRevision `json:"revisions,omitempty"`       // 页面ID -> 版本历史，按版本号升序
+	PromptVersions map[string]int            `json:"prompt_versions,omitempty"` // 生成时使用的提示词模板版本
+	TranslatedFrom string                    `json:"translated_from,omitempty"` // 由其他语言版本翻译而来时，源 Wiki 的 ID
+}
+
+// 页面版本的来源
+const (
+	RevisionSourceGenerated   = "generated"
+	RevisionSourceRegenerated = "regenerated"
+	RevisionSourceEdited      = "edited"
+	RevisionSourceImported    = "imported"
+)
+
+// PageRevision 表示 Wiki 页面的一个历史版本
+type PageRevision struct {
+	Revision      int       `json:"revision"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	Source        string    `json:"source"` // "generated"、"regenerated"、"edited" 或 "imported"
+	HumanAuthored bool      `json:"human_authored"`
+	Author        string    `json:"author,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	Guidance      string    `json:"guidance,omitempty"` // 重新生成时附加的指导意见
+	Commit        string    `json:"commit,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// WikiSummary 表示持久化 Wiki 的元数据，不包含页面内容
+type WikiSummary struct {
+	ID          string    `json:"id"`
+	RepoURL     string    `json:"repo_url"`
+	RepoKey     string    `json:"repo_key"`
+	Ref         string    `json:"ref"`
+	Commit      string    `json:"commit"`
+	Provider    string    `json:"provider"`
+	Model       string    `json:"model,omitempty"`
+	Mode        string    `json:"mode,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+	PageCount   int       `json:"page_count"`
+}
+
+// Summary 返回 Wiki 的元数据摘要
+func (w *Wiki) Summary() WikiSummary {
+	return WikiSummary{
+		ID:          w.ID,
+		RepoURL:     w.RepoURL,
+		RepoKey:     w.RepoKey,
+		Ref:         w.Ref,
+		Commit:      w.Commit,
+		Provider:    w.Provider,
+		Model:       w.Model,
+		Mode:        w.Mode,
+		Language:    w.Language,
+		GeneratedAt: w.GeneratedAt,
+		PageCount:   len(w.Pages),
+	}
+}
+
+// FindPage 按ID查找页面，不存在时返回 nil
+func (w *Wiki) FindPage(id string) *WikiPage {
+	for i := range w.Pages {
+		if w.Pages[i].ID == id {
+			return &w.Pages[i]
+		}
+	}
+	return nil
+}
+
+// RecordRevision 将页面保存为新版本并替换当前页面，返回更新后的页面
+// 页面第一次产生新版本时，会先把原有内容记录为第 1 版
+func (w *Wiki) RecordRevision(page WikiPage, rev PageRevision) WikiPage {
+	if w.Revisions == nil {
+		w.Revisions = make(map[string][]PageRevision)
+	}
+	history := w.Revisions[page.ID]
+
+	current := w.FindPage(page.ID)
+	if len(history) == 0 && current != nil {
+		initial := PageRevision{
+			Revision:      1,
+			Title:         current.Title,
+			Content:       current.Content,
+			Source:        RevisionSourceGenerated,
+			HumanAuthored: current.HumanAuthored,
+			Commit:        w.Commit,
+			CreatedAt:     w.GeneratedAt,
+		}
+		history = append(history, initial)
+	}
+
+	rev.Revision = len(history) + 1
+	rev.Title = page.Title
+	rev.Content = page.Content
+	if rev.CreatedAt.IsZero() {
+		rev.CreatedAt = time.Now().UTC()
+	}
+	w.Revisions[page.ID] = append(history, rev)
+
+	page.Revision = rev.Revision
+	page.HumanAuthored = rev.HumanAuthored
+	if current != nil {
+		*current = page
+	} else {
+		w.Pages = append(w.Pages, page)
+	}
+	return page
+}
+
+// PageDrift 表示一个因源码变更而过期的 Wiki 页面
+type PageDrift struct {
+	PageID        string   `json:"page_id"`
+	Title         string   `json:"title"`
+	ChangedFiles  []string `json:"changed_files"` // 页面引用的文件中发生变更的部分
+	HumanAuthored bool     `json:"human_authored,omitempty"`
+}
+
+// DriftReport 表示 Wiki 源码提交与新提交之间的过期检测结果
+type DriftReport struct {
+	RepoKey        string      `json:"repo_key"`
+	WikiID         string      `json:"wiki_id"`
+	Ref            string      `json:"ref"`
+	FromCommit     string      `json:"from_commit"`
+	ToCommit       string      `json:"to_commit"`
+	ChangedFiles   []string    `json:"changed_files"`
+	StalePages     []PageDrift `json:"stale_pages"`
+	UntrackedPages []string    `json:"untracked_pages,omitempty"` // 没有记录源文件、无法判断是否过期的页面
+	CheckedAt      time.Time   `json:"checked_at"`
+}
@@ -0,0 +1,235 @@
+// internal/prompts/prompts.go
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+// DefaultLanguage 是未指定语言时使用的输出语言
+const DefaultLanguage = "zh"
+
+// fallbackLanguage 是没有对应语言模板时使用的提示词语言
+const fallbackLanguage = "en"
+
+// languageNames 是支持的输出语言及其在提示词中使用的名称
+var languageNames = map[string]string{
+	"en": "English",
+	"zh": "Simplified Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"es": "Spanish",
+	"de": "German",
+	"fr": "French",
+}
+
+// versionPattern 匹配模板开头的版本注释，例如 {{/* version: 2 */}}
+var versionPattern = regexp.MustCompile(`^\s*\{\{-?\s*/\*\s*version:\s*(\d+)\s*\*/\s*-?\}\}`)
+
+// Data 是渲染提示词模板时可用的字段，不同模板只使用其中的一部分
+type Data struct {
+	Language       string // 输出语言名称，由 Render 填充
+	SourceLanguage string // 翻译的源语言名称
+	Repo           string // 仓库名称
+	RepoURL        string
+	Title          string
+	Description    string
+	Related        string // 相关页面列表
+	Module         string
+	Context        string // 带编号的代码上下文
+	Diagram        string
+	Guidance       string // 用户对页面的补充要求
+	Mode           string // Wiki 规划模式
+	Tree           string // 文件树
+	Readme         string
+	From           string
+	To             string
+	Count          int    // 数量，例如统计的提交数
+	Commits        string // 提交汇总
+	Question       string
+	History        string // 格式化的对话历史
+	Summary        string // 较早对话的摘要
+	File           string // 用户正在查看的文件及行范围
+	Error          string
+	Code           string
+	Content        string // 待处理的正文，例如待翻译的文本
+}
+
+// Library 保存按语言解析好的提示词模板
+type Library struct {
+	sets     map[string]*template.Template
+	versions map[string]map[string]int
+}
+
+// SupportedLanguages 返回支持的语言代码，按字母顺序排列
+func SupportedLanguages() []string {
+	langs := make([]string, 0, len(languageNames))
+	for lang := range languageNames {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// NormalizeLanguage 将语言参数规范化为支持的语言代码，例如 "zh-CN" 转换为 "zh"
+// 参数为空时返回空字符串，由调用方决定默认语言
+func NormalizeLanguage(lang string) (string, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		return "", nil
+	}
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if _, ok := languageNames[lang]; !ok {
+		return "", fmt.Errorf("不支持的语言 %q，可选值: %s", lang, strings.Join(SupportedLanguages(), ", "))
+	}
+	return lang, nil
+}
+
+// LanguageName 返回语言代码在提示词中使用的名称
+func LanguageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// Load 加载内置模板，dir 非空时用 <dir>/<语言>/<模板名>.tmpl 覆盖同名的内置模板
+// 某种语言缺少的模板使用英文模板，并要求模型以该语言输出
+func Load(dir string) (*Library, error) {
+	files, err := readTemplates(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		overrides, err := readTemplates(os.DirFS(dir), ".")
+		if err != nil {
+			return nil, fmt.Errorf("读取提示词目录 %s 失败: %v", dir, err)
+		}
+		for lang, byName := range overrides {
+			if _, ok := languageNames[lang]; !ok {
+				return nil, fmt.Errorf("提示词目录中有不支持的语言 %q", lang)
+			}
+			if files[lang] == nil {
+				files[lang] = make(map[string]string)
+			}
+			for name, text := range byName {
+				files[lang][name] = text
+			}
+		}
+	}
+
+	lib := &Library{
+		sets:     make(map[string]*template.Template),
+		versions: make(map[string]map[string]int),
+	}
+	for lang := range languageNames {
+		// 先放入英文模板，再用该语言自己的模板覆盖
+		merged := make(map[string]string)
+		for name, text := range files[fallbackLanguage] {
+			merged[name] = text
+		}
+		for name, text := range files[lang] {
+			merged[name] = text
+		}
+
+		set := template.New(lang)
+		versions := make(map[string]int)
+		for name, text := range merged {
+			if _, err := set.New(name).Parse(text); err != nil {
+				return nil, fmt.Errorf("解析提示词模板 %s/%s 失败: %v", lang, name, err)
+			}
+			versions[name] = templateVersion(text)
+		}
+		lib.sets[lang] = set
+		lib.versions[lang] = versions
+	}
+	return lib, nil
+}
+
+// Render 以指定语言渲染模板，name 可以是模板文件名或模板中 define 的名称
+func (l *Library) Render(lang, name string, data Data) (string, error) {
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	set, ok := l.sets[lang]
+	if !ok {
+		return "", fmt.Errorf("不支持的语言 %q", lang)
+	}
+	if set.Lookup(name) == nil {
+		return "", fmt.Errorf("提示词模板 %s 不存在", name)
+	}
+
+	data.Language = LanguageName(lang)
+	var buf bytes.Buffer
+	if err := set.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("渲染提示词模板 %s/%s 失败: %v", lang, name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Versions 返回指定语言下各模板文件的版本号，用于记录生成内容所用的提示词
+func (l *Library) Versions(lang string) map[string]int {
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	versions := make(map[string]int, len(l.versions[lang]))
+	for name, version := range l.versions[lang] {
+		versions[name] = version
+	}
+	return versions
+}
+
+// readTemplates 读取 root 下按语言分目录存放的 .tmpl 文件，返回 语言 -> 模板名 -> 内容
+func readTemplates(fsys fs.FS, root string) (map[string]map[string]string, error) {
+	files := make(map[string]map[string]string)
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		lang := entry.Name()
+		matches, err := fs.Glob(fsys, path.Join(root, lang, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			content, err := fs.ReadFile(fsys, match)
+			if err != nil {
+				return nil, err
+			}
+			if files[lang] == nil {
+				files[lang] = make(map[string]string)
+			}
+			name := strings.TrimSuffix(path.Base(match), ".tmpl")
+			files[lang][name] = string(content)
+		}
+	}
+	return files, nil
+}
+
+// templateVersion 读取模板开头的版本注释，没有版本注释时视为第 1 版
+func templateVersion(text string) int {
+	if m := versionPattern.FindStringSubmatch(text); m != nil {
+		if v, err := strconv.Atoi(m[1]); err == nil {
+			return v
+		}
+	}
+	return 1
+}
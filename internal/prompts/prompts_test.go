@@ -0,0 +1,73 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderAllLanguages(t *testing.T) {
+	lib, err := Load("")
+	if err != nil {
+		t.Fatalf("load embedded templates: %v", err)
+	}
+
+	names := []string{"overview", "architecture", "module", "page", "plan", "release_notes", "chat", "chat_summary", "query_rewrite", "diagram_repair", "translate", "overview_title", "sources_heading",
+		"api_section", "api_intro", "api_package_title", "activity_section", "activity_intro", "activity_hot_file_columns", "changelog_range", "git_export_subject",
+		"source_label", "overview_query", "architecture_query", "module_query"}
+	data := Data{Repo: "demo", Module: "api", Context: "[1] main.go", Question: "how?", Guidance: "be brief", Mode: "comprehensive"}
+	for _, lang := range SupportedLanguages() {
+		for _, name := range names {
+			out, err := lib.Render(lang, name, data)
+			if err != nil || out == "" {
+				t.Errorf("render %s/%s: %q, %v", lang, name, out, err)
+			}
+		}
+	}
+
+	out, _ := lib.Render("ja", "overview", data)
+	if !strings.Contains(out, "Japanese") || !strings.Contains(out, "be brief") {
+		t.Errorf("ja overview should ask for Japanese output and include guidance:\n%s", out)
+	}
+	out, _ = lib.Render("", "overview_title", data)
+	if out != "demo - 项目概述" {
+		t.Errorf("default language should be zh, got %q", out)
+	}
+	if v := lib.Versions("en")["overview"]; v != 1 {
+		t.Errorf("unexpected overview version %d", v)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "de"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	override := "{{/* version: 3 */ -}}\nSchreibe eine Übersicht für {{.Repo}}.\n"
+	if err := os.WriteFile(filepath.Join(dir, "de", "overview.tmpl"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lib, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load overrides: %v", err)
+	}
+	out, err := lib.Render("de", "overview", Data{Repo: "demo"})
+	if err != nil || out != "Schreibe eine Übersicht für demo." {
+		t.Errorf("override not used: %q, %v", out, err)
+	}
+	if v := lib.Versions("de")["overview"]; v != 3 {
+		t.Errorf("unexpected override version %d", v)
+	}
+	if out, _ := lib.Render("de", "module_title", Data{Module: "api"}); out != "api Module" {
+		t.Errorf("missing templates should fall back to English, got %q", out)
+	}
+
+	if _, err := NormalizeLanguage("zh-CN"); err != nil {
+		t.Errorf("zh-CN should normalize: %v", err)
+	}
+	if _, err := NormalizeLanguage("xx"); err == nil {
+		t.Error("unsupported language should be rejected")
+	}
+}
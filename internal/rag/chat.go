@@ -0,0 +1,131 @@
+// internal/rag/chat.go
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+// 聊天消息的角色
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ChatStreamer 是可选接口，提供者通过它以保留角色的多轮消息生成回答
+// messages 按时间顺序排列，用户与助手交替出现，最后一条是用户消息
+type ChatStreamer interface {
+	// GenerateChatStreamContext 生成流式响应；文本通道关闭后错误通道最多返回一个错误
+	GenerateChatStreamContext(ctx context.Context, messages []models.ChatMessage) (<-chan string, <-chan error, error)
+}
+
+// MemoryRetriever 是可选接口，提供者通过它结合指定的对话历史检索文档，而不是使用提供者自身的 Memory
+type MemoryRetriever interface {
+	// RetrieveDocumentsWithMemory 检索与查询相关的文档，并参考 memory 中的对话调整结果
+	RetrieveDocumentsWithMemory(query string, memory *Memory) ([]models.Document, error)
+}
+
+// RetrieveWithMemory 使用会话自己的对话历史检索文档，提供者未实现 MemoryRetriever 时直接检索
+func RetrieveWithMemory(provider RAGProvider, query string, memory *Memory) ([]models.Document, error) {
+	if retriever, ok := provider.(MemoryRetriever); ok && memory != nil {
+		return retriever.RetrieveDocumentsWithMemory(query, memory)
+	}
+	return provider.RetrieveDocuments(query)
+}
+
+// StreamChat 以多轮对话生成流式响应
+// 提供者实现了 ChatStreamer 时直接发送带角色的消息，否则将对话按角色格式化为单个提示词
+func StreamChat(ctx context.Context, provider RAGProvider, messages []models.ChatMessage) (<-chan string, <-chan error, error) {
+	messages = mergeMessages(messages)
+	if len(messages) == 0 || messages[len(messages)-1].Role != RoleUser {
+		return nil, nil, errors.New("对话必须以用户消息结束")
+	}
+	if streamer, ok := provider.(ChatStreamer); ok {
+		return streamer.GenerateChatStreamContext(ctx, messages)
+	}
+
+	prompt := FormatConversation(messages[:len(messages)-1]) + messages[len(messages)-1].Content
+	if streamer, ok := provider.(ContextStreamer); ok {
+		return streamer.GenerateStreamingResponseContext(ctx, prompt)
+	}
+	responseCh, err := provider.GenerateStreamingResponse(prompt)
+	if err != nil {
+		return nil, nil, err
+	}
+	errCh := make(chan error)
+	close(errCh)
+	return responseCh, errCh, nil
+}
+
+// FormatConversation 将对话历史格式化为带角色标签的文本，用于不支持多轮消息的提供者和对话摘要
+func FormatConversation(messages []models.ChatMessage) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<conversation>\n")
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "<%s>\n%s\n</%s>\n", msg.Role, strings.TrimSpace(msg.Content), msg.Role)
+	}
+	b.WriteString("</conversation>\n\n")
+	return b.String()
+}
+
+// TrimHistory 从最近的消息开始保留总 token 数不超过 budget 的历史消息
+// 返回保留的消息和被舍弃的较早消息；保留的消息总是从用户消息开始，budget 不大于 0 时全部保留
+func TrimHistory(history []models.ChatMessage, budget int, count func(string) int) (kept, dropped []models.ChatMessage) {
+	if budget <= 0 {
+		return history, nil
+	}
+	start, used := len(history), 0
+	for start > 0 {
+		tokens := count(history[start-1].Content)
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		start--
+	}
+	// 不以助手的回答开始，避免保留的对话缺少对应的问题
+	for start < len(history) && history[start].Role != RoleUser {
+		start++
+	}
+	return history[start:], history[:start]
+}
+
+// NewMemoryFromMessages 由对话消息创建 Memory，每个用户问题与其后的助手回答构成一轮，最多保留最近的 maxTurns 轮
+func NewMemoryFromMessages(messages []models.ChatMessage, maxTurns int) *Memory {
+	memory := NewMemory()
+	for i := 0; i+1 < len(messages); i++ {
+		if messages[i].Role == RoleUser && messages[i+1].Role == RoleAssistant {
+			memory.dialogTurns = append(memory.dialogTurns, models.DialogTurn{
+				ID:                fmt.Sprintf("turn-%d", len(memory.dialogTurns)+1),
+				UserQuery:         messages[i].Content,
+				AssistantResponse: messages[i+1].Content,
+			})
+			i++
+		}
+	}
+	memory.Truncate(maxTurns)
+	return memory
+}
+
+// mergeMessages 去掉空消息并合并连续的同角色消息，使用户与助手交替出现
+func mergeMessages(messages []models.ChatMessage) []models.ChatMessage {
+	var merged []models.ChatMessage
+	for _, msg := range messages {
+		if strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].Role == msg.Role {
+			merged[n-1].Content += "\n\n" + msg.Content
+			continue
+		}
+		merged = append(merged, msg)
+	}
+	return merged
+}
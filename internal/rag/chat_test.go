@@ -0,0 +1,82 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+// promptProvider 只支持单个提示词，记录收到的提示词
+type promptProvider struct {
+	RAGProvider
+	prompt string
+}
+
+func (p *promptProvider) GenerateStreamingResponse(prompt string) (chan string, error) {
+	p.prompt = prompt
+	ch := make(chan string, 1)
+	ch <- "answer"
+	close(ch)
+	return ch, nil
+}
+
+func TestTrimHistory(t *testing.T) {
+	history := []models.ChatMessage{
+		{Role: RoleUser, Content: "aaaa"},
+		{Role: RoleAssistant, Content: "bbbb"},
+		{Role: RoleUser, Content: "cc"},
+		{Role: RoleAssistant, Content: "dd"},
+	}
+	count := func(s string) int { return len(s) }
+
+	kept, dropped := TrimHistory(history, 8, count)
+	if len(kept) != 2 || kept[0].Content != "cc" || len(dropped) != 2 {
+		t.Errorf("kept %v, dropped %v", kept, dropped)
+	}
+	// 预算内最早的消息是助手回答时从下一条用户消息开始
+	kept, dropped = TrimHistory(history, 9, count)
+	if len(kept) != 2 || len(dropped) != 2 {
+		t.Errorf("kept should start with a user message: %v", kept)
+	}
+	if kept, dropped = TrimHistory(history, 0, count); len(kept) != 4 || dropped != nil {
+		t.Errorf("no budget should keep everything: %v", kept)
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	provider := &promptProvider{}
+	messages := []models.ChatMessage{
+		{Role: RoleUser, Content: "what does the store do?"},
+		{Role: RoleUser, Content: "the wiki store"},
+		{Role: RoleAssistant, Content: "It saves wikis."},
+		{Role: RoleAssistant, Content: ""},
+		{Role: RoleUser, Content: "and how is it tested?"},
+	}
+	chunks, errs, err := StreamChat(context.Background(), provider, messages)
+	if err != nil {
+		t.Fatalf("stream chat: %v", err)
+	}
+	var answer strings.Builder
+	for chunk := range chunks {
+		answer.WriteString(chunk)
+	}
+	if err := <-errs; err != nil || answer.String() != "answer" {
+		t.Fatalf("unexpected answer %q: %v", answer.String(), err)
+	}
+
+	want := "<conversation>\n<user>\nwhat does the store do?\n\nthe wiki store\n</user>\n<assistant>\nIt saves wikis.\n</assistant>\n</conversation>\n\nand how is it tested?"
+	if provider.prompt != want {
+		t.Errorf("unexpected prompt:\n%s", provider.prompt)
+	}
+
+	if _, _, err := StreamChat(context.Background(), provider, messages[:3]); err == nil {
+		t.Error("conversation ending with an assistant message should be rejected")
+	}
+
+	memory := NewMemoryFromMessages(messages, 1)
+	if turns := memory.GetDialogTurns(); len(turns) != 1 || turns[0].UserQuery != "the wiki store" || turns[0].AssistantResponse != "It saves wikis." {
+		t.Errorf("unexpected memory turns: %+v", turns)
+	}
+}
@@ -0,0 +1,197 @@
+// internal/rag/citations.go
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deepwiki-go/internal/models"
+	"github.com/deepwiki-go/pkg/utils"
+)
+
+// citationPattern 匹配正文中的 [n] 引用
+var citationPattern = regexp.MustCompile(`\[(\d{1,3})\]`)
+
+// SourceFromDocument 根据检索到的文档构造引用来源，缺少行号时覆盖整个文本
+func SourceFromDocument(doc models.Document) models.Citation {
+	source := models.Citation{}
+	source.FilePath, _ = doc.MetaData["file_path"].(string)
+	source.Commit, _ = doc.MetaData["commit"].(string)
+	source.StartLine = metaInt(doc.MetaData, "start_line")
+	source.EndLine = metaInt(doc.MetaData, "end_line")
+	if source.StartLine == 0 {
+		source.StartLine = 1
+	}
+	if source.EndLine == 0 {
+		source.EndLine = source.StartLine + strings.Count(doc.Text, "\n")
+	}
+	return source
+}
+
+// SourceLabel 返回来源在提示词上下文中的说明，例如 "文件: main.go (第 1-10 行)"，便于按输出语言渲染
+type SourceLabel func(source models.Citation) string
+
+// defaultSourceLabel 是未指定说明格式时使用的中文说明
+func defaultSourceLabel(source models.Citation) string {
+	return fmt.Sprintf("文件: %s (第 %d-%d 行)", source.FilePath, source.StartLine, source.EndLine)
+}
+
+// FormatSource 将编号来源格式化为提示词中的一段上下文，label 为 nil 时使用中文说明
+func FormatSource(source models.Citation, text string, label SourceLabel) string {
+	if label == nil {
+		label = defaultSourceLabel
+	}
+	return fmt.Sprintf("[%d] %s\n```\n%s\n```\n\n", source.Index, label(source), text)
+}
+
+// NumberDocuments 从 startIndex 开始为检索结果编号，返回提示词上下文和来源列表
+// maxChars 大于 0 时，超出长度的文档不再加入上下文；label 为 nil 时使用中文说明
+func NumberDocuments(docs []models.Document, startIndex, maxChars int, label SourceLabel) (string, []models.Citation) {
+	var context strings.Builder
+	var sources []models.Citation
+	for _, doc := range docs {
+		source := SourceFromDocument(doc)
+		source.Index = startIndex + len(sources)
+		entry := FormatSource(source, doc.Text, label)
+		if maxChars > 0 && context.Len()+len(entry) > maxChars && len(sources) > 0 {
+			break
+		}
+		context.WriteString(entry)
+		sources = append(sources, source)
+	}
+	return context.String(), sources
+}
+
+// ParseCitations 解析正文中的 [n] 引用，返回实际被引用的来源，按编号排序
+func ParseCitations(content string, sources []models.Citation) []models.Citation {
+	byIndex := make(map[int]models.Citation, len(sources))
+	for _, source := range sources {
+		byIndex[source.Index] = source
+	}
+
+	used := make(map[int]bool)
+	forEachCitation(content, func(start, end, index int) {
+		if _, ok := byIndex[index]; ok {
+			used[index] = true
+		}
+	})
+
+	cited := make([]models.Citation, 0, len(used))
+	for index := range used {
+		cited = append(cited, byIndex[index])
+	}
+	sort.Slice(cited, func(i, j int) bool { return cited[i].Index < cited[j].Index })
+	return cited
+}
+
+// ResolveCitations 为来源生成代码托管平台上带行锚点的链接，来源缺少提交时使用 fallbackRef
+func ResolveCitations(citations []models.Citation, repoURL, fallbackRef string) []models.Citation {
+	for i := range citations {
+		ref := citations[i].Commit
+		if ref == "" {
+			ref = fallbackRef
+		}
+		citations[i].URL = utils.BlobURL(repoURL, ref, citations[i].FilePath, citations[i].StartLine, citations[i].EndLine)
+	}
+	return citations
+}
+
+// LinkCitations 将正文中的 [n] 替换为指向源码的链接，并在末尾追加来源列表
+func LinkCitations(content string, citations []models.Citation) string {
+	return LinkCitationsWithHeading(content, citations, "来源")
+}
+
+// LinkCitationsWithHeading 与 LinkCitations 相同，来源列表使用指定的标题
+func LinkCitationsWithHeading(content string, citations []models.Citation, heading string) string {
+	if len(citations) == 0 {
+		return content
+	}
+	byIndex := make(map[int]models.Citation, len(citations))
+	for _, c := range citations {
+		byIndex[c.Index] = c
+	}
+
+	var linked strings.Builder
+	last := 0
+	forEachCitation(content, func(start, end, index int) {
+		c, ok := byIndex[index]
+		if !ok || c.URL == "" {
+			return
+		}
+		linked.WriteString(content[last:start])
+		linked.WriteString(fmt.Sprintf("[[%d]](%s)", index, c.URL))
+		last = end
+	})
+	linked.WriteString(content[last:])
+
+	linked.WriteString("\n\n## " + heading + "\n\n")
+	for _, c := range citations {
+		label := c.FilePath
+		if c.StartLine > 0 {
+			label = fmt.Sprintf("%s#L%d-L%d", c.FilePath, c.StartLine, c.EndLine)
+		}
+		if c.URL != "" {
+			linked.WriteString(fmt.Sprintf("- [%d] [%s](%s)\n", c.Index, label, c.URL))
+		} else {
+			linked.WriteString(fmt.Sprintf("- [%d] %s\n", c.Index, label))
+		}
+	}
+	return linked.String()
+}
+
+// CitedFiles 返回被引用来源的文件路径，去重并保持引用顺序
+func CitedFiles(citations []models.Citation) []string {
+	seen := make(map[string]bool)
+	files := []string{}
+	for _, c := range citations {
+		if c.FilePath != "" && !seen[c.FilePath] {
+			seen[c.FilePath] = true
+			files = append(files, c.FilePath)
+		}
+	}
+	return files
+}
+
+// forEachCitation 按出现顺序遍历代码块之外的 [n] 引用
+// 已经是 Markdown 链接的一部分（例如 [1](url) 或 [[1]](url)）的编号会被跳过
+func forEachCitation(content string, fn func(start, end, index int)) {
+	inFence := false
+	offset := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		} else if !inFence {
+			for _, m := range citationPattern.FindAllStringSubmatchIndex(line, -1) {
+				start, end := m[0], m[1]
+				if start > 0 && line[start-1] == '[' {
+					continue
+				}
+				if end < len(line) && line[end] == '(' {
+					continue
+				}
+				index, err := strconv.Atoi(line[m[2]:m[3]])
+				if err != nil {
+					continue
+				}
+				fn(offset+start, offset+end, index)
+			}
+		}
+		offset += len(line)
+	}
+}
+
+// metaInt 读取元数据中的整数，兼容 JSON 解码得到的 float64
+func metaInt(meta map[string]interface{}, key string) int {
+	switch v := meta[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package rag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deepwiki-go/internal/models"
+)
+
+func TestParseAndLinkCitations(t *testing.T) {
+	docs := []models.Document{
+		{Text: "a\nb\nc", MetaData: map[string]interface{}{"file_path": "main.go", "commit": "abc123"}},
+		{Text: "x", MetaData: map[string]interface{}{"file_path": "pkg/util.go", "start_line": float64(10), "end_line": float64(20)}},
+		{Text: "y", MetaData: map[string]interface{}{"file_path": "unused.go"}},
+	}
+	context, sources := NumberDocuments(docs, 1, 0, nil)
+	if len(sources) != 3 || sources[0].EndLine != 3 || sources[1].StartLine != 10 {
+		t.Fatalf("unexpected sources: %+v", sources)
+	}
+	if !strings.Contains(context, "[2] 文件: pkg/util.go (第 10-20 行)\n```\nx\n```") {
+		t.Errorf("unexpected default context:\n%s", context)
+	}
+	english, _ := NumberDocuments(docs[:1], 1, 0, func(source models.Citation) string { return "File: " + source.FilePath })
+	if !strings.HasPrefix(english, "[1] File: main.go\n") {
+		t.Errorf("source label not used:\n%s", english)
+	}
+
+	content := "入口在 main 中 [1]。工具函数见 [2][9]。\n\n```go\nx := a[3]\n```\n已有链接 [1](http://example.com)"
+	cited := ParseCitations(content, sources)
+	if len(cited) != 2 || cited[0].Index != 1 || cited[1].Index != 2 {
+		t.Fatalf("unexpected citations: %+v", cited)
+	}
+
+	cited = ResolveCitations(cited, "https://github.com/owner/repo", "main")
+	if cited[0].URL != "https://github.com/owner/repo/blob/abc123/main.go#L1-L3" {
+		t.Errorf("unexpected url: %s", cited[0].URL)
+	}
+	if cited[1].URL != "https://github.com/owner/repo/blob/main/pkg/util.go#L10-L20" {
+		t.Errorf("unexpected url: %s", cited[1].URL)
+	}
+
+	linked := LinkCitations(content, cited)
+	if !strings.Contains(linked, "[[1]](https://github.com/owner/repo/blob/abc123/main.go#L1-L3)。") {
+		t.Errorf("citation not linked:\n%s", linked)
+	}
+	if !strings.Contains(linked, "x := a[3]") || !strings.Contains(linked, "[1](http://example.com)") {
+		t.Errorf("code or existing links were rewritten:\n%s", linked)
+	}
+	if !strings.Contains(linked, "- [2] [pkg/util.go#L10-L20]") {
+		t.Errorf("missing sources section:\n%s", linked)
+	}
+
+	if files := CitedFiles(cited); len(files) != 2 || files[1] != "pkg/util.go" {
+		t.Errorf("unexpected cited files: %v", files)
+	}
+}
@@ -0,0 +1,120 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ContextStreamer 是支持上下文取消的可选生成接口
+// 与 GenerateStreamingResponse 不同，生成过程中的错误通过错误通道返回，而不是混入文本
+type ContextStreamer interface {
+	// GenerateStreamingResponseContext 生成流式响应；文本通道关闭后错误通道最多返回一个错误
+	GenerateStreamingResponseContext(ctx context.Context, prompt string) (<-chan string, <-chan error, error)
+}
+
+// chunkObserverKey 是上下文中文本片段观察者的键
+type chunkObserverKey struct{}
+
+// WithChunkObserver 返回一个携带文本片段观察者的上下文，GenerateText 每收到一个片段都会调用它
+func WithChunkObserver(ctx context.Context, observer func(chunk string)) context.Context {
+	return context.WithValue(ctx, chunkObserverKey{}, observer)
+}
+
+// observeChunk 将文本片段通知给上下文中的观察者
+func observeChunk(ctx context.Context, chunk string) {
+	if observer, ok := ctx.Value(chunkObserverKey{}).(func(string)); ok && chunk != "" {
+		observer(chunk)
+	}
+}
+
+// GenerateText 调用提供者生成完整的响应文本
+// 提供者实现了 ContextStreamer 时可以被取消并返回真实的错误，否则仅在等待期间响应取消
+func GenerateText(ctx context.Context, provider RAGProvider, prompt string) (string, error) {
+	if streamer, ok := provider.(ContextStreamer); ok {
+		chunks, errs, err := streamer.GenerateStreamingResponseContext(ctx, prompt)
+		if err != nil {
+			return "", err
+		}
+
+		var content strings.Builder
+		for chunk := range chunks {
+			content.WriteString(chunk)
+			observeChunk(ctx, chunk)
+		}
+		if err := <-errs; err != nil {
+			return content.String(), err
+		}
+		return content.String(), ctx.Err()
+	}
+
+	responseCh, err := provider.GenerateStreamingResponse(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	var content strings.Builder
+	for {
+		select {
+		case chunk, ok := <-responseCh:
+			if !ok {
+				return content.String(), nil
+			}
+			content.WriteString(chunk)
+			observeChunk(ctx, chunk)
+		case <-ctx.Done():
+			// 继续在后台读取，避免提供者的 goroutine 阻塞
+			go func() {
+				for range responseCh {
+				}
+			}()
+			return content.String(), ctx.Err()
+		}
+	}
+}
+
+// IsTransientError 判断生成错误是否为可重试的临时错误（超时、限流、服务端错误）
+// 只根据错误类型、gRPC 状态码和 HTTP 状态码判断，不匹配错误信息中的文字
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return isTransientStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return isTransientStatus(reqErr.HTTPStatusCode)
+	}
+
+	// Vertex AI 等 gRPC 客户端返回带状态码的错误
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+			return true
+		}
+	}
+	return false
+}
+
+// isTransientStatus 判断 HTTP 状态码是否表示临时错误
+func isTransientStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusRequestTimeout || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,47 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", fmt.Errorf("generate: %w", context.Canceled), false},
+		{"deadline", fmt.Errorf("generate: %w", context.DeadlineExceeded), true},
+		{"connection reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"net timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, true},
+		{"openai rate limit", &openai.APIError{HTTPStatusCode: 429}, true},
+		{"openai server error", fmt.Errorf("stream: %w", &openai.APIError{HTTPStatusCode: 503}), true},
+		{"openai bad request", &openai.APIError{HTTPStatusCode: 400, Message: "model gpt-4-0503 rejected 429 tokens"}, false},
+		{"openai request error", &openai.RequestError{HTTPStatusCode: 502, Err: errors.New("bad gateway")}, true},
+		{"grpc unavailable", status.Error(codes.Unavailable, "backend down"), true},
+		{"grpc exhausted", fmt.Errorf("vertex: %w", status.Error(codes.ResourceExhausted, "quota")), true},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "503 is not a valid temperature"), false},
+		// 错误信息中的数字或 EOF 不应被当作临时错误
+		{"unexpected eof", fmt.Errorf("decode: %w", io.ErrUnexpectedEOF), false},
+		{"plain text", errors.New("unexpected EOF in JSON after 5029 bytes (model gemini-1.5-429)"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientError(tt.err); got != tt.want {
+				t.Errorf("IsTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,9 @@ import (
 	"cloud.google.com/go/vertexai/genai"
 )
 
+// googleChatModel 是 Google 提供者生成回答使用的模型
+const googleChatModel = "gemini-2.5-pro"
+
 // GoogleRAG 实现基于 Google Vertex AI 的检索增强生成
 type GoogleRAG struct {
 	Memory       *Memory
@@ -44,6 +47,11 @@ func (r *GoogleRAG) Name() string {
 	return "google"
 }
 
+// Model 返回生成使用的模型名称
+func (r *GoogleRAG) Model() string {
+	return googleChatModel
+}
+
 // Initialize 初始化提供者
 func (r *GoogleRAG) Initialize() error {
 	// 初始化 Google 生成式 AI 客户端
@@ -62,8 +70,13 @@ func (r *GoogleRAG) Initialize() error {
 
 // PrepareRetriever 为仓库准备检索器
 func (r *GoogleRAG) PrepareRetriever(repoURLOrPath string, accessToken string) error {
+	return r.PrepareRetrieverContext(context.Background(), repoURLOrPath, accessToken)
+}
+
+// PrepareRetrieverContext 为仓库准备检索器，ctx 取消后停止索引
+func (r *GoogleRAG) PrepareRetrieverContext(ctx context.Context, repoURLOrPath string, accessToken string) error {
 	r.RepoURL = repoURLOrPath
-	if err := r.DbManager.PrepareDatabase(repoURLOrPath, accessToken); err != nil {
+	if err := r.DbManager.PrepareDatabaseContext(ctx, repoURLOrPath, accessToken); err != nil {
 		return err
 	}
 	// 这里可以根据需要加载文档列表（如有必要）
@@ -112,6 +125,11 @@ func (r *GoogleRAG) DeleteDocument(id string) error {
 
 // RetrieveDocuments 检索与查询相关的文档
 func (r *GoogleRAG) RetrieveDocuments(query string) ([]models.Document, error) {
+	return r.RetrieveDocumentsWithMemory(query, r.Memory)
+}
+
+// RetrieveDocumentsWithMemory 检索与查询相关的文档，并使用指定的对话历史重新排序
+func (r *GoogleRAG) RetrieveDocumentsWithMemory(query string, memory *Memory) ([]models.Document, error) {
 	if len(r.Documents) == 0 {
 		return nil, errors.New("没有可用于检索的文档")
 	}
@@ -123,7 +141,7 @@ func (r *GoogleRAG) RetrieveDocuments(query string) ([]models.Document, error) {
 	}
 
 	// 使用上下文历史记录增强检索结果
-	if relevantDocs, err = r.enhanceRetrievalWithMemory(query, relevantDocs); err != nil {
+	if relevantDocs, err = r.enhanceRetrievalWithMemory(query, memory, relevantDocs); err != nil {
 		log.Printf("增强检索结果时出错: %v", err)
 		// 继续使用原始结果
 	}
@@ -132,9 +150,9 @@ func (r *GoogleRAG) RetrieveDocuments(query string) ([]models.Document, error) {
 }
 
 // enhanceRetrievalWithMemory 使用上下文历史记录增强检索结果
-func (r *GoogleRAG) enhanceRetrievalWithMemory(query string, docs []models.Document) ([]models.Document, error) {
+func (r *GoogleRAG) enhanceRetrievalWithMemory(query string, memory *Memory, docs []models.Document) ([]models.Document, error) {
 	// 从记忆中获取相关上下文
-	context := r.Memory.GetRelevantContext(query)
+	context := memory.GetRelevantContext(query)
 	if context == "" {
 		return docs, nil // 没有相关上下文，使用原始结果
 	}
@@ -227,19 +245,65 @@ func extractKeywords(text string) []string {
 
 // GenerateStreamingResponse 生成流式响应
 func (r *GoogleRAG) GenerateStreamingResponse(prompt string) (chan string, error) {
-	if r.GoogleClient == nil {
-		return nil, errors.New("Google AI 客户端未初始化")
+	chunks, errs, err := r.GenerateStreamingResponseContext(context.Background(), prompt)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建一个通道用于流式传输响应
 	responseCh := make(chan string)
+	go func() {
+		defer close(responseCh)
+		for chunk := range chunks {
+			responseCh <- chunk
+		}
+		if err := <-errs; err != nil {
+			responseCh <- fmt.Sprintf("\n错误: %v", err)
+		}
+	}()
+
+	return responseCh, nil
+}
+
+// GenerateStreamingResponseContext 生成支持取消的流式响应，错误通过错误通道返回
+func (r *GoogleRAG) GenerateStreamingResponseContext(ctx context.Context, prompt string) (<-chan string, <-chan error, error) {
+	return r.streamContent(ctx, func(model *genai.GenerativeModel) *genai.GenerateContentResponseIterator {
+		return model.GenerateContentStream(ctx, genai.Text(prompt))
+	})
+}
+
+// GenerateChatStreamContext 以带角色的多轮消息生成流式响应，较早的消息作为聊天历史发送
+func (r *GoogleRAG) GenerateChatStreamContext(ctx context.Context, messages []models.ChatMessage) (<-chan string, <-chan error, error) {
+	if len(messages) == 0 {
+		return nil, nil, errors.New("没有消息")
+	}
+	return r.streamContent(ctx, func(model *genai.GenerativeModel) *genai.GenerateContentResponseIterator {
+		session := model.StartChat()
+		for _, msg := range messages[:len(messages)-1] {
+			role := "user"
+			if msg.Role == RoleAssistant {
+				role = "model"
+			}
+			session.History = append(session.History, &genai.Content{Role: role, Parts: []genai.Part{genai.Text(msg.Content)}})
+		}
+		return session.SendMessageStream(ctx, genai.Text(messages[len(messages)-1].Content))
+	})
+}
+
+// streamContent 使用配置好生成参数的模型发起请求，并将返回的文本流式传输到通道
+func (r *GoogleRAG) streamContent(ctx context.Context, start func(model *genai.GenerativeModel) *genai.GenerateContentResponseIterator) (<-chan string, <-chan error, error) {
+	if r.GoogleClient == nil {
+		return nil, nil, errors.New("Google AI 客户端未初始化")
+	}
+
+	responseCh := make(chan string)
+	errCh := make(chan error, 1)
 
 	// 在 goroutine 中处理生成
 	go func() {
+		defer close(errCh)
 		defer close(responseCh)
 
-		ctx := context.Background()
-
 		// 设置生成参数
 		temperature := float32(0.7)
 		topP := float32(0.8)
@@ -247,13 +311,13 @@ func (r *GoogleRAG) GenerateStreamingResponse(prompt string) (chan string, error
 		maxTokens := int32(2048)
 
 		// 创建生成请求
-		model := r.GoogleClient.GenerativeModel("gemini-2.5-pro")
+		model := r.GoogleClient.GenerativeModel(googleChatModel)
 		model.Temperature = &temperature
 		model.TopP = &topP
 		model.TopK = &topK
 		model.MaxOutputTokens = &maxTokens
 
-		iter := model.GenerateContentStream(ctx, genai.Text(prompt))
+		iter := start(model)
 
 		// 流式传输响应
 		for {
@@ -262,23 +326,31 @@ func (r *GoogleRAG) GenerateStreamingResponse(prompt string) (chan string, error
 				if err == io.EOF {
 					return
 				}
-				if err == context.Canceled {
+				if errors.Is(err, context.Canceled) && ctx.Err() == nil {
 					return
 				}
-				responseCh <- fmt.Sprintf("\n错误: %v", err)
+				errCh <- err
 				return
 			}
+			if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+				continue
+			}
 
 			// 发送响应块
 			for _, part := range resp.Candidates[0].Content.Parts {
 				if text, ok := part.(genai.Text); ok {
-					responseCh <- string(text)
+					select {
+					case responseCh <- string(text):
+					case <-ctx.Done():
+						errCh <- ctx.Err()
+						return
+					}
 				}
 			}
 		}
 	}()
 
-	return responseCh, nil
+	return responseCh, errCh, nil
 }
 
 // Close 清理资源
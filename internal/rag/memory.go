@@ -28,7 +28,7 @@ func NewMemory() *Memory {
 func (m *Memory) AddDialogTurn(userQuery string, assistantResponse string) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	log.Printf("AddDialogTurn userQuery: %s, assistantResponse: %s", userQuery, assistantResponse)
+	log.Printf("AddDialogTurn: 问题 %d 字节，回答 %d 字节", len(userQuery), len(assistantResponse))
 
 	turn := models.DialogTurn{
 		ID:                uuid.New().String(),
@@ -136,6 +136,16 @@ func similarityScore(a, b string) float64 {
 	return float64(matches) / float64(len(aWords)+len(bWords)-matches)
 }
 
+// Truncate 只保留最近的 maxTurns 个对话轮次，maxTurns 不大于 0 时不做限制
+func (m *Memory) Truncate(maxTurns int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if maxTurns > 0 && len(m.dialogTurns) > maxTurns {
+		m.dialogTurns = append([]models.DialogTurn(nil), m.dialogTurns[len(m.dialogTurns)-maxTurns:]...)
+	}
+}
+
 // Clear 清除内存中的所有对话轮次
 func (m *Memory) Clear() {
 	m.mutex.Lock()
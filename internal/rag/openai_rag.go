@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 
 	"github.com/deepwiki-go/internal/config"
@@ -12,6 +13,9 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 )
 
+// openAIChatModel 是 OpenAI 提供者生成回答使用的模型
+const openAIChatModel = openai.O4Mini2020416
+
 // OpenAIRAG 实现基于 OpenAI 的检索增强生成
 type OpenAIRAG struct {
 	Memory       *Memory
@@ -39,6 +43,11 @@ func (r *OpenAIRAG) Name() string {
 	return "openai"
 }
 
+// Model 返回生成使用的模型名称
+func (r *OpenAIRAG) Model() string {
+	return openAIChatModel
+}
+
 // Initialize 初始化提供者
 func (r *OpenAIRAG) Initialize() error {
 	if r.Config.OpenAIAPIKey == "" {
@@ -50,8 +59,13 @@ func (r *OpenAIRAG) Initialize() error {
 
 // PrepareRetriever 为仓库准备检索器
 func (r *OpenAIRAG) PrepareRetriever(repoURLOrPath string, accessToken string) error {
+	return r.PrepareRetrieverContext(context.Background(), repoURLOrPath, accessToken)
+}
+
+// PrepareRetrieverContext 为仓库准备检索器，ctx 取消后停止索引
+func (r *OpenAIRAG) PrepareRetrieverContext(ctx context.Context, repoURLOrPath string, accessToken string) error {
 	r.RepoURL = repoURLOrPath
-	err := r.DbManager.PrepareDatabase(repoURLOrPath, accessToken)
+	err := r.DbManager.PrepareDatabaseContext(ctx, repoURLOrPath, accessToken)
 	if err != nil {
 		return fmt.Errorf("failed to prepare database: %w", err)
 	}
@@ -73,37 +87,78 @@ func (r *OpenAIRAG) RetrieveDocuments(query string) ([]models.Document, error) {
 
 // GenerateStreamingResponse 生成流式响应
 func (r *OpenAIRAG) GenerateStreamingResponse(prompt string) (chan string, error) {
+	chunks, errs, err := r.GenerateStreamingResponseContext(context.Background(), prompt)
+	if err != nil {
+		return nil, err
+	}
+	responseCh := make(chan string)
+	go func() {
+		defer close(responseCh)
+		for chunk := range chunks {
+			responseCh <- chunk
+		}
+		if err := <-errs; err != nil {
+			responseCh <- "请求发送失败: " + err.Error()
+		}
+	}()
+	return responseCh, nil
+}
+
+// GenerateStreamingResponseContext 生成支持取消的流式响应，错误通过错误通道返回
+func (r *OpenAIRAG) GenerateStreamingResponseContext(ctx context.Context, prompt string) (<-chan string, <-chan error, error) {
+	return r.GenerateChatStreamContext(ctx, []models.ChatMessage{{Role: RoleUser, Content: prompt}})
+}
+
+// GenerateChatStreamContext 以带角色的多轮消息生成流式响应
+func (r *OpenAIRAG) GenerateChatStreamContext(ctx context.Context, messages []models.ChatMessage) (<-chan string, <-chan error, error) {
 	if r.OpenAIClient == nil {
-		return nil, errors.New("OpenAI 客户端未初始化")
+		return nil, nil, errors.New("OpenAI 客户端未初始化")
 	}
+	chatMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, msg := range messages {
+		role := openai.ChatMessageRoleUser
+		if msg.Role == RoleAssistant {
+			role = openai.ChatMessageRoleAssistant
+		}
+		chatMessages = append(chatMessages, openai.ChatCompletionMessage{Role: role, Content: msg.Content})
+	}
+
 	responseCh := make(chan string)
+	errCh := make(chan error, 1)
 	go func() {
+		defer close(errCh)
 		defer close(responseCh)
 		req := openai.ChatCompletionRequest{
-			Model: openai.O4Mini2020416,
-			Messages: []openai.ChatCompletionMessage{{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			}},
-			Stream: true,
+			Model:    openAIChatModel,
+			Messages: chatMessages,
+			Stream:   true,
 		}
-		stream, err := r.OpenAIClient.CreateChatCompletionStream(context.Background(), req)
+		stream, err := r.OpenAIClient.CreateChatCompletionStream(ctx, req)
 		if err != nil {
-			responseCh <- "请求发送失败: " + err.Error()
+			errCh <- err
 			return
 		}
 		defer stream.Close()
 		for {
 			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
 			if err != nil {
-				break
+				errCh <- err
+				return
 			}
 			if len(resp.Choices) > 0 {
-				responseCh <- resp.Choices[0].Delta.Content
+				select {
+				case responseCh <- resp.Choices[0].Delta.Content:
+				case <-ctx.Done():
+					errCh <- ctx.Err()
+					return
+				}
 			}
 		}
 	}()
-	return responseCh, nil
+	return responseCh, errCh, nil
 }
 
 // Close 清理资源
@@ -1,6 +1,7 @@
 package rag
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -29,6 +30,37 @@ type RAGProvider interface {
 	Close() error
 }
 
+// ContextPreparer 是可选接口，支持在准备检索器（索引仓库）的过程中响应取消
+type ContextPreparer interface {
+	// PrepareRetrieverContext 为仓库准备检索器，ctx 取消后停止索引并返回 ctx.Err()
+	PrepareRetrieverContext(ctx context.Context, repoURLOrPath string, accessToken string) error
+}
+
+// PrepareRetriever 为仓库准备检索器，提供者实现了 ContextPreparer 时可以被取消
+func PrepareRetriever(ctx context.Context, provider RAGProvider, repoURLOrPath string, accessToken string) error {
+	if preparer, ok := provider.(ContextPreparer); ok {
+		return preparer.PrepareRetrieverContext(ctx, repoURLOrPath, accessToken)
+	}
+	if err := provider.PrepareRetriever(repoURLOrPath, accessToken); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// ModelNamer 是可选接口，提供者通过它报告生成时使用的模型名称
+type ModelNamer interface {
+	// Model 返回生成使用的模型名称
+	Model() string
+}
+
+// ModelName 返回提供者使用的模型名称，未实现 ModelNamer 时返回空字符串
+func ModelName(provider RAGProvider) string {
+	if namer, ok := provider.(ModelNamer); ok {
+		return namer.Model()
+	}
+	return ""
+}
+
 // ProviderRegistry 管理 RAG 提供者的注册表
 type ProviderRegistry struct {
 	mu        sync.RWMutex
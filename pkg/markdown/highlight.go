@@ -0,0 +1,172 @@
+// pkg/markdown/highlight.go
+package markdown
+
+import (
+	"html"
+	"strings"
+)
+
+// syntax 描述一种语言的高亮规则
+type syntax struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+}
+
+// words 将空格分隔的关键字转为集合
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	cLike = [2]string{"/*", "*/"}
+
+	goSyntax = syntax{
+		keywords:     words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var true false nil iota"),
+		lineComments: []string{"//"}, blockComment: cLike, quotes: "\"'`",
+	}
+	jsSyntax = syntax{
+		keywords:     words("async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return super switch this throw try typeof var void while yield true false null undefined interface type enum implements"),
+		lineComments: []string{"//"}, blockComment: cLike, quotes: "\"'`",
+	}
+	pythonSyntax = syntax{
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield True False None self"),
+		lineComments: []string{"#"}, quotes: "\"'",
+	}
+	javaSyntax = syntax{
+		keywords:     words("abstract boolean break byte case catch char class const continue default do double else enum extends final finally float for if implements import instanceof int interface long new package private protected public return short static super switch synchronized this throw throws try void volatile while true false null val var fun override"),
+		lineComments: []string{"//"}, blockComment: cLike, quotes: "\"'",
+	}
+	cSyntax = syntax{
+		keywords:     words("auto break case char const continue default do double else enum extern float for goto if int long register return short signed sizeof static struct switch typedef union unsigned void volatile while class namespace template typename public private protected virtual new delete true false nullptr using"),
+		lineComments: []string{"//"}, blockComment: cLike, quotes: "\"'",
+	}
+	rustSyntax = syntax{
+		keywords:     words("as async await break const continue crate else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while"),
+		lineComments: []string{"//"}, blockComment: cLike, quotes: "\"",
+	}
+	shellSyntax = syntax{
+		keywords:     words("if then else elif fi case esac for while until do done in function return export local echo exit"),
+		lineComments: []string{"#"}, quotes: "\"'",
+	}
+	sqlSyntax = syntax{
+		keywords:     words("select from where insert into values update set delete create table drop alter index join left right inner outer on group by order having limit and or not null as distinct primary key SELECT FROM WHERE INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE DROP ALTER INDEX JOIN LEFT RIGHT INNER OUTER ON GROUP BY ORDER HAVING LIMIT AND OR NOT NULL AS DISTINCT PRIMARY KEY"),
+		lineComments: []string{"--"}, blockComment: cLike, quotes: "'\"",
+	}
+	configSyntax = syntax{
+		keywords:     words("true false null yes no"),
+		lineComments: []string{"#"}, quotes: "\"'",
+	}
+	jsonSyntax = syntax{keywords: words("true false null"), quotes: "\""}
+)
+
+// syntaxes 将代码块语言名映射到高亮规则
+var syntaxes = map[string]syntax{
+	"go": goSyntax, "golang": goSyntax,
+	"js": jsSyntax, "javascript": jsSyntax, "jsx": jsSyntax, "ts": jsSyntax, "typescript": jsSyntax, "tsx": jsSyntax,
+	"py": pythonSyntax, "python": pythonSyntax,
+	"java": javaSyntax, "kotlin": javaSyntax, "kt": javaSyntax, "scala": javaSyntax, "cs": javaSyntax, "csharp": javaSyntax,
+	"c": cSyntax, "h": cSyntax, "cpp": cSyntax, "c++": cSyntax, "hpp": cSyntax,
+	"rust": rustSyntax, "rs": rustSyntax,
+	"sh": shellSyntax, "bash": shellSyntax, "shell": shellSyntax, "zsh": shellSyntax,
+	"sql":  sqlSyntax,
+	"yaml": configSyntax, "yml": configSyntax, "toml": configSyntax, "ini": configSyntax,
+	"json": jsonSyntax,
+}
+
+// Highlight 对代码进行简单的词法高亮，返回转义后的 HTML
+// 关键字、字符串、注释和数字分别包裹在 class 为 k、s、c、n 的 <span> 中，未知语言只做转义
+func Highlight(code, lang string) string {
+	syn, ok := syntaxes[strings.ToLower(lang)]
+	if !ok {
+		return html.EscapeString(code)
+	}
+
+	var out strings.Builder
+	span := func(class, text string) {
+		out.WriteString(`<span class="` + class + `">` + html.EscapeString(text) + "</span>")
+	}
+	for i := 0; i < len(code); {
+		rest := code[i:]
+		if syn.blockComment[0] != "" && strings.HasPrefix(rest, syn.blockComment[0]) {
+			end := strings.Index(rest[len(syn.blockComment[0]):], syn.blockComment[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(syn.blockComment[0]) + len(syn.blockComment[1])
+			}
+			span("c", rest[:end])
+			i += end
+			continue
+		}
+		if prefix := lineComment(rest, syn.lineComments); prefix {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			span("c", rest[:end])
+			i += end
+			continue
+		}
+
+		c := code[i]
+		switch {
+		case strings.IndexByte(syn.quotes, c) >= 0:
+			end := 1
+			for end < len(rest) && rest[end] != c {
+				if rest[end] == '\\' && c != '`' {
+					end++
+				} else if rest[end] == '\n' && c != '`' {
+					break
+				}
+				end++
+			}
+			if end < len(rest) && rest[end] == c {
+				end++
+			}
+			end = min(end, len(rest))
+			span("s", rest[:end])
+			i += end
+
+		case c >= '0' && c <= '9' && (i == 0 || !isWordByte(code[i-1])):
+			end := 1
+			for end < len(rest) && (isWordByte(rest[end]) || rest[end] == '.') {
+				end++
+			}
+			span("n", rest[:end])
+			i += end
+
+		case isWordByte(c):
+			end := 1
+			for end < len(rest) && isWordByte(rest[end]) {
+				end++
+			}
+			if syn.keywords[rest[:end]] {
+				span("k", rest[:end])
+			} else {
+				out.WriteString(html.EscapeString(rest[:end]))
+			}
+			i += end
+
+		default:
+			out.WriteString(html.EscapeString(rest[:1]))
+			i++
+		}
+	}
+	return out.String()
+}
+
+// lineComment 判断文本是否以行注释开头
+func lineComment(text string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+// pkg/markdown/html.go
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// HTMLOptions 控制 HTML 渲染
+type HTMLOptions struct {
+	// LinkURL 改写链接和图片的目标地址，例如将页面间链接改为静态文件路径
+	LinkURL func(dest string) string
+	// CodeBlock 自定义代码块的渲染，返回 false 时使用默认渲染
+	CodeBlock func(lang, code string) (string, bool)
+	// Link 自定义链接的渲染，body 是已渲染的链接文本，返回 false 时使用默认渲染
+	Link func(dest, body string) (string, bool)
+	// Image 自定义图片的渲染，返回 false 时使用默认渲染
+	Image func(src, alt string) (string, bool)
+	// HeadingIDs 为标题生成 GitHub 风格的锚点
+	HeadingIDs bool
+	// XHTML 输出自闭合的空元素，例如 <br />
+	XHTML bool
+}
+
+// ToHTML 将 Markdown 文本渲染为 HTML
+func ToHTML(src string, opts HTMLOptions) string {
+	return RenderHTML(Parse(src), opts)
+}
+
+// RenderHTML 将解析后的块渲染为 HTML，所有文本都经过转义
+func RenderHTML(blocks []Block, opts HTMLOptions) string {
+	r := htmlRenderer{inline: inlineRenderer{opts: opts}, opts: opts, slugs: NewSlugger()}
+	var out strings.Builder
+	r.blocks(&out, blocks)
+	return out.String()
+}
+
+// htmlRenderer 保存渲染单个文档时的状态
+type htmlRenderer struct {
+	inline inlineRenderer
+	opts   HTMLOptions
+	slugs  *Slugger
+}
+
+// blocks 依次渲染块
+func (r htmlRenderer) blocks(out *strings.Builder, blocks []Block) {
+	for _, block := range blocks {
+		r.block(out, block)
+	}
+}
+
+// block 渲染单个块
+func (r htmlRenderer) block(out *strings.Builder, block Block) {
+	switch block.Kind {
+	case Heading:
+		if r.opts.HeadingIDs {
+			fmt.Fprintf(out, "<h%d id=\"%s\">%s</h%d>\n", block.Level, html.EscapeString(r.slugs.Slug(PlainText(block.Text))), r.inline.render(block.Text), block.Level)
+		} else {
+			fmt.Fprintf(out, "<h%d>%s</h%d>\n", block.Level, r.inline.render(block.Text), block.Level)
+		}
+
+	case Paragraph:
+		out.WriteString("<p>" + r.inline.render(block.Text) + "</p>\n")
+
+	case CodeBlock:
+		if r.opts.CodeBlock != nil {
+			if rendered, ok := r.opts.CodeBlock(block.Lang, block.Text); ok {
+				out.WriteString(rendered + "\n")
+				return
+			}
+		}
+		if block.Lang != "" {
+			fmt.Fprintf(out, "<pre><code class=\"language-%s\">%s</code></pre>\n", html.EscapeString(block.Lang), Highlight(block.Text, block.Lang))
+		} else {
+			out.WriteString("<pre><code>" + html.EscapeString(block.Text) + "</code></pre>\n")
+		}
+
+	case Quote:
+		out.WriteString("<blockquote>\n")
+		r.blocks(out, block.Children)
+		out.WriteString("</blockquote>\n")
+
+	case List:
+		tag := "ul"
+		if block.Ordered {
+			tag = "ol"
+		}
+		if block.Ordered && block.Start != 1 {
+			fmt.Fprintf(out, "<ol start=\"%d\">\n", block.Start)
+		} else {
+			out.WriteString("<" + tag + ">\n")
+		}
+		for _, item := range block.Items {
+			out.WriteString("<li>")
+			// 只有一个段落的列表项不包裹 <p>
+			if len(item) > 0 && item[0].Kind == Paragraph {
+				out.WriteString(r.inline.render(item[0].Text))
+				item = item[1:]
+				if len(item) > 0 {
+					out.WriteString("\n")
+				}
+			}
+			r.blocks(out, item)
+			out.WriteString("</li>\n")
+		}
+		out.WriteString("</" + tag + ">\n")
+
+	case Table:
+		out.WriteString("<table>\n<thead>\n<tr>")
+		for i, cell := range block.Header {
+			out.WriteString(r.cell("th", cell, alignAt(block.Align, i)))
+		}
+		out.WriteString("</tr>\n</thead>\n<tbody>\n")
+		for _, row := range block.Rows {
+			out.WriteString("<tr>")
+			for i, cell := range row {
+				out.WriteString(r.cell("td", cell, alignAt(block.Align, i)))
+			}
+			out.WriteString("</tr>\n")
+		}
+		out.WriteString("</tbody>\n</table>\n")
+
+	case Rule:
+		out.WriteString(r.inline.void("hr") + "\n")
+	}
+}
+
+// cell 渲染表格单元格
+func (r htmlRenderer) cell(tag, text, align string) string {
+	if align != "" {
+		return fmt.Sprintf("<%s style=\"text-align: %s\">%s</%s>", tag, align, r.inline.render(text), tag)
+	}
+	return fmt.Sprintf("<%s>%s</%s>", tag, r.inline.render(text), tag)
+}
+
+// alignAt 返回第 i 列的对齐方式
+func alignAt(align []string, i int) string {
+	if i < len(align) {
+		return align[i]
+	}
+	return ""
+}
+
+// Slugify 按 GitHub 的规则生成标题锚点：转为小写，去掉标点，空格替换为连字符
+func Slugify(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Slugger 为同一文档中的标题生成不重复的锚点，重复的锚点依次追加 -1、-2
+type Slugger struct {
+	seen map[string]int
+}
+
+// NewSlugger 创建新的锚点生成器
+func NewSlugger() *Slugger {
+	return &Slugger{seen: make(map[string]int)}
+}
+
+// Slug 返回标题的锚点
+func (s *Slugger) Slug(text string) string {
+	slug := Slugify(text)
+	n, ok := s.seen[slug]
+	s.seen[slug] = n + 1
+	if !ok {
+		return slug
+	}
+	for {
+		candidate := fmt.Sprintf("%s-%d", slug, n)
+		if _, taken := s.seen[candidate]; !taken {
+			s.seen[candidate] = 1
+			return candidate
+		}
+		n++
+		s.seen[slug] = n + 1
+	}
+}
+
+// HeadingInfo 描述文档中的一个标题
+type HeadingInfo struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+// Headings 返回文档中的标题及其锚点，锚点与 HeadingIDs 渲染结果一致（不包括列表和引用中的标题）
+func Headings(blocks []Block) []HeadingInfo {
+	slugs := NewSlugger()
+	var headings []HeadingInfo
+	for _, block := range blocks {
+		if block.Kind == Heading {
+			text := PlainText(block.Text)
+			headings = append(headings, HeadingInfo{Level: block.Level, Text: text, ID: slugs.Slug(text)})
+		}
+	}
+	return headings
+}
+
+// DocumentText 返回文档的纯文本内容，代码块只保留代码本身
+func DocumentText(blocks []Block) string {
+	var parts []string
+	var walk func([]Block)
+	walk = func(blocks []Block) {
+		for _, block := range blocks {
+			switch block.Kind {
+			case Heading, Paragraph:
+				parts = append(parts, PlainText(block.Text))
+			case CodeBlock:
+				parts = append(parts, block.Text)
+			case Quote:
+				walk(block.Children)
+			case List:
+				for _, item := range block.Items {
+					walk(item)
+				}
+			case Table:
+				parts = append(parts, strings.Join(block.Header, " "))
+				for _, row := range block.Rows {
+					parts = append(parts, PlainText(strings.Join(row, " ")))
+				}
+			}
+		}
+	}
+	walk(blocks)
+	return strings.Join(parts, "\n")
+}
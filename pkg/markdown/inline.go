@@ -0,0 +1,318 @@
+// pkg/markdown/inline.go
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// autolinkPattern 匹配正文中的裸 URL
+var autolinkPattern = regexp.MustCompile(`^https?://[^\s<>()\[\]]*[^\s<>()\[\].,;:!?'"]`)
+
+// inlineRenderer 将行内 Markdown 渲染为 HTML
+type inlineRenderer struct {
+	opts HTMLOptions
+}
+
+// render 渲染一段行内文本
+func (r inlineRenderer) render(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			out.WriteString(r.void("br") + "\n")
+			i += 2
+			continue
+
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!|~<>\"'", text[i+1]) >= 0:
+			out.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if code, next, ok := codeSpan(text, i); ok {
+				out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = next
+				continue
+			}
+
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if label, dest, next, ok := linkAt(text, i+1); ok {
+				out.WriteString(r.image(r.rewrite(dest), PlainText(label)))
+				i = next
+				continue
+			}
+
+		case c == '[':
+			if label, dest, next, ok := linkAt(text, i); ok {
+				out.WriteString(r.link(r.rewrite(dest), r.render(label)))
+				i = next
+				continue
+			}
+
+		case c == '<':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				if target := text[i+1 : i+end]; autolinkPattern.MatchString(target) && !strings.ContainsAny(target, " \n") {
+					out.WriteString(r.link(target, html.EscapeString(target)))
+					i += end + 1
+					continue
+				}
+			}
+
+		case c == 'h' && (i == 0 || !isWordByte(text[i-1])):
+			if m := autolinkPattern.FindString(text[i:]); m != "" {
+				out.WriteString(r.link(m, html.EscapeString(m)))
+				i += len(m)
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if rendered, next, ok := r.emphasis(text, i); ok {
+				out.WriteString(rendered)
+				i = next
+				continue
+			}
+
+		case c == '\n':
+			// 行尾两个空格表示硬换行
+			if strings.HasSuffix(out.String(), "  ") {
+				trimmed := strings.TrimRight(out.String(), " ")
+				out.Reset()
+				out.WriteString(trimmed + r.void("br"))
+			}
+			out.WriteByte('\n')
+			i++
+			continue
+		}
+		out.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+	return out.String()
+}
+
+// emphasis 渲染 **粗体**、*斜体*、~~删除线~~ 及其下划线形式
+func (r inlineRenderer) emphasis(text string, i int) (string, int, bool) {
+	c := text[i]
+	n := 1
+	for i+n < len(text) && text[i+n] == c && n < 3 {
+		n++
+	}
+	if c == '~' && n < 2 {
+		return "", 0, false
+	}
+	if c == '~' {
+		n = 2
+	}
+	// 下划线只在单词边界处生效，避免 snake_case 被误认为强调
+	if c == '_' && i > 0 && isWordByte(text[i-1]) {
+		return "", 0, false
+	}
+	start := i + n
+	if start >= len(text) || text[start] == ' ' || text[start] == '\n' {
+		return "", 0, false
+	}
+
+	delim := strings.Repeat(string(c), n)
+	for j := start + 1; j <= len(text)-n; j++ {
+		if text[j] == '`' {
+			if _, next, ok := codeSpan(text, j); ok {
+				j = next - 1
+				continue
+			}
+		}
+		if !strings.HasPrefix(text[j:], delim) || text[j-1] == ' ' || text[j-1] == '\\' {
+			continue
+		}
+		if c == '_' && j+n < len(text) && isWordByte(text[j+n]) {
+			continue
+		}
+		// 更长的分隔符属于外层
+		if j+n < len(text) && text[j+n] == c && n < 3 {
+			continue
+		}
+		inner := r.render(text[start:j])
+		switch {
+		case c == '~':
+			inner = "<del>" + inner + "</del>"
+		case n == 1:
+			inner = "<em>" + inner + "</em>"
+		case n == 2:
+			inner = "<strong>" + inner + "</strong>"
+		default:
+			inner = "<strong><em>" + inner + "</em></strong>"
+		}
+		return inner, j + n, true
+	}
+	return "", 0, false
+}
+
+// rewrite 使用调用方提供的函数改写链接目标
+func (r inlineRenderer) rewrite(dest string) string {
+	if r.opts.LinkURL != nil {
+		return r.opts.LinkURL(dest)
+	}
+	return dest
+}
+
+// link 渲染链接，目标不是安全的 URL 时只输出链接文字
+func (r inlineRenderer) link(dest, body string) string {
+	if !SafeURL(dest) {
+		return body
+	}
+	if r.opts.Link != nil {
+		if rendered, ok := r.opts.Link(dest, body); ok {
+			return rendered
+		}
+	}
+	return `<a href="` + html.EscapeString(dest) + `">` + body + "</a>"
+}
+
+// image 渲染图片，地址不是安全的 URL 时只输出替代文字
+func (r inlineRenderer) image(src, alt string) string {
+	if !SafeURL(src) {
+		return html.EscapeString(alt)
+	}
+	if r.opts.Image != nil {
+		if rendered, ok := r.opts.Image(src, alt); ok {
+			return rendered
+		}
+	}
+	tag := `<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `"`
+	if r.opts.XHTML {
+		return tag + " />"
+	}
+	return tag + ">"
+}
+
+// safeURLSchemes 是链接和图片允许使用的协议，其他协议（例如 javascript:、data:）可能执行脚本
+var safeURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// SafeURL 判断链接目标是否为相对地址、页内锚点或使用允许的协议
+func SafeURL(dest string) bool {
+	// 浏览器解析 URL 时会忽略空白和控制字符，例如 "java\tscript:"
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, html.UnescapeString(dest))
+
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 {
+		return true
+	}
+	// 冒号出现在路径、查询或锚点中时是相对地址
+	if strings.ContainsAny(cleaned[:colon], "/?#") {
+		return true
+	}
+	return safeURLSchemes[strings.ToLower(cleaned[:colon])]
+}
+
+// void 渲染空元素，XHTML 模式下自闭合
+func (r inlineRenderer) void(tag string) string {
+	if r.opts.XHTML {
+		return "<" + tag + " />"
+	}
+	return "<" + tag + ">"
+}
+
+// codeSpan 解析从 i 开始的行内代码，返回代码内容和结束位置
+func codeSpan(text string, i int) (string, int, bool) {
+	n := 0
+	for i+n < len(text) && text[i+n] == '`' {
+		n++
+	}
+	delim := strings.Repeat("`", n)
+	for j := i + n; j < len(text); {
+		k := strings.Index(text[j:], delim)
+		if k < 0 {
+			return "", 0, false
+		}
+		k += j
+		end := k + n
+		if end < len(text) && text[end] == '`' {
+			// 反引号数量不同，继续查找
+			for end < len(text) && text[end] == '`' {
+				end++
+			}
+			j = end
+			continue
+		}
+		code := strings.ReplaceAll(text[i+n:k], "\n", " ")
+		if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		return code, end, true
+	}
+	return "", 0, false
+}
+
+// linkAt 解析从 i 开始的 [label](dest "title") 形式的链接
+func linkAt(text string, i int) (label, dest string, next int, ok bool) {
+	depth := 0
+	closeBracket := -1
+	for j := i; j < len(text); j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '`':
+			if _, end, ok := codeSpan(text, j); ok {
+				j = end - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			closeBracket = j
+			break
+		}
+	}
+	if closeBracket < 0 || closeBracket+1 >= len(text) || text[closeBracket+1] != '(' {
+		return "", "", 0, false
+	}
+
+	depth = 0
+	for j := closeBracket + 1; j < len(text); j++ {
+		switch text[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\n':
+			return "", "", 0, false
+		}
+		if depth == 0 {
+			target := strings.TrimSpace(text[closeBracket+2 : j])
+			// 去掉可选的标题
+			if k := strings.IndexAny(target, " \t"); k >= 0 {
+				target = target[:k]
+			}
+			target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+			return text[i+1 : closeBracket], target, j + 1, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// isWordByte 判断字节是否属于单词（字母、数字或非 ASCII 字符）
+func isWordByte(b byte) bool {
+	return b == '_' || b >= 0x80 || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// tagPattern 匹配 HTML 标签
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// InlineHTML 将行内 Markdown 渲染为 HTML，不识别块级元素
+func InlineHTML(text string, opts HTMLOptions) string {
+	return inlineRenderer{opts: opts}.render(text)
+}
+
+// PlainText 将行内 Markdown 转换为纯文本，用于标题锚点和搜索索引
+func PlainText(text string) string {
+	rendered := inlineRenderer{}.render(text)
+	return html.UnescapeString(tagPattern.ReplaceAllString(rendered, ""))
+}
@@ -0,0 +1,283 @@
+// pkg/markdown/markdown.go
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind 表示块级元素的类型
+type Kind int
+
+// 支持的块级元素
+const (
+	Paragraph Kind = iota
+	Heading
+	CodeBlock
+	Quote
+	List
+	Table
+	Rule
+)
+
+// Block 表示解析后的一个块级元素
+type Block struct {
+	Kind     Kind
+	Level    int       // 标题级别 1-6
+	Text     string    // 段落或标题的行内文本，代码块的代码
+	Lang     string    // 代码块语言
+	Ordered  bool      // 是否为有序列表
+	Start    int       // 有序列表的起始编号
+	Items    [][]Block // 列表项，每项包含若干块
+	Children []Block   // 引用块的内容
+	Header   []string  // 表头单元格
+	Align    []string  // 每列的对齐方式: "", "left", "center", "right"
+	Rows     [][]string
+}
+
+var (
+	headingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern      = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`]*)$")
+	listItemPattern  = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])([ \t]+|$)`)
+	tableDelimiter   = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	setextUnderline  = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	blockquotePrefix = regexp.MustCompile(`^ {0,3}> ?`)
+)
+
+// Parse 将 Markdown 文本解析为块级元素列表
+// 支持 ATX/Setext 标题、段落、围栏代码块、引用、有序和无序列表（可嵌套）、GFM 表格和分隔线
+func Parse(src string) []Block {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	return parseLines(strings.Split(src, "\n"))
+}
+
+// parseLines 解析一组行
+func parseLines(lines []string) []Block {
+	var blocks []Block
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fencePattern.MatchString(line):
+			block, next := parseFence(lines, i)
+			blocks = append(blocks, block)
+			i = next
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			blocks = append(blocks, Block{Kind: Heading, Level: len(m[1]), Text: strings.TrimSpace(m[2])})
+			i++
+
+		case rulePattern.MatchString(line):
+			blocks = append(blocks, Block{Kind: Rule})
+			i++
+
+		case blockquotePrefix.MatchString(line):
+			var inner []string
+			for i < len(lines) && blockquotePrefix.MatchString(lines[i]) {
+				inner = append(inner, blockquotePrefix.ReplaceAllString(lines[i], ""))
+				i++
+			}
+			blocks = append(blocks, Block{Kind: Quote, Children: parseLines(inner)})
+
+		case listItemPattern.MatchString(line):
+			block, next := parseList(lines, i)
+			blocks = append(blocks, block)
+			i = next
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableDelimiter.MatchString(lines[i+1]):
+			block, next := parseTable(lines, i)
+			blocks = append(blocks, block)
+			i = next
+
+		default:
+			block, next := parseParagraph(lines, i)
+			blocks = append(blocks, block)
+			i = next
+		}
+	}
+	return blocks
+}
+
+// parseFence 解析围栏代码块，未闭合的代码块延续到末尾
+func parseFence(lines []string, start int) (Block, int) {
+	m := fencePattern.FindStringSubmatch(lines[start])
+	indent, fence := len(m[1]), m[2]
+	lang := strings.Fields(m[3])
+	block := Block{Kind: CodeBlock}
+	if len(lang) > 0 {
+		block.Lang = strings.ToLower(lang[0])
+	}
+
+	var code []string
+	i := start + 1
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence[:1]) && strings.Trim(trimmed, fence[:1]) == "" && len(trimmed) >= len(fence) {
+			i++
+			break
+		}
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+	block.Text = strings.Join(code, "\n")
+	return block, i
+}
+
+// parseList 解析一个列表，缩进的后续行属于当前列表项，可包含嵌套列表
+func parseList(lines []string, start int) (Block, int) {
+	first := listItemPattern.FindStringSubmatch(lines[start])
+	ordered := !strings.ContainsAny(first[2][:1], "-*+")
+	block := Block{Kind: List, Ordered: ordered, Start: 1}
+	if ordered {
+		block.Start, _ = strconv.Atoi(strings.TrimRight(first[2], ".)"))
+	}
+
+	i := start
+	for i < len(lines) {
+		m := listItemPattern.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) > len(first[1])+1 || isOrderedMarker(m[2]) != ordered {
+			break
+		}
+		contentIndent := len(m[0])
+		if strings.TrimSpace(m[3]) == "" && len(m[3]) == 0 {
+			contentIndent = len(m[1]) + len(m[2]) + 1
+		}
+		item := []string{lines[i][len(m[0]):]}
+		i++
+
+		for i < len(lines) {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// 空行后仍有缩进内容时属于同一列表项
+				if i+1 < len(lines) && indentation(lines[i+1]) >= contentIndent {
+					item = append(item, "")
+					i++
+					continue
+				}
+				break
+			}
+			if indentation(line) >= contentIndent {
+				item = append(item, line[contentIndent:])
+				i++
+				continue
+			}
+			if listItemPattern.MatchString(line) || startsBlock(line) {
+				break
+			}
+			// 段落的惰性续行
+			item = append(item, strings.TrimSpace(line))
+			i++
+		}
+		block.Items = append(block.Items, parseLines(item))
+
+		// 列表项之间的空行
+		if i < len(lines) && strings.TrimSpace(lines[i]) == "" && i+1 < len(lines) && listItemPattern.MatchString(lines[i+1]) {
+			i++
+		}
+	}
+	return block, i
+}
+
+// parseTable 解析 GFM 表格
+func parseTable(lines []string, start int) (Block, int) {
+	block := Block{Kind: Table, Header: splitTableRow(lines[start])}
+	for _, cell := range splitTableRow(lines[start+1]) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			block.Align = append(block.Align, "center")
+		case right:
+			block.Align = append(block.Align, "right")
+		case left:
+			block.Align = append(block.Align, "left")
+		default:
+			block.Align = append(block.Align, "")
+		}
+	}
+
+	i := start + 2
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		row := splitTableRow(lines[i])
+		for len(row) < len(block.Header) {
+			row = append(row, "")
+		}
+		block.Rows = append(block.Rows, row[:len(block.Header)])
+	}
+	return block, i
+}
+
+// parseParagraph 解析段落，遇到空行或其他块的开始时结束；下一行是 === 或 --- 时为 Setext 标题
+func parseParagraph(lines []string, start int) (Block, int) {
+	// 保留行尾空格，用于识别硬换行
+	text := []string{strings.TrimLeft(lines[start], " ")}
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if m := setextUnderline.FindStringSubmatch(line); m != nil {
+			level := 1
+			if strings.HasPrefix(m[1], "-") {
+				level = 2
+			}
+			return Block{Kind: Heading, Level: level, Text: strings.TrimSpace(strings.Join(text, "\n"))}, i + 1
+		}
+		if strings.TrimSpace(line) == "" || startsBlock(line) || listItemPattern.MatchString(line) {
+			break
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+	return Block{Kind: Paragraph, Text: strings.TrimRight(strings.Join(text, "\n"), " ")}, i
+}
+
+// startsBlock 判断一行是否开始一个会打断段落的块
+func startsBlock(line string) bool {
+	return fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) || blockquotePrefix.MatchString(line)
+}
+
+// splitTableRow 将表格行拆分为单元格，支持 \| 转义
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	inCode := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '`':
+			inCode = !inCode
+			cell.WriteByte('`')
+		case line[i] == '|' && !inCode:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// indentation 返回行首空格数
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// isOrderedMarker 判断列表标记是否为有序列表标记
+func isOrderedMarker(marker string) bool {
+	return !strings.ContainsAny(marker[:1], "-*+")
+}
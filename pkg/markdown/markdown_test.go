@@ -0,0 +1,100 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToHTML(t *testing.T) {
+	src := "# Hello World\n\nUse `go run` with *care*, **really** and snake_case_names.  \nSee [the API](#api) or https://example.com/a.\n\n" +
+		"- one\n- two\n  - nested\n\n| Name | Size |\n|:-----|-----:|\n| `a|b` | 2 |\n\n> quoted <b>\n\n" +
+		"```go\n// comment\nreturn \"s\"\n```\n\n# Hello World\n"
+	out := ToHTML(src, HTMLOptions{HeadingIDs: true, LinkURL: func(dest string) string {
+		return strings.Replace(dest, "#api", "api.html", 1)
+	}})
+
+	for _, want := range []string{
+		`<h1 id="hello-world">Hello World</h1>`,
+		`<code>go run</code> with <em>care</em>, <strong>really</strong> and snake_case_names.<br>`,
+		`<a href="api.html">the API</a>`,
+		`<a href="https://example.com/a">https://example.com/a</a>.`,
+		"<li>two\n<ul>\n<li>nested</li>",
+		`<td style="text-align: left"><code>a|b</code></td><td style="text-align: right">2</td>`,
+		"<blockquote>\n<p>quoted &lt;b&gt;</p>",
+		`<span class="c">// comment</span>` + "\n" + `<span class="k">return</span> <span class="s">&#34;s&#34;</span>`,
+		`<h1 id="hello-world-1">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Hello World":          "hello-world",
+		"API: v2 (beta)!":      "api-v2-beta",
+		"snake_case & co":      "snake_case--co",
+		"  Trim me  ":          "trim-me",
+		"项目概述 Overview":        "项目概述-overview",
+		"C++ / Go":             "c--go",
+		"already-slugged-text": "already-slugged-text",
+		"Section 1.2":          "section-12",
+		"Deploy 🚀 now":         "deploy--now",
+		"`Config` struct":      "config-struct",
+	}
+	for in, want := range cases {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	s := NewSlugger()
+	got := []string{s.Slug("Intro"), s.Slug("Intro"), s.Slug("Intro-1"), s.Slug("Intro")}
+	if strings.Join(got, " ") != "intro intro-1 intro-1-1 intro-2" {
+		t.Errorf("unexpected duplicate slugs: %v", got)
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	src := "See [a](#a \"title\") and ![img](#a), `[code](#a)`.\n\n```md\n[fenced](#a)\n```\n[[1]](https://example.com)\n"
+	got := RewriteLinks(src, func(dest string) string {
+		if dest == "#a" {
+			return "a.md"
+		}
+		return dest
+	})
+	want := "See [a](a.md \"title\") and ![img](a.md), `[code](#a)`.\n\n```md\n[fenced](#a)\n```\n[[1]](https://example.com)\n"
+	if got != want {
+		t.Errorf("RewriteLinks:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnsafeLinks(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "[click](javascript:alert(1))", want: "<p>click</p>"},
+		{src: "[click](JavaScript:alert(1))", want: "<p>click</p>"},
+		{src: "[click](&#106;avascript:alert(1))", want: "<p>click</p>"},
+		{src: "[click](data:text/html;base64,PHNjcmlwdD4=)", want: "<p>click</p>"},
+		{src: "![x<y](vbscript:msgbox)", want: "<p>x&lt;y</p>"},
+		{src: "[mail](mailto:dev@example.com)", want: `<p><a href="mailto:dev@example.com">mail</a></p>`},
+		{src: "[page](docs/setup.md#install)", want: `<p><a href="docs/setup.md#install">page</a></p>`},
+		{src: "[time](guide?at=10:30)", want: `<p><a href="guide?at=10:30">time</a></p>`},
+		{src: "[top](#top)", want: `<p><a href="#top">top</a></p>`},
+		{src: "![logo](https://example.com/logo.png)", want: `<p><img src="https://example.com/logo.png" alt="logo"></p>`},
+	}
+	for _, tt := range tests {
+		if got := strings.TrimSpace(ToHTML(tt.src, HTMLOptions{})); got != tt.want {
+			t.Errorf("ToHTML(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	// 改写后的目标同样需要检查
+	got := ToHTML("[a](#a)", HTMLOptions{LinkURL: func(string) string { return "javascript:void(0)" }})
+	if strings.Contains(got, "javascript") {
+		t.Errorf("rewritten unsafe link rendered: %s", got)
+	}
+}
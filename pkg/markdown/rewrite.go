@@ -0,0 +1,64 @@
+// pkg/markdown/rewrite.go
+package markdown
+
+import "strings"
+
+// RewriteLinks 改写 Markdown 源文本中链接和图片的目标地址，代码块和行内代码中的内容保持不变
+// rewrite 返回新的目标地址，返回原值表示不改写
+func RewriteLinks(src string, rewrite func(dest string) string) string {
+	var out strings.Builder
+	lines := strings.SplitAfter(src, "\n")
+	var fence string
+	for _, line := range lines {
+		if m := fencePattern.FindStringSubmatch(strings.TrimRight(line, "\n")); m != nil && (fence == "" || strings.HasPrefix(m[2], fence) && strings.TrimSpace(m[3]) == "") {
+			if fence == "" {
+				fence = m[2]
+			} else {
+				fence = ""
+			}
+			out.WriteString(line)
+			continue
+		}
+		if fence != "" {
+			out.WriteString(line)
+			continue
+		}
+		out.WriteString(rewriteLine(line, rewrite))
+	}
+	return out.String()
+}
+
+// rewriteLine 改写一行中的链接目标
+func rewriteLine(line string, rewrite func(dest string) string) string {
+	var out strings.Builder
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case '\\':
+			end := min(i+2, len(line))
+			out.WriteString(line[i:end])
+			i = end
+			continue
+		case '`':
+			if _, next, ok := codeSpan(line, i); ok {
+				out.WriteString(line[i:next])
+				i = next
+				continue
+			}
+		case '[':
+			if label, dest, next, ok := linkAt(line, i); ok {
+				// 保留原有的标题等内容，只替换目标地址
+				raw := line[i:next]
+				target := raw[len(label)+2:]
+				if newDest := rewrite(dest); newDest != dest && dest != "" {
+					target = strings.Replace(target, dest, newDest, 1)
+				}
+				out.WriteString("[" + rewriteLine(label, rewrite) + "]" + target)
+				i = next
+				continue
+			}
+		}
+		out.WriteByte(line[i])
+		i++
+	}
+	return out.String()
+}
@@ -0,0 +1,344 @@
+// pkg/mermaid/mermaid.go
+package mermaid
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SyntaxError 表示 Mermaid 图表中的语法错误
+type SyntaxError struct {
+	Line    int // 从 1 开始的行号，0 表示整体错误
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("第 %d 行: %s", e.Line, e.Message)
+}
+
+// Block 表示 Markdown 中的一个 Mermaid 代码块
+type Block struct {
+	Open  int    // 起始 ``` 行在原文中的偏移
+	Start int    // 代码块内容在原文中的起始偏移
+	End   int    // 代码块内容在原文中的结束偏移（不含）
+	Code  string // 代码块内容
+}
+
+// ExtractBlocks 提取 Markdown 中所有 ```mermaid 代码块
+func ExtractBlocks(markdown string) []Block {
+	var blocks []Block
+	offset := 0
+	inBlock := false
+	inOtherFence := false
+	open, start := 0, 0
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inBlock && strings.HasPrefix(trimmed, "```"):
+			blocks = append(blocks, Block{Open: open, Start: start, End: offset, Code: markdown[start:offset]})
+			inBlock = false
+		case inOtherFence && strings.HasPrefix(trimmed, "```"):
+			inOtherFence = false
+		case !inBlock && !inOtherFence && strings.HasPrefix(trimmed, "```"):
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(trimmed, "```")), "mermaid") {
+				inBlock = true
+				open = offset
+				start = offset + len(line)
+			} else {
+				inOtherFence = true
+			}
+		}
+		offset += len(line)
+	}
+	return blocks
+}
+
+// EscapeLabel 将文本转换为带引号的节点标签，可安全地用于 id["label"] 形式
+func EscapeLabel(label string) string {
+	label = strings.ReplaceAll(label, "\n", " ")
+	label = strings.ReplaceAll(label, `"`, "#quot;")
+	return `"` + label + `"`
+}
+
+// Validate 校验 Mermaid 图表语法，支持 graph/flowchart、sequenceDiagram 和 classDiagram
+func Validate(src string) error {
+	lines := strings.Split(src, "\n")
+	header := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
+			continue
+		}
+		header = i
+		break
+	}
+	if header < 0 {
+		return &SyntaxError{Message: "图表内容为空"}
+	}
+
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(lines[header]), ";"))
+	if len(fields) == 0 {
+		return &SyntaxError{Line: header + 1, Message: "缺少图表类型"}
+	}
+	body := lines[header+1:]
+	switch fields[0] {
+	case "graph", "flowchart":
+		if len(fields) > 2 || (len(fields) == 2 && !flowDirections[fields[1]]) {
+			return &SyntaxError{Line: header + 1, Message: fmt.Sprintf("无效的图表方向 %q", strings.Join(fields[1:], " "))}
+		}
+		return validateBody(body, header+1, validateFlowchartLine)
+	case "sequenceDiagram":
+		return validateBody(body, header+1, validateSequenceLine)
+	case "classDiagram":
+		return validateBody(body, header+1, validateClassLine)
+	}
+	return &SyntaxError{Line: header + 1, Message: fmt.Sprintf("不支持的图表类型 %q，请使用 graph/flowchart、sequenceDiagram 或 classDiagram", fields[0])}
+}
+
+// lineState 记录校验过程中的块嵌套状态
+type lineState struct {
+	blocks []string // 未闭合的块关键字
+}
+
+func (s *lineState) open(keyword string) { s.blocks = append(s.blocks, keyword) }
+
+func (s *lineState) close() bool {
+	if len(s.blocks) == 0 {
+		return false
+	}
+	s.blocks = s.blocks[:len(s.blocks)-1]
+	return true
+}
+
+func (s *lineState) top() string {
+	if len(s.blocks) == 0 {
+		return ""
+	}
+	return s.blocks[len(s.blocks)-1]
+}
+
+// validateBody 逐行校验图表主体，跳过空行和注释，并检查块是否闭合
+func validateBody(lines []string, lineOffset int, validateLine func(line string, state *lineState) string) error {
+	state := &lineState{}
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		if msg := validateLine(line, state); msg != "" {
+			return &SyntaxError{Line: lineOffset + i + 1, Message: msg}
+		}
+	}
+	if len(state.blocks) > 0 {
+		return &SyntaxError{Message: fmt.Sprintf("%s 块缺少对应的 end", state.top())}
+	}
+	return nil
+}
+
+var flowDirections = map[string]bool{"TD": true, "TB": true, "BT": true, "RL": true, "LR": true}
+
+var (
+	flowNodeID       = regexp.MustCompile(`^[A-Za-z0-9_]+(?:[.\-][A-Za-z0-9_]+)*`)
+	flowEdge         = regexp.MustCompile(`^(?:<?(?:-{2,}|={2,}|-\.+-|~{3,})[->ox]?)`)
+	flowEdgeText     = regexp.MustCompile(`^\|[^|]*\|`)
+	flowInlineText   = regexp.MustCompile(`^(?:--|==|-\.)\s+[^\-=.>]+?\s+(?:-{2,}>|-{3,}|={2,}>|={3,}|\.->|\.-)`)
+	flowStatementKey = regexp.MustCompile(`^(classDef|class|style|linkStyle|click|direction)\s`)
+)
+
+// flowShapes 是节点形状的起止符号，按起始符号长度从长到短排列
+var flowShapes = [][2]string{
+	{"(((", ")))"}, {"([", "])"}, {"[[", "]]"}, {"[(", ")]"}, {"((", "))"}, {"{{", "}}"},
+	{"[/", "/]"}, {"[/", `\]`}, {`[\`, `\]`}, {`[\`, "/]"}, {"[", "]"}, {"(", ")"}, {"{", "}"}, {">", "]"},
+}
+
+// validateFlowchartLine 校验流程图中的一行
+func validateFlowchartLine(line string, state *lineState) string {
+	line = strings.TrimSuffix(line, ";")
+	switch {
+	case line == "end":
+		if !state.close() {
+			return "多余的 end"
+		}
+		return ""
+	case strings.HasPrefix(line, "subgraph"):
+		state.open("subgraph")
+		return ""
+	case flowStatementKey.MatchString(line + " "):
+		return ""
+	}
+
+	rest := line
+	expectNode := true
+	for {
+		rest = strings.TrimSpace(rest)
+		if expectNode {
+			var msg string
+			rest, msg = parseFlowNode(rest)
+			if msg != "" {
+				return msg
+			}
+			expectNode = false
+			continue
+		}
+		if rest == "" {
+			return ""
+		}
+		if strings.HasPrefix(rest, "&") {
+			rest = rest[1:]
+			expectNode = true
+			continue
+		}
+		if m := flowInlineText.FindString(rest); m != "" {
+			rest = rest[len(m):]
+			expectNode = true
+			continue
+		}
+		m := flowEdge.FindString(rest)
+		if m == "" {
+			return fmt.Sprintf("无法解析 %q，节点标签中的特殊字符需要用双引号包裹", truncate(rest))
+		}
+		rest = strings.TrimSpace(rest[len(m):])
+		if t := flowEdgeText.FindString(rest); t != "" {
+			rest = rest[len(t):]
+		}
+		expectNode = true
+		if strings.TrimSpace(rest) == "" {
+			return "连线缺少目标节点"
+		}
+	}
+}
+
+// parseFlowNode 解析一个节点 ID 及其可选的形状和标签，返回剩余内容
+func parseFlowNode(s string) (string, string) {
+	id := flowNodeID.FindString(s)
+	if id == "" {
+		return s, fmt.Sprintf("缺少节点 ID: %q", truncate(s))
+	}
+	rest := s[len(id):]
+	for _, shape := range flowShapes {
+		if !strings.HasPrefix(rest, shape[0]) {
+			continue
+		}
+		inner := rest[len(shape[0]):]
+		if strings.HasPrefix(inner, `"`) {
+			end := strings.Index(inner[1:], `"`)
+			if end < 0 {
+				return rest, fmt.Sprintf("节点 %s 的标签缺少结束引号", id)
+			}
+			after := inner[end+2:]
+			if !strings.HasPrefix(after, shape[1]) {
+				continue
+			}
+			return after[len(shape[1]):], ""
+		}
+		end := strings.Index(inner, shape[1])
+		if end < 0 {
+			continue
+		}
+		label := inner[:end]
+		if strings.ContainsAny(label, `[](){}"|<>`) {
+			return rest, fmt.Sprintf("节点 %s 的标签 %q 包含特殊字符，需要用双引号包裹", id, label)
+		}
+		if shape[0] == "[" && (strings.HasSuffix(label, "/") || strings.HasSuffix(label, `\`) || strings.HasPrefix(label, "/") || strings.HasPrefix(label, `\`)) {
+			return rest, fmt.Sprintf("节点 %s 的标签 %q 以斜杠开头或结尾，需要用双引号包裹", id, label)
+		}
+		return inner[end+len(shape[1]):], ""
+	}
+	if strings.ContainsAny(rest[:min(1, len(rest))], "[({>") {
+		return rest, fmt.Sprintf("节点 %s 的形状没有闭合", id)
+	}
+	return rest, ""
+}
+
+var (
+	sequenceMessage     = regexp.MustCompile(`^[^\s:>\-]+(?:\s+[^\s:>\-]+)*\s*(?:->>|-->>|->|-->|-x|--x|-\)|--\))[+-]?\s*[^\s:]+(?:\s+[^\s:]+)*\s*(?::.*)?$`)
+	sequenceParticipant = regexp.MustCompile(`^(participant|actor)\s+\S+(?:\s+as\s+.+)?$`)
+	sequenceNote        = regexp.MustCompile(`^[Nn]ote\s+(left of|right of|over)\s+[^:]+:.*$`)
+	sequenceBlockOpen   = regexp.MustCompile(`^(loop|alt|opt|par|critical|break|rect)\b`)
+	sequenceSimple      = regexp.MustCompile(`^(autonumber|title\b.*|activate\s+\S+|deactivate\s+\S+|box\b.*)$`)
+)
+
+// validateSequenceLine 校验时序图中的一行
+func validateSequenceLine(line string, state *lineState) string {
+	switch {
+	case line == "end":
+		if !state.close() {
+			return "多余的 end"
+		}
+		return ""
+	case sequenceBlockOpen.MatchString(line):
+		state.open(sequenceBlockOpen.FindString(line))
+		return ""
+	case line == "else" || strings.HasPrefix(line, "else "):
+		if state.top() != "alt" {
+			return "else 只能出现在 alt 块中"
+		}
+		return ""
+	case line == "and" || strings.HasPrefix(line, "and "):
+		if state.top() != "par" {
+			return "and 只能出现在 par 块中"
+		}
+		return ""
+	case line == "option" || strings.HasPrefix(line, "option "):
+		if state.top() != "critical" {
+			return "option 只能出现在 critical 块中"
+		}
+		return ""
+	case sequenceParticipant.MatchString(line), sequenceNote.MatchString(line), sequenceSimple.MatchString(line):
+		return ""
+	case sequenceMessage.MatchString(line):
+		return ""
+	}
+	return fmt.Sprintf("无法解析的时序图语句 %q", truncate(line))
+}
+
+var (
+	classRelation   = regexp.MustCompile(`^[\w.~<>,]+\s*(?:"[^"]*"\s*)?(?:<\|--|--\|>|\*--|--\*|o--|--o|<--|-->|<\.\.|\.\.>|\.\.\|>|<\|\.\.|--|\.\.)\s*(?:"[^"]*"\s*)?[\w.~<>,]+\s*(?::.*)?$`)
+	classDeclare    = regexp.MustCompile(`^class\s+[\w.]+(?:~[\w,\s<>]+~)?(?:\s*\[".*"\])?\s*(\{)?$`)
+	classMember     = regexp.MustCompile(`^[\w.]+\s*:\s*.+$`)
+	classAnnotation = regexp.MustCompile(`^<<\w+>>\s*[\w.]*$`)
+	classSimple     = regexp.MustCompile(`^(direction\s+(TB|BT|LR|RL)|note\b.*|classDef\b.*|style\b.*|cssClass\b.*|click\b.*|link\b.*|callback\b.*|namespace\s+[\w.]+\s*\{)$`)
+)
+
+// validateClassLine 校验类图中的一行
+func validateClassLine(line string, state *lineState) string {
+	if state.top() == "class" {
+		// 类体内的成员定义格式较自由，只检查闭合
+		if line == "}" {
+			state.close()
+		}
+		return ""
+	}
+	switch {
+	case line == "}":
+		if !state.close() {
+			return "多余的 }"
+		}
+		return ""
+	case classDeclare.MatchString(line):
+		if strings.HasSuffix(line, "{") {
+			state.open("class")
+		}
+		return ""
+	case classSimple.MatchString(line):
+		if strings.HasPrefix(line, "namespace") {
+			state.open("namespace")
+		}
+		return ""
+	case classAnnotation.MatchString(line), classRelation.MatchString(line), classMember.MatchString(line):
+		return ""
+	}
+	return fmt.Sprintf("无法解析的类图语句 %q", truncate(line))
+}
+
+// truncate 截断错误信息中引用的过长内容
+func truncate(s string) string {
+	if r := []rune(s); len(r) > 40 {
+		return string(r[:40]) + "..."
+	}
+	return s
+}
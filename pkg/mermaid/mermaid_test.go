@@ -0,0 +1,59 @@
+package mermaid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		"graph TD\n    Root[\"项目根目录\"]\n    Root --> Dir0[\"src/\"]\n    Dir0 --> Dir0_0[\"main.go\"]\n    classDef dir fill:#bbf;\n    class Dir0 dir;",
+		"flowchart LR\n  A[Client] -->|HTTP| B(API) & C{Cache}\n  subgraph backend\n    B -.-> D[(DB)]\n  end\n  C -- miss --> D",
+		"sequenceDiagram\n  participant C as Client\n  C->>S: request\n  alt ok\n    S-->>C: 200\n  else error\n    S--xC: 500\n  end\n  Note over C,S: done",
+		"classDiagram\n  class Server {\n    +router *gin.Engine\n    +Start() error\n  }\n  Server --> RAGManager : uses\n  RAGProvider <|.. OpenAIRAG\n  <<interface>> RAGProvider",
+	}
+	for _, src := range valid {
+		if err := Validate(src); err != nil {
+			t.Errorf("expected valid diagram, got %v:\n%s", err, src)
+		}
+	}
+
+	invalid := []string{
+		"",
+		";",
+		"%% comment\n  ;\n  A --> B",
+		"pie\n  \"a\" : 1",
+		"graph XY\n  A --> B",
+		"graph TD\n  Root[项目根目录] --> Dir0[src/]",
+		"graph TD\n  A[main(args)] --> B",
+		"graph TD\n  A --> ",
+		"graph TD\n  subgraph x\n  A --> B",
+		"sequenceDiagram\n  loop forever\n    A->>B: hi",
+		"sequenceDiagram\n  else nope",
+		"classDiagram\n  Server ->> Client",
+	}
+	for _, src := range invalid {
+		if err := Validate(src); err == nil {
+			t.Errorf("expected invalid diagram:\n%s", src)
+		}
+	}
+}
+
+func TestExtractBlocksAndEscapeLabel(t *testing.T) {
+	content := "# 标题\n\n```mermaid\ngraph TD\n  A --> B\n```\n\n```go\n```mermaid\n```\n"
+	blocks := ExtractBlocks(content)
+	if len(blocks) != 1 {
+		t.Fatalf("expected 1 block, got %d", len(blocks))
+	}
+	if blocks[0].Code != "graph TD\n  A --> B\n" || !strings.HasPrefix(content[blocks[0].Open:], "```mermaid") {
+		t.Errorf("unexpected block: %+v", blocks[0])
+	}
+
+	label := EscapeLabel(`say "hi" [x]`)
+	if label != `"say #quot;hi#quot; [x]"` {
+		t.Errorf("unexpected label: %s", label)
+	}
+	if err := Validate("graph TD\n  A[" + label + "] --> B"); err != nil {
+		t.Errorf("escaped label rejected: %v", err)
+	}
+}
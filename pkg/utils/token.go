@@ -22,3 +22,17 @@ func CountTokens(text string, model string) int {
 	log.Printf("Token count for text (model: %s): %d", model, count)
 	return count
 }
+
+// EstimateTokens 不加载分词器快速估算 token 数：ASCII 文本约 4 个字符一个 token，其他字符（如中文）每个字符一个 token
+// 用于对话历史预算等只需要近似值的场景
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < 0x80 {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}